@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/config"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+// The admin API manages scheduling data that would otherwise need direct
+// Supabase access. Every route requires "Authorization: Bearer <ADMIN_API_TOKEN>".
+const (
+	adminPathPrefix         = "/admin/"
+	adminPropertyAgentsPath = "/admin/property-agents"
+	adminDoNotContactPath   = "/admin/do-not-contact"
+)
+
+// handleAdmin authenticates and routes an /admin request
+func handleAdmin(ctx context.Context, cfg config.Config, event json.RawMessage, path string, query url.Values) LambdaResponse {
+	if cfg.AdminAPIToken == "" {
+		return errorResponse(404, "Not found")
+	}
+	if !adminAuthorized(cfg, event) {
+		slog.WarnContext(ctx, "admin_unauthorized", "path", path)
+		return errorResponse(401, "Unauthorized")
+	}
+
+	method := requestMethod(event)
+	switch path {
+	case adminPropertyAgentsPath:
+		return handleAdminPropertyAgents(ctx, cfg, event, method, query)
+	case adminDoNotContactPath:
+		return handleAdminDoNotContact(ctx, cfg, event, method, query)
+	}
+	return errorResponse(404, "Not found")
+}
+
+// adminAuthorized reports whether event carries the admin bearer token.
+// Without ADMIN_API_TOKEN nothing is authorized.
+func adminAuthorized(cfg config.Config, event json.RawMessage) bool {
+	if cfg.AdminAPIToken == "" {
+		return false
+	}
+	auth := requestHeader(event, "Authorization")
+	return subtle.ConstantTimeCompare([]byte(auth), []byte("Bearer "+cfg.AdminAPIToken)) == 1
+}
+
+// handleAdminPropertyAgents lists (GET), sets (PUT) and removes
+// (DELETE ?property_id=) property → agent overrides
+func handleAdminPropertyAgents(ctx context.Context, cfg config.Config, event json.RawMessage, method string, query url.Values) LambdaResponse {
+	supa := newSupabaseClient(cfg)
+
+	switch method {
+	case "GET":
+		overrides, err := supa.ListPropertyAgentOverrides(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "admin_overrides_fetch_failed", "error", err)
+			return errorResponse(502, "Failed to read overrides")
+		}
+		if overrides == nil {
+			overrides = []models.PropertyAgentOverride{}
+		}
+		return adminJSON(200, overrides)
+
+	case "PUT":
+		var override models.PropertyAgentOverride
+		body, _, ok := extractHTTP(event)
+		if !ok || json.Unmarshal(body, &override) != nil {
+			return errorResponse(400, "Invalid JSON body")
+		}
+		override.PropertyID = strings.TrimSpace(override.PropertyID)
+		override.AgentEmail = strings.ToLower(strings.TrimSpace(override.AgentEmail))
+		if override.PropertyID == "" || !strings.Contains(override.AgentEmail, "@") {
+			return errorResponse(400, "property_id and agent_email are required")
+		}
+		if err := supa.SavePropertyAgentOverride(ctx, override); err != nil {
+			slog.ErrorContext(ctx, "admin_override_save_failed", "property_id", override.PropertyID, "error", err)
+			return errorResponse(502, "Failed to save override")
+		}
+		slog.InfoContext(ctx, "admin_override_saved", "property_id", override.PropertyID, "agent", override.AgentEmail)
+		return adminJSON(200, override)
+
+	case "DELETE":
+		propertyID := strings.TrimSpace(query.Get("property_id"))
+		if propertyID == "" {
+			return errorResponse(400, "property_id is required")
+		}
+		if err := supa.DeletePropertyAgentOverride(ctx, propertyID); err != nil {
+			slog.ErrorContext(ctx, "admin_override_delete_failed", "property_id", propertyID, "error", err)
+			return errorResponse(502, "Failed to delete override")
+		}
+		slog.InfoContext(ctx, "admin_override_deleted", "property_id", propertyID)
+		return LambdaResponse{StatusCode: 204}
+	}
+	return LambdaResponse{StatusCode: 405, Headers: map[string]string{"Allow": "GET, PUT, DELETE"}}
+}
+
+// handleAdminDoNotContact lists (GET ?tenant_id=), adds (PUT) and removes
+// (DELETE ?tenant_id=&phone=) do-not-contact numbers. An empty tenant_id
+// applies to every tenant.
+func handleAdminDoNotContact(ctx context.Context, cfg config.Config, event json.RawMessage, method string, query url.Values) LambdaResponse {
+	supa := newSupabaseClient(cfg)
+
+	switch method {
+	case "GET":
+		entries, err := supa.ListDoNotContact(ctx, query.Get("tenant_id"))
+		if err != nil {
+			slog.ErrorContext(ctx, "admin_do_not_contact_fetch_failed", "error", err)
+			return errorResponse(502, "Failed to read do-not-contact list")
+		}
+		if entries == nil {
+			entries = []models.DoNotContact{}
+		}
+		return adminJSON(200, entries)
+
+	case "PUT":
+		var entry models.DoNotContact
+		body, _, ok := extractHTTP(event)
+		if !ok || json.Unmarshal(body, &entry) != nil {
+			return errorResponse(400, "Invalid JSON body")
+		}
+		entry.TenantID = strings.TrimSpace(entry.TenantID)
+		entry.Phone = strings.TrimSpace(entry.Phone)
+		if entry.Phone == "" {
+			return errorResponse(400, "phone is required")
+		}
+		if err := supa.AddDoNotContact(ctx, entry); err != nil {
+			slog.ErrorContext(ctx, "admin_do_not_contact_save_failed", "tenant_id", entry.TenantID, "error", err)
+			return errorResponse(502, "Failed to save do-not-contact entry")
+		}
+		slog.InfoContext(ctx, "admin_do_not_contact_added", "tenant_id", entry.TenantID)
+		return adminJSON(200, entry)
+
+	case "DELETE":
+		phone := strings.TrimSpace(query.Get("phone"))
+		if phone == "" {
+			return errorResponse(400, "phone is required")
+		}
+		tenantID := strings.TrimSpace(query.Get("tenant_id"))
+		if err := supa.RemoveDoNotContact(ctx, tenantID, phone); err != nil {
+			slog.ErrorContext(ctx, "admin_do_not_contact_delete_failed", "tenant_id", tenantID, "error", err)
+			return errorResponse(502, "Failed to delete do-not-contact entry")
+		}
+		slog.InfoContext(ctx, "admin_do_not_contact_removed", "tenant_id", tenantID)
+		return LambdaResponse{StatusCode: 204}
+	}
+	return LambdaResponse{StatusCode: 405, Headers: map[string]string{"Allow": "GET, PUT, DELETE"}}
+}
+
+func adminJSON(status int, v interface{}) LambdaResponse {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return errorResponse(500, "Failed to encode response")
+	}
+	return LambdaResponse{
+		StatusCode: status,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(body),
+	}
+}
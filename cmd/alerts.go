@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/breaker"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/clients"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/config"
+)
+
+// registerAlerting subscribes to dependency breaker events so on-call hears
+// about AppFolio/Google/Supabase outages from the service itself. Events are
+// always logged; they are paged only when a PagerDuty routing key is set.
+func registerAlerting(cfg config.Config) {
+	var pd *clients.PagerDutyClient
+	if cfg.PagerDutyRoutingKey != "" {
+		pd = clients.NewPagerDutyClient(cfg.PagerDutyRoutingKey)
+	}
+
+	source := cfg.FunctionName
+	if source == "" {
+		source = "go-scheduling-service"
+	}
+
+	breaker.OnEvent(func(e breaker.Event) {
+		slog.Warn("dependency_health_event",
+			"dependency", e.Dependency,
+			"from", e.From.String(),
+			"to", e.To.String(),
+			"error_rate", e.ErrorRate,
+			"requests", e.Requests,
+			"failures", e.Failures,
+			"last_error", e.LastError,
+		)
+		if pd == nil {
+			return
+		}
+
+		alert, ok := alertForEvent(source, e)
+		if !ok {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := pd.SendAlert(ctx, alert); err != nil {
+			slog.Error("alert_send_failed", "dependency", e.Dependency, "dedup_key", alert.DedupKey, "error", err)
+			return
+		}
+		slog.Info("alert_sent", "dependency", e.Dependency, "dedup_key", alert.DedupKey, "resolve", alert.Resolve)
+	})
+}
+
+// alertFlushTimeout bounds how long an invocation waits for alerts still
+// being sent before returning
+const alertFlushTimeout = 5 * time.Second
+
+// flushAlerts waits for breaker listeners still delivering alerts: once a
+// Lambda invocation returns, its environment may be frozen before they finish.
+func flushAlerts(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), alertFlushTimeout)
+	defer cancel()
+	if !breaker.Flush(ctx) {
+		slog.WarnContext(ctx, "alert_flush_timeout")
+	}
+}
+
+func alertForEvent(source string, e breaker.Event) (clients.Alert, bool) {
+	details := map[string]interface{}{
+		"requests_in_window": e.Requests,
+		"failures_in_window": e.Failures,
+		"last_error":         e.LastError,
+	}
+
+	switch {
+	case e.IsErrorRate():
+		return clients.Alert{
+			DedupKey:  fmt.Sprintf("%s/%s/error-rate", source, e.Dependency),
+			Summary:   fmt.Sprintf("%s error rate %.0f%% over the last %s", e.Dependency, e.ErrorRate*100, breaker.ErrorRateWindow),
+			Source:    source,
+			Component: e.Dependency,
+			Severity:  "error",
+			Details:   details,
+		}, true
+	case e.To == breaker.Open && e.From == breaker.Closed:
+		return clients.Alert{
+			DedupKey:  fmt.Sprintf("%s/%s/outage", source, e.Dependency),
+			Summary:   fmt.Sprintf("%s circuit breaker opened: dependency appears to be down", e.Dependency),
+			Source:    source,
+			Component: e.Dependency,
+			Severity:  "critical",
+			Details:   details,
+		}, true
+	case e.To == breaker.Closed && e.From != breaker.Closed:
+		return clients.Alert{
+			DedupKey: fmt.Sprintf("%s/%s/outage", source, e.Dependency),
+			Resolve:  true,
+		}, true
+	}
+	return clients.Alert{}, false
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/config"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/logic"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+// applicationLinkPath is the tracked redirect to a unit's online application
+const applicationLinkPath = "/apply"
+
+// applicationLinkMaxAge stops the job from texting prospects whose showing
+// ended long ago (e.g. the first run after the job is enabled).
+const applicationLinkMaxAge = 24 * time.Hour
+
+// sendApplicationLinks texts the online-application link to every prospect
+// whose booked showing has ended, and records the send on the lead.
+func sendApplicationLinks(ctx context.Context, cfg config.Config) jobResult {
+	var result jobResult
+	if cfg.ApplicationURLTemplate == "" || cfg.PublicBaseURL == "" || cfg.LinkSigningSecret == "" || cfg.TwilioAccountSID == "" {
+		slog.WarnContext(ctx, "application_links_not_configured")
+		return result
+	}
+
+	p := pipelineFor(cfg)
+	supa := p.supabase
+
+	now := time.Now()
+	sessions, err := supa.ListShowingsAwaitingApplication(ctx, now.Add(-applicationLinkMaxAge), now)
+	if err != nil {
+		slog.ErrorContext(ctx, "application_showings_fetch_failed", "error", err)
+		result.Errors = append(result.Errors, err.Error())
+		return result
+	}
+
+	for _, session := range sessions {
+		link := applicationLink(cfg, session.Phone, session.PropertyID)
+		msg := fmt.Sprintf("Thanks for touring %s! If you'd like to rent it, you can apply online here: %s", session.PropertyAddress, link)
+		err := p.sendText(ctx, "", session.Phone, logic.Location(session.TimeZone), msg, "application_link")
+		if errors.Is(err, errQuietHours) || errors.Is(err, errDoNotContact) || errors.Is(err, errNoSMSConsent) {
+			// Quiet hours: a later run sends it. Otherwise it's never sent.
+			continue
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "application_link_send_failed", "property_id", session.PropertyID, "error", err)
+			result.Errors = append(result.Errors, err.Error())
+			continue
+		}
+
+		sentAt := time.Now().UTC()
+		session.ApplicationSentAt = &sentAt
+		if err := supa.SaveSMSSession(ctx, session); err != nil {
+			// Without the marker the next run would text the prospect again
+			slog.ErrorContext(ctx, "sms_session_save_failed", "property_id", session.PropertyID, "error", err)
+			result.Errors = append(result.Errors, err.Error())
+		}
+		if err := supa.SaveLead(ctx, models.Lead{Phone: session.Phone, ApplicationPropertyID: session.PropertyID, ApplicationSentAt: &sentAt}); err != nil {
+			slog.WarnContext(ctx, "lead_save_failed", "error", err)
+		}
+
+		slog.InfoContext(ctx, "application_link_sent", "property_id", session.PropertyID)
+		metrics.Incr(ctx, "ApplicationLinkSent")
+		result.Processed++
+	}
+	return result
+}
+
+// applicationLink returns the tracked redirect URL for a prospect and property
+func applicationLink(cfg config.Config, phone, propertyID string) string {
+	return strings.TrimRight(cfg.PublicBaseURL, "/") + applicationLinkPath + "?t=" + signLinkToken(cfg.LinkSigningSecret, phone+"|"+propertyID)
+}
+
+// signLinkToken encodes payload with a truncated HMAC so tracked links can't be forged
+func signLinkToken(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// verifyLinkToken returns the payload of a token produced by signLinkToken
+func verifyLinkToken(secret, token string) (string, bool) {
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", false
+	}
+	if !hmac.Equal([]byte(signLinkToken(secret, string(payload))), []byte(encoded+"."+sig)) {
+		return "", false
+	}
+	return string(payload), true
+}
+
+// extractRoute returns the path and query string of an HTTP-sourced event
+// (Function URL / API Gateway 2.0, or API Gateway 1.0).
+func extractRoute(event json.RawMessage) (string, url.Values, bool) {
+	var envelope struct {
+		RawPath               string            `json:"rawPath"`
+		RawQueryString        string            `json:"rawQueryString"`
+		Path                  string            `json:"path"`
+		QueryStringParameters map[string]string `json:"queryStringParameters"`
+	}
+	if err := json.Unmarshal(event, &envelope); err != nil {
+		return "", nil, false
+	}
+
+	if envelope.RawPath != "" {
+		query, _ := url.ParseQuery(envelope.RawQueryString)
+		return envelope.RawPath, query, true
+	}
+	if envelope.Path != "" {
+		query := url.Values{}
+		for k, v := range envelope.QueryStringParameters {
+			query.Set(k, v)
+		}
+		return envelope.Path, query, true
+	}
+	return "", nil, false
+}
+
+// handleApplicationClick records a tracked application-link click on the
+// lead and redirects to the unit's online application.
+func handleApplicationClick(ctx context.Context, cfg config.Config, query url.Values) LambdaResponse {
+	payload, ok := verifyLinkToken(cfg.LinkSigningSecret, query.Get("t"))
+	phone, propertyID, found := strings.Cut(payload, "|")
+	if cfg.LinkSigningSecret == "" || !ok || !found {
+		slog.WarnContext(ctx, "application_link_invalid")
+		return errorResponse(404, "Link not found")
+	}
+
+	supa := newSupabaseClient(cfg)
+	clicks := 1
+	if lead, err := supa.GetLead(ctx, phone); err != nil {
+		slog.WarnContext(ctx, "lead_fetch_failed", "error", err)
+	} else if lead != nil {
+		clicks = lead.ApplicationClicks + 1
+	}
+
+	// Tracking must never block the prospect from reaching the application
+	clickedAt := time.Now().UTC()
+	if mayContact(ctx, supa, "", phone, "lead_tracking") {
+		if err := supa.SaveLead(ctx, models.Lead{Phone: phone, ApplicationClickedAt: &clickedAt, ApplicationClicks: clicks}); err != nil {
+			slog.WarnContext(ctx, "lead_save_failed", "error", err)
+		}
+	}
+
+	slog.InfoContext(ctx, "application_link_clicked", "property_id", propertyID, "clicks", clicks)
+	metrics.Incr(ctx, "ApplicationLinkClicked")
+
+	return LambdaResponse{
+		StatusCode: 302,
+		Headers:    map[string]string{"Location": strings.ReplaceAll(cfg.ApplicationURLTemplate, "{property_id}", url.QueryEscape(propertyID))},
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/config"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/logic"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+// bookingResponsePath receives an agent's accept/decline link. GET shows a
+// confirmation button and only the POST acts, so link previews (e.g. Slack
+// unfurling) can't decline a booking.
+const bookingResponsePath = "/booking/respond"
+
+// bookingResponseMaxAge bounds how long after a booking its accept/decline
+// links still work; an agent who hasn't answered by then no longer needs to
+const bookingResponseMaxAge = 7 * 24 * time.Hour
+
+// Agent responses to a booking
+const (
+	agentAccepted = "accepted"
+	agentDeclined = "declined"
+)
+
+func (p *pipeline) approvalEnabled() bool {
+	return p.cfg.AgentBookingApproval && p.cfg.PublicBaseURL != "" && p.cfg.LinkSigningSecret != ""
+}
+
+// bookingResponseLink returns the signed accept or decline link for a
+// booking, good for bookingResponseMaxAge
+func bookingResponseLink(cfg config.Config, response, phone, eventID string) string {
+	issued := strconv.FormatInt(time.Now().Unix(), 10)
+	token := signLinkToken(cfg.LinkSigningSecret, strings.Join([]string{response, phone, eventID, issued}, "|"))
+	return strings.TrimRight(cfg.PublicBaseURL, "/") + bookingResponsePath + "?t=" + token
+}
+
+// handleBookingResponse records an agent accepting or declining an SMS
+// booking. A decline deletes the event and texts the prospect other times.
+func handleBookingResponse(ctx context.Context, requestID string, cfg config.Config, event json.RawMessage, query url.Values) LambdaResponse {
+	payload, ok := verifyLinkToken(cfg.LinkSigningSecret, query.Get("t"))
+	parts := strings.Split(payload, "|")
+	if cfg.LinkSigningSecret == "" || !ok || len(parts) != 4 || (parts[0] != agentAccepted && parts[0] != agentDeclined) {
+		slog.WarnContext(ctx, "booking_response_link_invalid")
+		return errorResponse(404, "Link not found")
+	}
+	issued, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil || time.Since(time.Unix(issued, 0)) > bookingResponseMaxAge {
+		slog.InfoContext(ctx, "booking_response_link_expired")
+		return messagePage(410, "Showing", "This link has expired.")
+	}
+	response, phone, eventID := parts[0], parts[1], parts[2]
+
+	if requestMethod(event) != "POST" {
+		verb := "Accept"
+		if response == agentDeclined {
+			verb = "Decline"
+		}
+		return LambdaResponse{
+			StatusCode: 200,
+			Headers:    map[string]string{"Content-Type": "text/html; charset=utf-8"},
+			Body: fmt.Sprintf(`<!doctype html><title>Showing</title><form method="post" action="%s"><button>%s this showing</button></form>`,
+				html.EscapeString(bookingResponsePath+"?t="+url.QueryEscape(query.Get("t"))), verb),
+		}
+	}
+
+	p := pipelineFor(cfg)
+	session, err := p.supabase.GetSMSSession(ctx, phone)
+	if err != nil {
+		slog.ErrorContext(ctx, "sms_session_fetch_failed", "error", err)
+		return messagePage(502, "Showing", "Something went wrong. Please try again in a minute.")
+	}
+	if session == nil || session.EventID != eventID {
+		return messagePage(410, "Showing", "This showing was already cancelled or rebooked.")
+	}
+	if session.AgentResponse != "" {
+		return messagePage(200, "Showing", fmt.Sprintf("This showing was already %s.", session.AgentResponse))
+	}
+
+	if response == agentAccepted {
+		if err := p.supabase.RecordAgentResponse(ctx, phone, agentAccepted); err != nil {
+			slog.ErrorContext(ctx, "agent_response_save_failed", "error", err)
+			return messagePage(502, "Showing", "Something went wrong. Please try again in a minute.")
+		}
+		p.transitionBooking(ctx, session.BookingID, models.BookingConfirmed, "")
+		slog.InfoContext(ctx, "booking_accepted", "property_id", session.PropertyID, "agent", session.AgentEmail)
+		metrics.Incr(ctx, "BookingAccepted")
+		return messagePage(200, "Showing", "Thanks, the showing is accepted.")
+	}
+
+	if err := p.declineBooking(ctx, requestID, *session); err != nil {
+		return messagePage(502, "Showing", "Something went wrong. Please try again in a minute.")
+	}
+	return messagePage(200, "Showing", "The showing is declined. The prospect has been sent other times.")
+}
+
+// declineBooking frees the declined slot and texts the prospect the agent's
+// other open times (excluding the declined one)
+func (p *pipeline) declineBooking(ctx context.Context, requestID string, session models.SMSSession) error {
+	token, err := p.supabase.GetAccessToken(ctx, session.AgentEmail)
+	if err == nil {
+		err = p.calendar.DeleteEvent(ctx, token, session.AgentEmail, session.EventID)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "calendar_event_delete_failed", "event_id", session.EventID, "error", err)
+		return err
+	}
+	// Clear the booking so the re-offer starts a fresh session
+	if err := p.supabase.DeleteSMSSession(ctx, session.Phone); err != nil {
+		slog.WarnContext(ctx, "sms_session_delete_failed", "error", err)
+	}
+	p.transitionBooking(ctx, session.BookingID, models.BookingCancelled, cancelAgentDecline)
+	slog.InfoContext(ctx, "booking_declined", "property_id", session.PropertyID, "agent", session.AgentEmail)
+	metrics.Incr(ctx, "BookingDeclined")
+
+	result := p.findAvailability(ctx, requestID, models.Request{Query: session.PropertyAddress, Phone: session.Phone, TenantID: session.TenantID, UnitID: session.UnitID, Source: session.Source},
+		propertyMatch{PropertyID: session.PropertyID, Source: models.MatchOverride})
+	result.Slots = withoutSlot(result.Slots, *session.BookedStart)
+	result.Response.Availability.Suggestions = withoutSlot(result.Response.Availability.Suggestions, *session.BookedStart)
+	offer := p.offerAvailability(ctx, session.Phone, result)
+
+	msg := fmt.Sprintf("Sorry, %s can't make your showing at %s on %s after all.\n%s", session.AgentName,
+		session.PropertyAddress, showingTime(*session.BookedStart, session.TimeZone), offer)
+	if err := p.sendText(ctx, "", session.Phone, logic.Location(session.TimeZone), msg, "booking_reoffer"); err != nil {
+		// The slot is already free; the prospect can text the address again
+		slog.WarnContext(ctx, "booking_reoffer_not_sent", "error", err)
+	}
+	return nil
+}
+
+// withoutSlot drops the slot starting at start
+func withoutSlot(slots []models.TimeSlot, start time.Time) []models.TimeSlot {
+	out := make([]models.TimeSlot, 0, len(slots))
+	for _, slot := range slots {
+		if !slot.Start.Equal(start) {
+			out = append(out, slot)
+		}
+	}
+	return out
+}
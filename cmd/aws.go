@@ -0,0 +1,21 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+var (
+	awsSessionOnce sync.Once
+	awsSess        *session.Session
+	awsSessErr     error
+)
+
+// awsSession returns the shared AWS SDK session, created on first use
+func awsSession() (*session.Session, error) {
+	awsSessionOnce.Do(func() {
+		awsSess, awsSessErr = session.NewSession()
+	})
+	return awsSess, awsSessErr
+}
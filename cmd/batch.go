@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+const (
+	// maxBatchItems bounds the properties checked in one batch request
+	maxBatchItems = 25
+	// batchConcurrency is how many batch items are looked up at once
+	batchConcurrency = 4
+)
+
+// batchAvailability checks availability for every item of req.Batch. Items
+// fail independently: a failed lookup (or a panic in one) is recorded on its
+// item and the others still run.
+func (p *pipeline) batchAvailability(ctx context.Context, requestID string, req models.Request) models.BatchResponse {
+	items := make([]models.BatchItem, len(req.Batch))
+	sem := make(chan struct{}, batchConcurrency)
+	var wg sync.WaitGroup
+	for i, item := range req.Batch {
+		item.TenantID = req.TenantID
+		if item.Phone == "" {
+			item.Phone = req.Phone
+		}
+		if item.Source == "" {
+			item.Source = req.Source
+		}
+		wg.Go(func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			items[i] = p.batchItem(ctx, requestID, item)
+		})
+	}
+	wg.Wait()
+
+	var resp models.BatchResponse
+	resp.Items = items
+	var failures []string
+	for _, item := range items {
+		if item.Success {
+			resp.Succeeded++
+			continue
+		}
+		resp.Failed++
+		failures = append(failures, fmt.Sprintf("%s: %s", item.Query, item.Error))
+	}
+
+	metrics.Record(ctx, "BatchItems", float64(resp.Succeeded), metrics.Count, "Outcome", "success")
+	metrics.Record(ctx, "BatchItems", float64(resp.Failed), metrics.Count, "Outcome", "failure")
+	log := slog.InfoContext
+	if resp.Failed > 0 {
+		log = slog.WarnContext
+	}
+	log(ctx, "batch_complete", "items", len(items), "succeeded", resp.Succeeded, "failed", resp.Failed, "failures", failures)
+	return resp
+}
+
+// batchItem runs one batch item, turning a failure into its Error
+func (p *pipeline) batchItem(ctx context.Context, requestID string, req models.Request) (item models.BatchItem) {
+	item.Query = req.Query
+	if req.Query == "" {
+		item.Error = "Query is required"
+		return item
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			slog.ErrorContext(ctx, "batch_item_panic", "query", req.Query, "panic", r)
+			item = models.BatchItem{Query: req.Query, Error: "internal error"}
+		}
+	}()
+
+	result := p.findAvailability(ctx, requestID, req, propertyMatch{})
+	result.Response.Metadata = req.Metadata
+	result.Response.FormattedMsg = brandText(result.Response.FormattedMsg, p.cfg.BrandingFor(req.TenantID))
+	item.Response = &result.Response
+	item.Success = result.Response.Success
+	if !item.Success {
+		item.Error = result.Response.Message
+	}
+	return item
+}
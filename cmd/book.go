@@ -0,0 +1,307 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/events"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/logging"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/logic"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/usage"
+)
+
+const (
+	actionBook = "book"
+	// bookToolName is the VAPI tool that maps to actionBook
+	bookToolName = "book_showing"
+
+	actionConfirm = "confirm"
+	// confirmToolName is the VAPI tool that maps to actionConfirm
+	confirmToolName = "confirm_slot"
+)
+
+// bookShowing books req.SlotStart, one of the slots result offers, on the
+// agent's calendar and answers with its confirmation. Anything short of a
+// booking keeps result's availability, so the caller hears the times that
+// are still open.
+func (p *pipeline) bookShowing(ctx context.Context, requestID string, req models.Request, result availabilityResult) models.Response {
+	p = p.forTenant(req.TenantID)
+	resp := result.Response
+	if !resp.Success {
+		return resp
+	}
+	if result.LockID != "" {
+		// Self-guided tours need an access code texted to the prospect
+		resp.Success = false
+		resp.Message = "Self-guided showings are booked by text."
+		resp.FormattedMsg = "This property offers self-guided tours. Text the address to us and we'll send your booking options."
+		resp.NextActions = nil
+		return resp
+	}
+	if req.Phone == "" {
+		resp.Success = false
+		resp.Message = "Phone is required."
+		resp.FormattedMsg = "What's the best phone number for the showing?"
+		resp.NextActions = []string{models.NextCollectPhone}
+		return resp
+	}
+
+	offer := showingOffer(result)
+	start, err := parseCallbackTime(req.SlotStart, logic.Location(offer.TimeZone))
+	var slot *models.TimeSlot
+	for i := range offer.Slots {
+		if err == nil && offer.Slots[i].Start.Equal(start) {
+			slot = &offer.Slots[i]
+			break
+		}
+	}
+	if slot == nil {
+		slog.InfoContext(ctx, "booking_slot_unavailable", "slot_start", req.SlotStart, "error", err)
+		metrics.Incr(ctx, "BookingSlotUnavailable")
+		resp.Success = false
+		resp.Message = "Requested time is not available."
+		resp.FormattedMsg = "Sorry, that time isn't available. " + resp.FormattedMsg
+		resp.NextActions = []string{models.NextOfferSlots}
+		return resp
+	}
+
+	booked := p.bookSlot(ctx, requestID, req, offer, *slot, result.AccessToken)
+	resp.Success = booked.Success
+	resp.Message = booked.Message
+	resp.FormattedMsg = booked.FormattedMsg
+	resp.NextActions = booked.NextActions
+	resp.Booking = booked.Booking
+	return resp
+}
+
+// confirmSlot books req.SlotID, one of the slots offered earlier in the
+// same voice call, after checking the agent's calendar is still free then
+func (p *pipeline) confirmSlot(ctx context.Context, requestID string, req models.Request) models.Response {
+	p = p.forTenant(req.TenantID)
+	callID := logging.CallID(ctx)
+	if callID == "" {
+		return models.Response{
+			Success:      false,
+			Message:      "SlotId can only be confirmed during the call it was offered in.",
+			FormattedMsg: "Let me check the available times again.",
+			NextActions:  []string{models.NextOfferSlots},
+		}
+	}
+	if req.Phone == "" {
+		return models.Response{
+			Success:      false,
+			Message:      "Phone is required.",
+			FormattedMsg: "What's the best phone number for the showing?",
+			NextActions:  []string{models.NextCollectPhone},
+		}
+	}
+
+	state, err := p.supabase.GetCallState(ctx, callID)
+	if err != nil {
+		slog.ErrorContext(ctx, "call_state_fetch_failed", "error", err)
+		return models.Response{
+			Success:      false,
+			Message:      "Failed to load the offered slots.",
+			FormattedMsg: "Sorry, I couldn't book that time right now. A team member will follow up with you to schedule.",
+			NextActions:  []string{models.NextTransferToHuman},
+		}
+	}
+	var slot models.TimeSlot
+	ok := state != nil && state.Offer != nil
+	if ok {
+		slot, ok = state.Offer.Slot(req.SlotID)
+	}
+	if !ok || !slot.Start.After(time.Now()) {
+		slog.InfoContext(ctx, "slot_confirm_unknown", "slot_id", req.SlotID, "offered", ok)
+		metrics.Incr(ctx, "BookingSlotUnavailable")
+		return models.Response{
+			Success:      false,
+			Message:      "SlotId was not offered in this call or has passed.",
+			FormattedMsg: "Sorry, that time isn't available anymore. Let me check the open times again.",
+			NextActions:  []string{models.NextOfferSlots},
+		}
+	}
+	return p.bookSlot(ctx, requestID, req, *state.Offer, slot, "")
+}
+
+// rememberOffer keeps the slots a voice caller was just read in their call
+// state, for a confirm_slot call to book one of them by ID
+func (p *pipeline) rememberOffer(ctx context.Context, req models.Request, result availabilityResult) {
+	callID := logging.CallID(ctx)
+	if callID == "" || !result.Response.Success || result.LockID != "" {
+		return
+	}
+	offer := showingOffer(result)
+	offer.Slots = nil
+	seen := make(map[string]bool)
+	avail := result.Response.Availability
+	for _, slot := range slices.Concat(avail.Suggestions, avail.Slots) {
+		if !seen[slot.ID] {
+			seen[slot.ID] = true
+			offer.Slots = append(offer.Slots, slot)
+		}
+	}
+	if len(offer.Slots) == 0 {
+		return
+	}
+	state := models.CallState{CallID: callID, TenantID: req.TenantID, Offer: &offer}
+	if err := p.forTenant(req.TenantID).supabase.SaveCallState(ctx, state); err != nil {
+		// Confirming by slot ID won't work; booking by SlotStart still does
+		slog.WarnContext(ctx, "call_state_save_failed", "error", err)
+	}
+}
+
+// showingOffer is what booking one of result's slots needs
+func showingOffer(result availabilityResult) models.ShowingOffer {
+	return models.ShowingOffer{
+		PropertyID:      result.PropertyID,
+		UnitID:          result.Response.Property.UnitID,
+		PropertyAddress: result.Response.Property.Address,
+		Agent:           result.Response.Agent,
+		TimeZone:        result.TimeZone,
+		Source:          result.Source,
+		Slots:           result.Slots,
+	}
+}
+
+// bookSlot re-checks slot against the agent's free/busy, puts the showing
+// on their calendar and records it. token is the agent's calendar token,
+// fetched when empty.
+func (p *pipeline) bookSlot(ctx context.Context, requestID string, req models.Request, offer models.ShowingOffer, slot models.TimeSlot, token string) models.Response {
+	agent := offer.Agent
+	fail := func(msg string) models.Response {
+		return models.Response{
+			Success:      false,
+			Message:      "Failed to book showing.",
+			FormattedMsg: msg,
+			NextActions:  []string{models.NextTransferToHuman},
+		}
+	}
+	if err := checkUsage(ctx, p.cfg, req.TenantID, usage.Bookings); err != nil {
+		return fail("I'm not able to book showings right now. A team member will follow up with you.")
+	}
+	if token == "" {
+		var err error
+		if token, err = p.supabase.GetAccessToken(ctx, agent.Email); err != nil {
+			slog.ErrorContext(ctx, "token_fetch_failed", "email", agent.Email, "error", err)
+			return fail(fmt.Sprintf("I couldn't reach %s's calendar to book that time. A team member will follow up with you.", agent.Name))
+		}
+	}
+
+	holder := slotHolder(ctx, req.Phone)
+	busy, err := p.busySlots(ctx, token, busyScope{
+		Email:      agent.Email,
+		Holder:     holder,
+		PropertyID: offer.PropertyID,
+		UnitID:     offer.UnitID,
+		Settings:   p.propertySettings(ctx, offer.PropertyID),
+		Loc:        logic.Location(offer.TimeZone),
+	}, slot.Start, slot.End)
+	if err != nil {
+		calendarFetchFailed(ctx, agent.Email, err)
+		return fail(fmt.Sprintf("I couldn't confirm %s's availability right now. A team member will follow up with you.", agent.Name))
+	}
+	if logic.IsBusy(slot.Start, slot.End, busy) {
+		// Booked by someone else since the slots were read
+		slog.InfoContext(ctx, "booking_slot_taken", "start", slot.Start)
+		metrics.Incr(ctx, "BookingSlotUnavailable")
+		return models.Response{
+			Success:      false,
+			Message:      "Requested time was just taken.",
+			FormattedMsg: "Sorry, that time was just taken. Is there another time that works for you?",
+			NextActions:  []string{models.NextOfferSlots},
+		}
+	}
+
+	loc := logic.Location(offer.TimeZone)
+	address := offer.PropertyAddress
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = "Not given"
+	}
+	channel := requestChannel(ctx)
+	event := models.CalendarEvent{
+		Summary:     showingSummaryPrefix + address,
+		Description: fmt.Sprintf("Booked via %s\nProspect: %s\nProspect phone: %s\nProperty ID: %s", channel, name, req.Phone, offer.PropertyID),
+		Location:    address,
+		Start:       &models.CalendarEventTime{DateTime: slot.Start.Format(time.RFC3339), TimeZone: loc.String()},
+		End:         &models.CalendarEventTime{DateTime: slot.End.Format(time.RFC3339), TimeZone: loc.String()},
+	}
+	created, err := p.calendar.CreateEvent(ctx, token, agent.Email, event)
+	if err != nil {
+		slog.ErrorContext(ctx, "calendar_event_create_failed", "error", err)
+		return fail(fmt.Sprintf("I wasn't able to book that time. Please email %s at %s to schedule.", agent.Name, agent.Email))
+	}
+	meterUsage(ctx, p.cfg, req.TenantID, usage.Bookings, 1)
+	p.releaseSlotHold(ctx, holder, agent.Email, slot.Start)
+
+	booking := models.Booking{
+		TenantID:        req.TenantID,
+		Status:          models.BookingBooked,
+		Channel:         channel,
+		Source:          offer.Source,
+		Phone:           req.Phone,
+		PropertyID:      offer.PropertyID,
+		UnitID:          offer.UnitID,
+		PropertyAddress: address,
+		AgentEmail:      agent.Email,
+		AgentName:       agent.Name,
+		Start:           slot.Start.UTC(),
+		End:             slot.End.UTC(),
+		TimeZone:        loc.String(),
+		EventID:         created.ID,
+	}
+	// The event is booked either way. Without the record there is nothing a
+	// confirmation number could cancel or reschedule, so none is given.
+	booking = p.recordBooking(ctx, booking)
+
+	slog.InfoContext(ctx, "voice_showing_booked", "property_id", offer.PropertyID, "agent", agent.Name, "event_id", created.ID, "booking_id", booking.ID, "confirmation_id", booking.ConfirmationNumber(), "channel", channel)
+	metrics.Incr(ctx, "ShowingBooked", "Channel", channel)
+	events.Emit(ctx, events.Event{
+		Type:       events.TypeBooking,
+		PropertyID: offer.PropertyID,
+		Phone:      req.Phone,
+		Data:       events.ShowingBooked{Channel: channel, Start: slot.Start, End: slot.End, Agent: agent.Email, CalendarEventID: created.ID, Source: offer.Source},
+	})
+	text := fmt.Sprintf(":calendar: Showing booked via %s: %s on %s with %s (prospect %s, %s).",
+		channel, address, slot.Start.In(loc).Format("Mon, Jan 2 at 3:04 PM"), agent.Name, name, req.Phone)
+	text += p.leadTagsLine(ctx, req.Phone)
+	p.notifyTeam(ctx, requestID, agent.Zone, text)
+
+	msg := fmt.Sprintf("You're booked! Your showing at %s is on %s at %s with %s.", address, slot.Date, slot.Time, agent.Name)
+	if booking.ID != "" {
+		msg += fmt.Sprintf(" Your confirmation number is %s.", booking.ConfirmationNumber())
+	}
+	return models.Response{
+		Success:      true,
+		Property:     models.PropertyInfo{ID: offer.PropertyID, Address: address, UnitID: offer.UnitID},
+		Agent:        agent,
+		Message:      "Showing booked.",
+		FormattedMsg: msg,
+		Booking:      bookingConfirmation(booking),
+	}
+}
+
+// bookingConfirmation is what the caller is told about booking, with its
+// times in the zone it was booked in
+func bookingConfirmation(booking models.Booking) *models.BookingConfirmation {
+	slot := logic.NewTimeSlot(booking.Start, booking.End, logic.Location(booking.TimeZone))
+	return &models.BookingConfirmation{
+		ConfirmationID:  booking.ConfirmationNumber(),
+		Date:            slot.Date,
+		Time:            slot.Time,
+		Start:           slot.Start,
+		End:             slot.End,
+		TimeZone:        slot.TimeZone,
+		PropertyAddress: booking.PropertyAddress,
+		AgentName:       booking.AgentName,
+		AgentEmail:      booking.AgentEmail,
+		CalendarEventID: booking.EventID,
+	}
+}
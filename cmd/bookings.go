@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/lifecycle"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/logging"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/logic"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/notify"
+)
+
+// Booking cancellation reasons
+const (
+	cancelByProspect   = "prospect_cancelled"
+	cancelHoldExpired  = "hold_expired"
+	cancelAgentDecline = "agent_declined"
+)
+
+// newLifecycle returns p's booking state machine with its hooks
+func newLifecycle(p *pipeline) *lifecycle.Machine {
+	m := lifecycle.New(p.bookings)
+	m.On(lifecycle.AnyStatus, p.notifyBookingStatus)
+	m.On(models.BookingCancelled, p.syncCancelledEvent)
+	return m
+}
+
+// transitionBooking moves a booking to status. Flows call it after their
+// calendar/lock work is done, so a failure (including a transition a
+// concurrent flow made impossible) is logged rather than surfaced.
+func (p *pipeline) transitionBooking(ctx context.Context, bookingID, status, cancelReason string) {
+	if bookingID == "" {
+		return
+	}
+	_, err := p.lifecycle.Transition(ctx, bookingID, status, func(b *models.Booking) {
+		if cancelReason != "" {
+			b.CancelReason = cancelReason
+		}
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "booking_transition_failed", "booking_id", bookingID, "to", status, "error", err)
+	}
+}
+
+// notifyBookingStatus sends "booking_<status>" notifications on the
+// channels the tenant routes them to (by default none). No phone recipient
+// is set: texts to the prospect go through sendText's compliance checks.
+func (p *pipeline) notifyBookingStatus(ctx context.Context, from string, booking models.Booking) {
+	p.notifier.Dispatch(ctx, notify.Message{
+		TenantID: booking.TenantID,
+		Purpose:  "booking_" + booking.Status,
+		Subject:  fmt.Sprintf("Showing %s: %s", booking.Status, booking.PropertyAddress),
+		Text: fmt.Sprintf("Showing at %s on %s with %s is now %s (was %s).", booking.PropertyAddress,
+			booking.Start.In(logic.Location(booking.TimeZone)).Format("Mon, Jan 2 at 3:04 PM"), orUnknown(booking.AgentName), booking.Status, from),
+		Data: map[string]interface{}{
+			"bookingId":  booking.ID,
+			"status":     booking.Status,
+			"from":       from,
+			"propertyId": booking.PropertyID,
+			"start":      booking.Start,
+			"end":        booking.End,
+		},
+	})
+}
+
+// syncCancelledEvent makes sure a cancelled booking's calendar event is
+// gone. Flows that delete it themselves leave nothing to do: deleting an
+// already-deleted event succeeds.
+func (p *pipeline) syncCancelledEvent(ctx context.Context, from string, booking models.Booking) {
+	if booking.EventID == "" || booking.AgentEmail == "" {
+		return
+	}
+	token, err := p.supabase.GetAccessToken(ctx, booking.AgentEmail)
+	if err == nil {
+		err = p.calendar.DeleteEvent(ctx, token, booking.AgentEmail, booking.EventID)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "calendar_event_delete_failed", "booking_id", booking.ID, "event_id", booking.EventID, "error", err)
+	}
+}
+
+// recordBooking stores a new booking record and returns it with its ID and
+// Reference filled in, or booking unchanged (no ID) if it couldn't be
+// stored. The calendar event or lock code is already in place, so a failure
+// is logged rather than failing the booking.
+func (p *pipeline) recordBooking(ctx context.Context, booking models.Booking) models.Booking {
+	created, err := p.bookings.Create(ctx, booking)
+	if err != nil {
+		slog.ErrorContext(ctx, "booking_record_failed", "property_id", booking.PropertyID, "event_id", booking.EventID, "error", err)
+		return booking
+	}
+	slog.InfoContext(ctx, "booking_recorded", "booking_id", created.ID, "reference", created.Reference, "status", created.Status, "channel", created.Channel)
+	return created
+}
+
+// requestChannel is the Booking.Channel of a request to the scheduling API:
+// "voice" for a VAPI tool call, "web" for anything else (e.g. the widget).
+// SMS bookings are made by the SMS flow and recorded by smsBooking.
+func requestChannel(ctx context.Context) string {
+	if logging.CallID(ctx) != "" {
+		return "voice"
+	}
+	return "web"
+}
+
+// smsBooking is the booking record for the showing held in session
+func smsBooking(session *models.SMSSession, status string) models.Booking {
+	return models.Booking{
+		TenantID:        session.TenantID,
+		Status:          status,
+		Channel:         "sms",
+		Source:          session.Source,
+		Phone:           session.Phone,
+		PropertyID:      session.PropertyID,
+		UnitID:          session.UnitID,
+		PropertyAddress: session.PropertyAddress,
+		AgentEmail:      session.AgentEmail,
+		AgentName:       session.AgentName,
+		Start:           *session.BookedStart,
+		End:             *session.BookedEnd,
+		TimeZone:        session.TimeZone,
+		EventID:         session.EventID,
+		SelfGuided:      session.SelfGuided,
+		LockID:          session.LockID,
+		AccessCodeID:    session.AccessCodeID,
+		ConfirmBy:       session.ConfirmBy,
+	}
+}
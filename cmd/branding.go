@@ -0,0 +1,36 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/config"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+// brandText fills the branding placeholders in a message
+func brandText(text string, b models.Branding) string {
+	if !strings.Contains(text, "{") {
+		return text
+	}
+	return strings.NewReplacer(
+		"{company}", b.CompanyName,
+		"{company_phone}", b.Phone,
+		"{website}", b.Website,
+		"{sign_off}", b.SignOff,
+	).Replace(text)
+}
+
+// signText brands an outbound text and appends the tenant's sign-off
+func signText(text string, b models.Branding) string {
+	text = brandText(text, b)
+	if b.SignOff != "" && !strings.HasSuffix(text, b.SignOff) {
+		text += "\n" + b.SignOff
+	}
+	return text
+}
+
+// brandedResponse is successResponse with FormattedMsg branded for tenantID
+func brandedResponse(cfg config.Config, tenantID string, resp models.Response) LambdaResponse {
+	resp.FormattedMsg = brandText(resp.FormattedMsg, cfg.BrandingFor(tenantID))
+	return successResponse(resp)
+}
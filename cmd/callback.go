@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/clients"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/config"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/logic"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+const (
+	actionCallback = "callback"
+	// callbackToolName is the VAPI tool that maps to actionCallback
+	callbackToolName = "schedule_callback"
+
+	// maxCallbackLead bounds how far ahead a call-back can be scheduled
+	maxCallbackLead = 30 * 24 * time.Hour
+)
+
+// scheduleCallback schedules an outbound VAPI call to the prospect at
+// req.CallbackAt and records the call on their lead.
+func scheduleCallback(ctx context.Context, cfg config.Config, req models.Request) LambdaResponse {
+	if cfg.VAPIAPIKey == "" || cfg.VAPIAssistantID == "" || cfg.VAPIPhoneNumberID == "" {
+		slog.WarnContext(ctx, "callback_not_configured")
+		return brandedResponse(cfg, req.TenantID, models.Response{
+			Success:      false,
+			Message:      "Call-backs are not configured.",
+			FormattedMsg: "I'm not able to schedule a call back right now. A team member will follow up with you.",
+			NextActions:  []string{models.NextTransferToHuman},
+		})
+	}
+	if req.Phone == "" {
+		return brandedResponse(cfg, req.TenantID, models.Response{
+			Success:      false,
+			Message:      "Phone is required.",
+			FormattedMsg: "What's the best number to call you back on?",
+			NextActions:  []string{models.NextCollectPhone},
+		})
+	}
+
+	supa := newTenantSupabaseClient(cfg, req.TenantID)
+	if !mayContact(ctx, supa, req.TenantID, req.Phone, "callback") {
+		return brandedResponse(cfg, req.TenantID, models.Response{
+			Success:      false,
+			Message:      "Phone is on the do-not-contact list.",
+			FormattedMsg: "I'm not able to schedule a call back to that number.",
+		})
+	}
+
+	loc := logic.ScheduleLocation(cfg.ScheduleRulesFor(req.TenantID))
+	now := time.Now().In(loc)
+	at, err := parseCallbackTime(req.CallbackAt, loc)
+	if err != nil || !at.After(now) || at.Sub(now) > maxCallbackLead {
+		slog.WarnContext(ctx, "callback_time_invalid", "callback_at", req.CallbackAt, "error", err)
+		return brandedResponse(cfg, req.TenantID, models.Response{
+			Success:      false,
+			Message:      "Invalid call-back time.",
+			FormattedMsg: "I couldn't schedule a call for that time. What day and time works for a call back?",
+			NextActions:  []string{models.NextAskDatePreference},
+		})
+	}
+
+	call, err := clients.NewVAPIClient(cfg.VAPIAPIKey).ScheduleCall(ctx, clients.OutboundCall{
+		AssistantID:    cfg.VAPIAssistantID,
+		PhoneNumberID:  cfg.VAPIPhoneNumberID,
+		CustomerNumber: req.Phone,
+		At:             at,
+		Metadata:       map[string]string{"reason": req.Reason, "query": req.Query, "tenant_id": req.TenantID},
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "callback_schedule_failed", "error", err)
+		return brandedResponse(cfg, req.TenantID, models.Response{
+			Success:      false,
+			Message:      "Failed to schedule call-back.",
+			FormattedMsg: "I wasn't able to schedule that call back. A team member will follow up with you.",
+			NextActions:  []string{models.NextTransferToHuman},
+		})
+	}
+
+	scheduledAt := at.UTC()
+	lead := models.Lead{Phone: req.Phone, CallbackCallID: call.ID, CallbackAt: &scheduledAt}
+	if err := supa.SaveLead(ctx, lead); err != nil {
+		// The call is scheduled either way; only the reference is lost
+		slog.WarnContext(ctx, "lead_save_failed", "call_id", call.ID, "error", err)
+	}
+
+	slog.InfoContext(ctx, "callback_scheduled", "call_id", call.ID, "at", scheduledAt, "reason", req.Reason)
+	metrics.Incr(ctx, "CallbackScheduled")
+	return brandedResponse(cfg, req.TenantID, models.Response{
+		Success:      true,
+		Message:      "Call-back scheduled.",
+		FormattedMsg: fmt.Sprintf("You're all set. We'll call you back %s.", relativeDay(at, now)),
+	})
+}
+
+// parseCallbackTime accepts RFC 3339 or a local "2006-01-02T15:04" time
+func parseCallbackTime(s string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.In(loc), nil
+	}
+	return time.ParseInLocation("2006-01-02T15:04", s, loc)
+}
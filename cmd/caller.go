@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/clients"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+// identifyCaller matches the caller's phone against the property system's
+// tenants and prospects. It returns nil when the source can't look callers
+// up, the number is unknown, or the lookup fails.
+func (p *pipeline) identifyCaller(ctx context.Context, phone string) *models.Caller {
+	dir, ok := p.properties.(clients.CallerDirectory)
+	if !ok || phone == "" || skipDegraded(ctx, p.properties.Name(), "caller_lookup") {
+		return nil
+	}
+
+	done := timeStage(ctx, "caller")
+	caller, err := dir.LookupCaller(ctx, phone)
+	done()
+	if err != nil {
+		// Unidentified callers get the normal prospect flow
+		slog.WarnContext(ctx, "caller_lookup_failed", "source", p.properties.Name(), "error", err)
+		return nil
+	}
+	if caller == nil {
+		return nil
+	}
+
+	slog.InfoContext(ctx, "caller_identified", "type", caller.Type, "caller_id", caller.ID)
+	metrics.Incr(ctx, "CallerIdentified", "Type", caller.Type)
+	return caller
+}
+
+// tenantTransfer routes an existing tenant asking about a property to the
+// leasing team as a transfer request instead of offering showing times.
+func (p *pipeline) tenantTransfer(ctx context.Context, requestID string, req models.Request, caller *models.Caller, propID string, prop propertyRecord, provenance string) availabilityResult {
+	slog.InfoContext(ctx, "tenant_transfer_routed", "property_id", propID, "caller_id", caller.ID, "current_property_id", caller.PropertyID)
+	metrics.Incr(ctx, "TenantTransferRouted")
+	p.notifyTeam(ctx, requestID, "", fmt.Sprintf(":repeat: Current tenant %s (phone %s, property %s) is asking about a transfer to %s.\nQuery: %q",
+		orUnknown(caller.Name), req.Phone, orUnknown(caller.PropertyID), prop.Address1, req.Query))
+
+	// Not a success: no slots are offered, so the SMS flow relays the message as-is
+	return availabilityResult{PropertyID: propID, Response: models.Response{
+		Success:      false,
+		Property:     prop.info(),
+		Provenance:   provenance,
+		Caller:       caller,
+		Message:      "Existing tenant; routed as a transfer request.",
+		FormattedMsg: fmt.Sprintf("I see you're one of our current residents. Transfers are handled by our leasing team, so I've let them know you're interested in %s and someone will reach out to you shortly.", prop.Address1),
+		NextActions:  []string{models.NextTransferToHuman},
+	}}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/events"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/store"
+)
+
+const (
+	actionCancel = "cancel"
+	// cancelToolName is the VAPI tool that maps to actionCancel
+	cancelToolName = "cancel_showing"
+)
+
+// cancelBooking cancels the booking req.ConfirmationID, or the one upcoming
+// showing booked for req.Phone: its calendar event (or lock code) is
+// removed and the booking recorded as cancelled by the prospect
+func (p *pipeline) cancelBooking(ctx context.Context, req models.Request) models.Response {
+	p = p.forTenant(req.TenantID)
+	booking, resp := p.bookingFor(ctx, req, actionCancel)
+	if booking == nil {
+		return resp
+	}
+	fail := models.Response{
+		Success:      false,
+		Message:      "Failed to cancel showing.",
+		FormattedMsg: "I couldn't cancel your showing right now. A team member will follow up with you.",
+		NextActions:  []string{models.NextTransferToHuman},
+		Booking:      bookingConfirmation(*booking),
+	}
+
+	if booking.AccessCodeID != "" && p.locks != nil {
+		if err := p.locks.RevokeAccessCode(ctx, booking.LockID, booking.AccessCodeID); err != nil {
+			slog.ErrorContext(ctx, "access_code_revoke_failed", "access_code_id", booking.AccessCodeID, "error", err)
+			return fail
+		}
+	}
+	if booking.EventID != "" {
+		token, err := p.supabase.GetAccessToken(ctx, booking.AgentEmail)
+		if err == nil {
+			err = p.calendar.DeleteEvent(ctx, token, booking.AgentEmail, booking.EventID)
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "calendar_event_delete_failed", "booking_id", booking.ID, "event_id", booking.EventID, "error", err)
+			return fail
+		}
+	}
+	p.transitionBooking(ctx, booking.ID, models.BookingCancelled, cancelByProspect)
+
+	// An SMS booking's session would otherwise still show it as booked
+	if booking.Channel == "sms" {
+		if session, err := p.supabase.GetSMSSession(ctx, booking.Phone); err == nil && session != nil && session.BookingID == booking.ID {
+			if err := p.supabase.DeleteSMSSession(ctx, booking.Phone); err != nil {
+				slog.WarnContext(ctx, "sms_session_delete_failed", "error", err)
+			}
+		}
+	}
+
+	channel := requestChannel(ctx)
+	slog.InfoContext(ctx, "showing_cancelled", "booking_id", booking.ID, "event_id", booking.EventID, "channel", channel, "booked_via", booking.Channel)
+	metrics.Incr(ctx, "ShowingCancelled", "Channel", channel)
+	events.Emit(ctx, events.Event{
+		Type:       events.TypeCancellation,
+		PropertyID: booking.PropertyID,
+		Phone:      booking.Phone,
+		Data:       events.ShowingCancelled{Channel: channel, Start: &booking.Start, CalendarEventID: booking.EventID, AccessCodeID: booking.AccessCodeID},
+	})
+
+	confirmation := bookingConfirmation(*booking)
+	return models.Response{
+		Success:      true,
+		Message:      "Showing cancelled.",
+		FormattedMsg: fmt.Sprintf("Your showing at %s on %s at %s has been cancelled.", booking.PropertyAddress, confirmation.Date, confirmation.Time),
+		Booking:      confirmation,
+	}
+}
+
+// bookingByConfirmation looks up a confirmation number as the prospect gave
+// it. Records saved before references existed were given their ID instead.
+func (p *pipeline) bookingByConfirmation(ctx context.Context, confirmation string) (*models.Booking, error) {
+	booking, err := p.bookings.GetByReference(ctx, store.NormalizeReference(confirmation))
+	if err != nil || booking != nil {
+		return booking, err
+	}
+	return p.bookings.Get(ctx, confirmation)
+}
+
+// bookingFor finds the active booking a cancel or reschedule request
+// (action) means. When there is none, or several upcoming ones for the
+// phone, it returns nil and the response to give instead.
+func (p *pipeline) bookingFor(ctx context.Context, req models.Request, action string) (*models.Booking, models.Response) {
+	lookupFailed := models.Response{
+		Success:      false,
+		Message:      "Failed to look up bookings.",
+		FormattedMsg: "I couldn't look up your showing right now. A team member will follow up with you.",
+		NextActions:  []string{models.NextTransferToHuman},
+	}
+	notFound := models.Response{
+		Success:      false,
+		Message:      "No matching booking.",
+		FormattedMsg: "I couldn't find an upcoming showing to " + action + ". Could you give me your confirmation number?",
+	}
+
+	if req.ConfirmationID != "" {
+		booking, err := p.bookingByConfirmation(ctx, req.ConfirmationID)
+		if err != nil {
+			slog.ErrorContext(ctx, "booking_fetch_failed", "booking_id", req.ConfirmationID, "error", err)
+			return nil, lookupFailed
+		}
+		// A phone given alongside must be the booking's
+		if booking == nil || !booking.Active() || (req.Phone != "" && booking.Phone != req.Phone) {
+			slog.InfoContext(ctx, action+"_booking_not_found", "booking_id", req.ConfirmationID)
+			return nil, notFound
+		}
+		return booking, models.Response{}
+	}
+
+	bookings, err := p.bookings.ListByPhone(ctx, req.Phone)
+	if err != nil {
+		slog.ErrorContext(ctx, "booking_fetch_failed", "error", err)
+		return nil, lookupFailed
+	}
+	var upcoming []models.Booking
+	for _, b := range bookings {
+		if b.Active() && b.Start.After(time.Now()) {
+			upcoming = append(upcoming, b)
+		}
+	}
+	switch len(upcoming) {
+	case 0:
+		slog.InfoContext(ctx, action+"_booking_not_found", "by", "phone")
+		return nil, notFound
+	case 1:
+		return &upcoming[0], models.Response{}
+	}
+
+	resp := models.Response{
+		Success:      false,
+		Message:      "Several upcoming bookings; ConfirmationId is required.",
+		FormattedMsg: fmt.Sprintf("I see %d upcoming showings for you:", len(upcoming)),
+	}
+	for _, b := range upcoming {
+		confirmation := bookingConfirmation(b)
+		resp.Bookings = append(resp.Bookings, *confirmation)
+		resp.FormattedMsg += fmt.Sprintf(" %s on %s at %s;", b.PropertyAddress, confirmation.Date, confirmation.Time)
+	}
+	resp.FormattedMsg = resp.FormattedMsg[:len(resp.FormattedMsg)-1] + ". Which one would you like to " + action + "?"
+	return nil, resp
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/checkpoint"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/config"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+)
+
+// jobTimeoutMargin is how much of the invocation's time a checkpointed job
+// leaves for saving its progress and handing over to the next invocation
+const jobTimeoutMargin = time.Minute
+
+// jobRun is one run of a job, possibly spanning several invocations. With
+// a checkpoint table (and a Lambda deadline), items are processed in key
+// order and, before the invocation times out, the run saves its cursor and
+// re-invokes the function to carry on from it.
+type jobRun struct {
+	job   string
+	runID string
+	// store is nil when the run isn't checkpointed
+	store *checkpoint.DynamoStore
+	// resumed is the progress of earlier invocations of the run
+	resumed checkpoint.Checkpoint
+}
+
+// startJobRun begins the trigger's run, or resumes it when the trigger
+// names one
+func startJobRun(ctx context.Context, requestID string, cfg config.Config, trigger jobTrigger) *jobRun {
+	run := &jobRun{job: trigger.Job, runID: cmp.Or(trigger.RunID, trigger.Job+"#"+requestID)}
+	if _, ok := ctx.Deadline(); !ok || cfg.JobCheckpointTable == "" || lambdacontext.FunctionName == "" {
+		return run
+	}
+	sess, err := awsSession()
+	if err != nil {
+		slog.ErrorContext(ctx, "aws_session_failed", "error", err)
+		return run
+	}
+	run.store = checkpoint.NewDynamoStore(sess, cfg.JobCheckpointTable)
+	if trigger.RunID == "" {
+		return run
+	}
+
+	cp, err := run.store.Load(ctx, trigger.RunID)
+	switch {
+	case err != nil:
+		// Starting over beats skipping the rest of the run
+		slog.ErrorContext(ctx, "job_checkpoint_load_failed", "run_id", run.runID, "error", err)
+	case cp == nil:
+		slog.WarnContext(ctx, "job_checkpoint_missing", "run_id", run.runID)
+	default:
+		run.resumed = *cp
+		slog.InfoContext(ctx, "job_resumed", "run_id", run.runID, "cursor", cp.Cursor, "processed", cp.Processed)
+	}
+	return run
+}
+
+// result starts the invocation's jobResult from the run's earlier progress
+func (r *jobRun) result() jobResult {
+	return jobResult{RunID: r.runID, Processed: r.resumed.Processed, Errors: slices.Clone(r.resumed.Errors)}
+}
+
+// finish drops the checkpoint of a run that has completed
+func (r *jobRun) finish(ctx context.Context, result jobResult) {
+	if r.store == nil || result.Continued || r.resumed.RunID == "" {
+		return
+	}
+	if err := r.store.Delete(ctx, r.runID); err != nil {
+		slog.WarnContext(ctx, "job_checkpoint_delete_failed", "run_id", r.runID, "error", err)
+	}
+}
+
+// handOver saves the run's progress and starts the invocation that carries
+// it on. When either fails, the run stops here and result says so.
+func (r *jobRun) handOver(ctx context.Context, cursor string, result *jobResult) {
+	cp := checkpoint.Checkpoint{RunID: r.runID, Job: r.job, Cursor: cursor, Processed: result.Processed, Errors: result.Errors}
+	if err := r.store.Save(context.WithoutCancel(ctx), cp); err != nil {
+		slog.ErrorContext(ctx, "job_checkpoint_save_failed", "run_id", r.runID, "error", err)
+		result.Errors = append(result.Errors, "checkpoint: "+err.Error())
+		return
+	}
+	if err := invokeSelf(context.WithoutCancel(ctx), jobTrigger{Job: r.job, RunID: r.runID}); err != nil {
+		slog.ErrorContext(ctx, "job_reinvoke_failed", "run_id", r.runID, "error", err)
+		result.Errors = append(result.Errors, "re-invoke: "+err.Error())
+		return
+	}
+	result.Continued = true
+	slog.InfoContext(ctx, "job_handed_over", "run_id", r.runID, "cursor", cursor, "processed", result.Processed)
+	metrics.Incr(ctx, "JobHandedOver", "Job", r.job)
+}
+
+// eachCheckpointed calls fn for each item of a checkpointed run, in key
+// order, skipping those earlier invocations did. It returns false when the
+// invocation ran short of time and handed the rest over (see handOver).
+// Runs without a checkpoint store just call fn for every item.
+func eachCheckpointed[T any](ctx context.Context, run *jobRun, result *jobResult, items []T, key func(T) string, fn func(T)) bool {
+	if run.store == nil {
+		for _, item := range items {
+			fn(item)
+		}
+		return true
+	}
+
+	items = slices.Clone(items)
+	slices.SortFunc(items, func(a, b T) int { return cmp.Compare(key(a), key(b)) })
+	deadline, _ := ctx.Deadline()
+	cursor := run.resumed.Cursor
+	for _, item := range items {
+		k := key(item)
+		if cursor != "" && k <= cursor {
+			continue
+		}
+		if time.Until(deadline) < jobTimeoutMargin {
+			run.handOver(ctx, cursor, result)
+			return false
+		}
+		fn(item)
+		cursor = k
+	}
+	return true
+}
+
+// invokeSelf starts an asynchronous invocation of this function with the
+// trigger as its event
+func invokeSelf(ctx context.Context, trigger jobTrigger) error {
+	sess, err := awsSession()
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(trigger)
+	if err != nil {
+		return err
+	}
+	client := lambda.New(sess)
+	xray.AWS(client.Client)
+	_, err = client.InvokeWithContext(ctx, &lambda.InvokeInput{
+		FunctionName:   aws.String(lambdacontext.FunctionName),
+		InvocationType: aws.String(lambda.InvocationTypeEvent),
+		Payload:        payload,
+	})
+	return err
+}
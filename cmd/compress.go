@@ -0,0 +1,98 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// minCompressSize is the smallest body worth compressing; below it the
+// base64 overhead and CPU cost outweigh the savings.
+const minCompressSize = 1024
+
+// requestHeader returns a header of an HTTP-sourced event (any casing), or ""
+func requestHeader(event json.RawMessage, name string) string {
+	var envelope struct {
+		Headers map[string]string `json:"headers"`
+	}
+	if err := json.Unmarshal(event, &envelope); err != nil {
+		return ""
+	}
+	for k, v := range envelope.Headers {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return ""
+}
+
+// negotiateEncoding picks br or gzip from an Accept-Encoding header,
+// preferring br on equal quality, or "" when neither is acceptable.
+func negotiateEncoding(accept string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if name != "br" && name != "gzip" || q <= 0 {
+			continue
+		}
+		if q > bestQ || q == bestQ && name == "br" {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+// compressResponse compresses a large text body with the client's preferred
+// encoding, returning it base64-encoded as API Gateway and Function URLs
+// require for binary bodies. Other responses are returned unchanged.
+func compressResponse(resp LambdaResponse, acceptEncoding string) LambdaResponse {
+	if len(resp.Body) < minCompressSize || resp.IsBase64Encoded || resp.Headers["Content-Encoding"] != "" {
+		return resp
+	}
+	contentType := resp.Headers["Content-Type"]
+	if !strings.HasPrefix(contentType, "application/json") && !strings.HasPrefix(contentType, "text/") {
+		return resp
+	}
+	encoding := negotiateEncoding(acceptEncoding)
+	if encoding == "" {
+		return resp
+	}
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+	var w io.WriteCloser
+	if encoding == "br" {
+		w = brotli.NewWriterLevel(buf, brotli.DefaultCompression)
+	} else {
+		w = gzip.NewWriter(buf)
+	}
+	if _, err := io.WriteString(w, resp.Body); err != nil {
+		return resp
+	}
+	if err := w.Close(); err != nil {
+		return resp
+	}
+
+	headers := make(map[string]string, len(resp.Headers)+2)
+	for k, v := range resp.Headers {
+		headers[k] = v
+	}
+	headers["Content-Encoding"] = encoding
+	headers["Vary"] = "Accept-Encoding"
+	resp.Headers = headers
+	resp.Body = base64.StdEncoding.EncodeToString(buf.Bytes())
+	resp.IsBase64Encoded = true
+	return resp
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/clients"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/config"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/logic"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+// confirmSMS turns the prospect's tentative booking into a confirmed one
+func (p *pipeline) confirmSMS(ctx context.Context, requestID, phone string) string {
+	session, err := p.supabase.GetSMSSession(ctx, phone)
+	if err != nil {
+		slog.ErrorContext(ctx, "sms_session_fetch_failed", "error", err)
+		return "Sorry, I couldn't look up your showing right now. Please try again in a minute."
+	}
+	if session == nil || !session.Booked() {
+		return "You don't have a showing waiting for confirmation. Text a property address any time to get showing times."
+	}
+	if !session.Tentative() {
+		return fmt.Sprintf("Your showing at %s on %s is already confirmed. Reply C to cancel.",
+			session.PropertyAddress, showingTime(*session.BookedStart, session.TimeZone))
+	}
+	if time.Now().After(*session.ConfirmBy) {
+		// The release job hasn't run yet; release it now so the reply is accurate
+		p.releaseHold(ctx, requestID, *session)
+		return "Sorry, that hold expired before we got your YES. Text the address again for fresh showing times."
+	}
+
+	token, err := p.supabase.GetAccessToken(ctx, session.AgentEmail)
+	if err == nil {
+		err = p.calendar.PatchEvent(ctx, token, session.AgentEmail, session.EventID, models.CalendarEvent{Status: models.EventConfirmed})
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "calendar_event_confirm_failed", "event_id", session.EventID, "error", err)
+		return "I couldn't confirm your showing right now. Please reply YES again in a minute."
+	}
+	if err := p.supabase.ConfirmSMSBooking(ctx, phone, session.EventID); errors.Is(err, clients.ErrConflict) {
+		// The release job freed the slot while we were confirming the event
+		slog.WarnContext(ctx, "hold_already_resolved", "event_id", session.EventID)
+		return "Sorry, that hold expired before we got your YES. Text the address again for fresh showing times."
+	} else if err != nil {
+		// The event is confirmed; the release job would delete it, so retry
+		slog.ErrorContext(ctx, "sms_session_save_failed", "event_id", session.EventID, "error", err)
+		return "I couldn't confirm your showing right now. Please reply YES again in a minute."
+	}
+
+	metrics.Incr(ctx, "BookingConfirmed")
+	p.transitionBooking(ctx, session.BookingID, models.BookingConfirmed, "")
+	p.announceSMSBooking(ctx, requestID, phone, session)
+	return fmt.Sprintf("You're booked! Showing at %s on %s with %s. Reply C to cancel.",
+		session.PropertyAddress, showingTime(*session.BookedStart, session.TimeZone), session.AgentName)
+}
+
+// releaseUnconfirmedHolds frees the slots of tentative SMS bookings whose
+// confirmation window has passed, and lets the agent and prospect know.
+func releaseUnconfirmedHolds(ctx context.Context, requestID string, cfg config.Config) jobResult {
+	var result jobResult
+	p := pipelineFor(cfg)
+
+	sessions, err := p.supabase.ListExpiredHolds(ctx, time.Now())
+	if err != nil {
+		slog.ErrorContext(ctx, "expired_holds_fetch_failed", "error", err)
+		result.Errors = append(result.Errors, err.Error())
+		return result
+	}
+
+	for _, session := range sessions {
+		err := p.releaseHold(ctx, requestID, session)
+		if errors.Is(err, errHoldResolved) {
+			continue
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", session.PropertyID, err))
+			continue
+		}
+		msg := fmt.Sprintf("We didn't get your YES, so your hold for %s on %s was released. Text the address again for fresh showing times.",
+			session.PropertyAddress, showingTime(*session.BookedStart, session.TimeZone))
+		if err := p.sendText(ctx, "", session.Phone, logic.Location(session.TimeZone), msg, "hold_release"); err != nil {
+			slog.WarnContext(ctx, "hold_release_sms_not_sent", "property_id", session.PropertyID, "error", err)
+		}
+		result.Processed++
+	}
+	return result
+}
+
+// errHoldResolved means a hold was confirmed or replaced before it could be
+// released
+var errHoldResolved = errors.New("hold already resolved")
+
+// releaseHold deletes a tentative booking's calendar event and session,
+// cancels its booking and tells the agent's team the slot is free again.
+// The event goes first: until the session is deleted the release job
+// retries, so a failure at either step leaves the hold to be released on
+// the next run rather than a held booking nothing will clean up. A YES
+// confirming the hold in between wins (errHoldResolved) and the event is
+// restored.
+func (p *pipeline) releaseHold(ctx context.Context, requestID string, session models.SMSSession) error {
+	token, err := p.supabase.GetAccessToken(ctx, session.AgentEmail)
+	if err == nil {
+		// Already deleted (e.g. on an earlier run) counts as done
+		err = p.calendar.DeleteEvent(ctx, token, session.AgentEmail, session.EventID)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "calendar_event_delete_failed", "event_id", session.EventID, "error", err)
+		return err
+	}
+
+	if err := p.supabase.ReleaseHold(ctx, session.Phone, session.EventID); errors.Is(err, clients.ErrConflict) {
+		slog.InfoContext(ctx, "hold_already_resolved", "event_id", session.EventID)
+		p.restoreConfirmedEvent(ctx, token, session)
+		return errHoldResolved
+	} else if err != nil {
+		slog.ErrorContext(ctx, "sms_session_delete_failed", "event_id", session.EventID, "error", err)
+		return err
+	}
+
+	p.transitionBooking(ctx, session.BookingID, models.BookingCancelled, cancelHoldExpired)
+	slog.InfoContext(ctx, "sms_hold_released", "property_id", session.PropertyID, "event_id", session.EventID)
+	metrics.Incr(ctx, "BookingHoldReleased")
+	p.notifyTeam(ctx, requestID, session.AgentZone, fmt.Sprintf(":hourglass: Unconfirmed showing released: %s on %s with %s (prospect %s didn't reply YES).",
+		session.PropertyAddress, showingTime(*session.BookedStart, session.TimeZone), session.AgentName, session.Phone))
+	return nil
+}
+
+// restoreConfirmedEvent puts back the event of a hold that was confirmed
+// while releaseHold was deleting it. Google keeps deleted events, and
+// setting one's status back to confirmed restores it. A hold that was
+// replaced instead is left deleted.
+func (p *pipeline) restoreConfirmedEvent(ctx context.Context, token string, session models.SMSSession) {
+	current, err := p.supabase.GetSMSSession(ctx, session.Phone)
+	if err != nil {
+		slog.ErrorContext(ctx, "sms_session_fetch_failed", "event_id", session.EventID, "error", err)
+		return
+	}
+	if current == nil || current.EventID != session.EventID || current.Tentative() {
+		return
+	}
+	if err := p.calendar.PatchEvent(ctx, token, session.AgentEmail, session.EventID, models.CalendarEvent{Status: models.EventConfirmed}); err != nil {
+		slog.ErrorContext(ctx, "calendar_event_restore_failed", "event_id", session.EventID, "error", err)
+		metrics.Incr(ctx, "CalendarEventRestoreFailed")
+		return
+	}
+	slog.WarnContext(ctx, "calendar_event_restored", "event_id", session.EventID)
+}
+
+// formatWindow renders a confirmation window for a text: "30 minutes", "2 hours"
+func formatWindow(d time.Duration) string {
+	if d >= time.Hour && d%time.Hour == 0 {
+		if d == time.Hour {
+			return "1 hour"
+		}
+		return fmt.Sprintf("%d hours", d/time.Hour)
+	}
+	return fmt.Sprintf("%d minutes", int(d.Minutes()))
+}
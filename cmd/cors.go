@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/config"
+)
+
+const (
+	corsAllowMethods = "GET, POST, OPTIONS"
+	corsAllowHeaders = "Content-Type, Authorization"
+	corsMaxAge       = "600"
+)
+
+// requestMethod returns the HTTP method of an HTTP-sourced event (Function
+// URL / API Gateway 2.0, or API Gateway 1.0), or "" for direct invokes.
+func requestMethod(event json.RawMessage) string {
+	var envelope struct {
+		HTTPMethod     string `json:"httpMethod"`
+		RequestContext struct {
+			HTTP struct {
+				Method string `json:"method"`
+			} `json:"http"`
+		} `json:"requestContext"`
+	}
+	if err := json.Unmarshal(event, &envelope); err != nil {
+		return ""
+	}
+	if envelope.RequestContext.HTTP.Method != "" {
+		return envelope.RequestContext.HTTP.Method
+	}
+	return envelope.HTTPMethod
+}
+
+// corsPreflight answers a browser's OPTIONS preflight. Any tenant's origin
+// passes, since the preflight can't say which tenant it is for; applyCORS
+// decides whether the response itself may be read.
+func corsPreflight(cfg config.Config, origin string) LambdaResponse {
+	if !cfg.OriginListed(origin) {
+		return LambdaResponse{StatusCode: 403}
+	}
+	return LambdaResponse{
+		StatusCode: 204,
+		Headers: map[string]string{
+			"Access-Control-Allow-Origin":  origin,
+			"Access-Control-Allow-Methods": corsAllowMethods,
+			"Access-Control-Allow-Headers": corsAllowHeaders,
+			"Access-Control-Max-Age":       corsMaxAge,
+			"Vary":                         "Origin",
+		},
+	}
+}
+
+// applyCORS adds CORS headers to a response for an allowed origin
+func applyCORS(resp LambdaResponse, cfg config.Config, tenantID, origin string) LambdaResponse {
+	if origin == "" || !cfg.OriginAllowed(tenantID, origin) {
+		return resp
+	}
+	headers := make(map[string]string, len(resp.Headers)+3)
+	for k, v := range resp.Headers {
+		headers[k] = v
+	}
+	headers["Access-Control-Allow-Origin"] = origin
+	headers["Access-Control-Expose-Headers"] = "Server-Timing"
+	if vary := headers["Vary"]; vary != "" && !strings.Contains(vary, "Origin") {
+		headers["Vary"] = vary + ", Origin"
+	} else if vary == "" {
+		headers["Vary"] = "Origin"
+	}
+	resp.Headers = headers
+	return resp
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/inflight"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/logging"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+// duplicateCallWindow is how long a finished tool call's result still
+// answers duplicates of it
+const duplicateCallWindow = 2 * time.Second
+
+// duplicateCalls coalesces the identical tool calls VAPI sometimes fires
+// milliseconds apart into one lookup (and booking). Only container mode
+// serves concurrent requests from one process; Lambda runs one invocation
+// per environment at a time, so there a duplicate is only answered from the
+// window when it lands on the environment that just served the original.
+var duplicateCalls = inflight.New[availabilityResult](duplicateCallWindow)
+
+// answerOnce runs answer once for identical concurrent requests of the same
+// voice call (keyed by call ID and a hash of the whole request, so calls
+// differing in any field, such as the date range, page or slot to book, run
+// separately). Duplicates share the first request's result, so a tool call
+// fired twice books its showing once. Requests outside a call run answer
+// directly.
+func answerOnce(ctx context.Context, req models.Request, answer func() availabilityResult) availabilityResult {
+	callID := logging.CallID(ctx)
+	if callID == "" {
+		return answer()
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return answer()
+	}
+	sum := sha256.Sum256(body)
+	key := callID + ":" + hex.EncodeToString(sum[:8])
+
+	result, _, shared := duplicateCalls.Do(key, func() (availabilityResult, error) {
+		return answer(), nil
+	})
+	if shared {
+		slog.InfoContext(ctx, "duplicate_call_coalesced", "query", req.Query, "action", req.Action)
+		metrics.Incr(ctx, "DuplicateCallCoalesced")
+	}
+	return result
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/clients"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+)
+
+// mayContact reports whether phone may be texted, emailed or stored as a
+// marketing lead for tenantID. A failed lookup counts as do-not-contact: a
+// missed message can be retried, a compliance violation can't be undone.
+func mayContact(ctx context.Context, supa *clients.SupabaseClient, tenantID, phone, purpose string) bool {
+	if phone == "" {
+		return true
+	}
+	blocked, err := supa.OnDoNotContact(ctx, tenantID, phone)
+	if err != nil {
+		slog.ErrorContext(ctx, "do_not_contact_check_failed", "purpose", purpose, "error", err)
+		blocked = true
+	}
+	if blocked {
+		slog.InfoContext(ctx, "do_not_contact_suppressed", "purpose", purpose)
+		metrics.Incr(ctx, "DoNotContactSuppressed", "Purpose", purpose)
+	}
+	return !blocked
+}
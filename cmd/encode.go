@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+)
+
+// maxPooledBuffer keeps unusually large responses from pinning memory in the pool
+const maxPooledBuffer = 64 << 10
+
+var bufferPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledBuffer {
+		bufferPool.Put(buf)
+	}
+}
+
+// encodeBody marshals v to a JSON string using a pooled buffer
+func encodeBody(v any) (string, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		return "", err
+	}
+	return string(bytes.TrimSuffix(buf.Bytes(), []byte("\n"))), nil
+}
+
+// envelope is a fully encoded Lambda response. The runtime JSON-encodes
+// whatever the handler returns, but passes an io.Reader that encodes to "{}"
+// through untouched, so returning one skips a second reflection pass over
+// the envelope and the re-escaping of Body. Close hands the buffer back to
+// the pool once the runtime has posted the response.
+type envelope struct {
+	buf *bytes.Buffer
+}
+
+func (e *envelope) Read(p []byte) (int, error) { return e.buf.Read(p) }
+
+func (e *envelope) ContentType() string { return "application/json" }
+
+func (e *envelope) Close() error {
+	if e.buf != nil {
+		putBuffer(e.buf)
+		e.buf = nil
+	}
+	return nil
+}
+
+// lambdaHandler is HandleRequest with the envelope encoded in a single pass.
+// Background work started during the invocation (the AppFolio probe, then
+// any alerts it or the request raised) finishes before it returns.
+func lambdaHandler(ctx context.Context, event json.RawMessage) (io.Reader, error) {
+	resp, err := HandleRequest(ctx, event)
+	waitAppFolioProbe(ctx)
+	flushAlerts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	buf := getBuffer()
+	resp.writeJSON(buf)
+	return &envelope{buf: buf}, nil
+}
+
+// writeJSON writes the API Gateway envelope, escaping Body straight into
+// buf; the output matches encoding/json with HTML escaping off.
+func (r LambdaResponse) writeJSON(buf *bytes.Buffer) {
+	// Body dominates; leave room for its escaped quotes
+	buf.Grow(len(r.Body) + len(r.Body)/8 + 128)
+
+	buf.WriteString(`{"statusCode":`)
+	buf.Write(strconv.AppendInt(buf.AvailableBuffer(), int64(r.StatusCode), 10))
+	buf.WriteString(`,"headers":`)
+	if r.Headers == nil {
+		buf.WriteString("null")
+	} else {
+		keys := make([]string, 0, len(r.Headers))
+		for k := range r.Headers {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeJSONString(buf, k)
+			buf.WriteByte(':')
+			writeJSONString(buf, r.Headers[k])
+		}
+		buf.WriteByte('}')
+	}
+	buf.WriteString(`,"body":`)
+	writeJSONString(buf, r.Body)
+	if r.IsBase64Encoded {
+		buf.WriteString(`,"isBase64Encoded":true`)
+	}
+	buf.WriteByte('}')
+}
+
+const hexDigits = "0123456789abcdef"
+
+// writeJSONString writes s as a JSON string literal, escaping the same
+// characters encoding/json does (without HTML escaping)
+func writeJSONString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' {
+				i++
+				continue
+			}
+			buf.WriteString(s[start:i])
+			switch b {
+			case '"', '\\':
+				buf.WriteByte('\\')
+				buf.WriteByte(b)
+			case '\n':
+				buf.WriteString(`\n`)
+			case '\r':
+				buf.WriteString(`\r`)
+			case '\t':
+				buf.WriteString(`\t`)
+			default:
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hexDigits[b>>4])
+				buf.WriteByte(hexDigits[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if c == utf8.RuneError && size == 1 {
+			buf.WriteString(s[start:i])
+			buf.WriteRune(utf8.RuneError)
+			i += size
+			start = i
+			continue
+		}
+		// U+2028/U+2029 are valid JSON but break JavaScript consumers
+		if c == '\u2028' || c == '\u2029' {
+			buf.WriteString(s[start:i])
+			buf.WriteString(`\u202`)
+			buf.WriteByte(hexDigits[c&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf.WriteString(s[start:])
+	buf.WriteByte('"')
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/config"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/events"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+// eventSinks receive the domain events recorded during each invocation
+var eventSinks []events.Sink
+
+// registerEventSinks configures where domain events are delivered. With no
+// sinks configured, events are still recorded but dropped at flush.
+func registerEventSinks(cfg config.Config) {
+	residentBuckets := make(map[string]models.DataResidency)
+	for tenantID, residency := range cfg.TenantDataResidency {
+		if residency.EventLogBucket != "" {
+			residentBuckets[tenantID] = residency
+		}
+	}
+	if cfg.EventLogBucket == "" && len(residentBuckets) == 0 && cfg.EventBusName == "" {
+		return
+	}
+	sess, err := awsSession()
+	if err != nil {
+		slog.Error("aws_session_failed", "error", err)
+		return
+	}
+	if cfg.EventLogBucket != "" || len(residentBuckets) > 0 {
+		log := events.NewS3Log(sess, cfg.EventLogBucket, cfg.EventLogPrefix)
+		for tenantID, residency := range residentBuckets {
+			log.RouteTenant(sess, tenantID, residency.EventLogBucket, residency.Region)
+		}
+		eventSinks = append(eventSinks, log)
+	}
+	if cfg.EventBusName != "" {
+		eventSinks = append(eventSinks, events.NewEventBridgeBus(sess, cfg.EventBusName, cfg.EventBusSource))
+	}
+}
+
+// flushEvents delivers the invocation's domain events. Failures are logged
+// rather than surfaced: the caller has already been served.
+func flushEvents(ctx context.Context, rec *events.Recorder) {
+	if err := rec.Flush(ctx, eventSinks...); err != nil {
+		slog.ErrorContext(ctx, "event_flush_failed", "error", err)
+	}
+}
+
+// emitMatchFailed records that an inquiry could not be matched to a bookable
+// property/agent, and at which step
+func emitMatchFailed(ctx context.Context, req models.Request, propID, stage, reason string) {
+	events.Emit(ctx, events.Event{
+		Type:       events.TypeMatchFailed,
+		TenantID:   req.TenantID,
+		PropertyID: propID,
+		Phone:      req.Phone,
+		Data:       events.MatchFailed{Stage: stage, Reason: reason, Query: req.Query},
+	})
+}
+
+// emitOffer records the showing times offered for a property
+func emitOffer(ctx context.Context, req models.Request, propID string, avail models.Availability) {
+	suggested := make([]time.Time, 0, len(avail.Suggestions))
+	for _, s := range avail.Suggestions {
+		suggested = append(suggested, s.Start)
+	}
+	events.Emit(ctx, events.Event{
+		Type:       events.TypeOffer,
+		TenantID:   req.TenantID,
+		PropertyID: propID,
+		Phone:      req.Phone,
+		Data:       events.ShowingOffered{SlotsAvailable: avail.TotalSlotsAvailable, Suggested: suggested},
+	})
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/breaker"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+)
+
+// skipDegraded reports whether an optional step should be skipped because
+// its dependency has been failing in this container (see breaker.Degraded),
+// so partial outages cost the step rather than the whole request.
+func skipDegraded(ctx context.Context, dependency, step string) bool {
+	if !breaker.Degraded(dependency) {
+		return false
+	}
+	slog.WarnContext(ctx, "degraded_dependency_skipped", "dependency", dependency, "step", step)
+	metrics.Incr(ctx, "DependencySkipped", "Dependency", dependency)
+	return true
+}
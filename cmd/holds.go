@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/logging"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+// slotHolder identifies who slots offered now are held for: the voice call,
+// or else the prospect's phone. Empty means nothing is held, e.g. for a
+// direct availability check.
+func slotHolder(ctx context.Context, phone string) string {
+	if callID := logging.CallID(ctx); callID != "" {
+		return "call:" + callID
+	}
+	if phone != "" {
+		return "phone:" + phone
+	}
+	return ""
+}
+
+// holdSlots holds the first SlotHoldCount of slots for holder and returns
+// slots without those another caller held first
+func (p *pipeline) holdSlots(ctx context.Context, holder, email, propertyID string, slots []models.TimeSlot) []models.TimeSlot {
+	if p.holds == nil || holder == "" {
+		return slots
+	}
+	kept := make([]models.TimeSlot, 0, len(slots))
+	held := 0
+	for i, slot := range slots {
+		if i >= p.cfg.SlotHoldCount {
+			kept = append(kept, slot)
+			continue
+		}
+		ok, err := p.holds.Hold(ctx, email, holder, propertyID, slot.Start, slot.End, p.cfg.SlotHoldTTL)
+		if err != nil {
+			// Offer it unheld; booking still re-checks the calendar
+			slog.WarnContext(ctx, "slot_hold_failed", "start", slot.Start, "error", err)
+			kept = append(kept, slot)
+			continue
+		}
+		if !ok {
+			slog.InfoContext(ctx, "slot_hold_conflict", "start", slot.Start)
+			metrics.Incr(ctx, "SlotHoldConflict")
+			continue
+		}
+		held++
+		kept = append(kept, slot)
+	}
+	if held > 0 {
+		slog.InfoContext(ctx, "slots_held", "count", held, "ttl", p.cfg.SlotHoldTTL)
+	}
+	return kept
+}
+
+// releaseSlotHold drops holder's hold on a slot that has just been booked
+func (p *pipeline) releaseSlotHold(ctx context.Context, holder, email string, start time.Time) {
+	if p.holds == nil || holder == "" {
+		return
+	}
+	if err := p.holds.Release(ctx, email, holder, start); err != nil {
+		// It expires on its own; the booked event already blocks the slot
+		slog.WarnContext(ctx, "slot_hold_release_failed", "start", start, "error", err)
+	}
+}
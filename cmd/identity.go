@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/clients"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/config"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+// requireIDVerification gates self-guided access on the lead's identity
+// verification. When the lead isn't verified yet it starts (or reuses) a
+// verification session and returns the reply asking them to complete it.
+func (p *pipeline) requireIDVerification(ctx context.Context, phone string, choice int) (string, bool) {
+	if p.identity == nil {
+		return "", true
+	}
+
+	lead, err := p.supabase.GetLead(ctx, phone)
+	if err != nil {
+		slog.ErrorContext(ctx, "lead_fetch_failed", "error", err)
+		return "Sorry, I couldn't check your ID verification right now. Please try again in a minute.", false
+	}
+	if lead.IDVerified() {
+		return "", true
+	}
+
+	switch {
+	case lead != nil && lead.IDVerificationStatus == models.IDVerificationProcessing:
+		return fmt.Sprintf("We're still checking your ID. Reply %d again in a few minutes to get your access code.", choice), false
+	case lead != nil && lead.IDVerificationStatus == models.IDVerificationRequiresInput && lead.IDVerificationURL != "":
+		return idVerificationReply(lead.IDVerificationURL, choice), false
+	}
+
+	session, err := p.identity.CreateVerificationSession(ctx, phone)
+	if err != nil {
+		slog.ErrorContext(ctx, "id_verification_start_failed", "error", err)
+		return "Self-guided tours require ID verification, and I couldn't start it right now. Please try again in a minute.", false
+	}
+	if err := p.supabase.SaveLead(ctx, models.Lead{
+		Phone:                   phone,
+		IDVerificationStatus:    session.Status,
+		IDVerificationSessionID: session.ID,
+		IDVerificationURL:       session.URL,
+	}); err != nil {
+		slog.ErrorContext(ctx, "lead_save_failed", "error", err)
+	}
+
+	slog.InfoContext(ctx, "id_verification_started", "session_id", session.ID)
+	metrics.Incr(ctx, "IDVerificationStarted")
+	return idVerificationReply(session.URL, choice), false
+}
+
+func idVerificationReply(verifyURL string, choice int) string {
+	return fmt.Sprintf("Self-guided tours require a quick ID check before we can send your access code: %s\nOnce you're done, reply %d again to get your code.", verifyURL, choice)
+}
+
+// handleIdentityWebhook records Stripe Identity verification results on the lead
+func handleIdentityWebhook(ctx context.Context, cfg config.Config, body []byte, signature string) LambdaResponse {
+	slog.InfoContext(ctx, "event_type_detected", "type", "stripe_identity")
+
+	if cfg.StripeWebhookSecret == "" || !clients.ValidateStripeSignature(cfg.StripeWebhookSecret, signature, body, clients.StripeSignatureTolerance) {
+		slog.WarnContext(ctx, "stripe_signature_invalid")
+		return errorResponse(403, "Invalid signature")
+	}
+
+	event, ok := clients.ParseIdentityEvent(body)
+	if !ok {
+		// Acknowledge other event types so Stripe doesn't retry them
+		return LambdaResponse{StatusCode: 200, Body: `{"received":true}`}
+	}
+
+	phone := event.Session.Metadata["phone"]
+	if phone == "" {
+		slog.WarnContext(ctx, "id_verification_without_phone", "session_id", event.Session.ID)
+		return LambdaResponse{StatusCode: 200, Body: `{"received":true}`}
+	}
+
+	lead := models.Lead{
+		Phone:                   phone,
+		IDVerificationStatus:    event.Session.Status,
+		IDVerificationSessionID: event.Session.ID,
+	}
+	if event.Session.Status == models.IDVerificationVerified {
+		now := time.Now().UTC()
+		lead.IDVerifiedAt = &now
+	}
+
+	sb := newSupabaseClient(cfg)
+	if err := sb.SaveLead(ctx, lead); err != nil {
+		slog.ErrorContext(ctx, "lead_save_failed", "session_id", event.Session.ID, "error", err)
+		// Let Stripe retry the delivery
+		return errorResponse(500, "Failed to record verification")
+	}
+
+	slog.InfoContext(ctx, "id_verification_updated", "session_id", event.Session.ID, "event", event.Type, "status", event.Session.Status)
+	metrics.Incr(ctx, "IDVerificationUpdated", "Status", event.Session.Status)
+	return LambdaResponse{StatusCode: 200, Body: `{"received":true}`}
+}
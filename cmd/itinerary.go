@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/clients"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/config"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/logic"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+// showingSummaryPrefix marks calendar events created by this service
+const showingSummaryPrefix = "Showing: "
+
+// itinerarySummaryPrefix marks the all-day itinerary event on an agent's calendar
+const itinerarySummaryPrefix = "Showing itinerary"
+
+// buildItineraries writes each agent's route for today's booked showings to
+// an all-day calendar event, creating it on the first run of the day and
+// updating its description afterwards.
+func buildItineraries(ctx context.Context, cfg config.Config, run *jobRun) jobResult {
+	result := run.result()
+	supa := newSupabaseClient(cfg)
+	cal := clients.NewCalendarClient()
+
+	var maps *clients.MapsClient
+	if cfg.GoogleMapsAPIKey != "" {
+		maps = clients.NewMapsClient(cfg.GoogleMapsAPIKey)
+	}
+
+	agents, err := supa.ListAgents(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "agent_roster_fetch_failed", "error", err)
+	}
+
+	loc := logic.ScheduleLocation(cfg.ScheduleRules)
+	now := time.Now().In(loc)
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+
+	var roster []models.AgentInfo
+	for _, agent := range logic.RosterByZone(agents) {
+		roster = append(roster, agent)
+	}
+	agentEmail := func(agent models.AgentInfo) string { return agent.Email }
+	eachCheckpointed(ctx, run, &result, roster, agentEmail, func(agent models.AgentInfo) {
+		if logic.OnVacation(agent, now) {
+			return
+		}
+		if err := buildAgentItinerary(ctx, supa, cal, maps, agent, dayStart, dayEnd); err != nil {
+			slog.ErrorContext(ctx, "itinerary_failed", "agent", agent.Name, "error", err)
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", agent.Email, err))
+			return
+		}
+		result.Processed++
+	})
+	return result
+}
+
+func buildAgentItinerary(ctx context.Context, supa *clients.SupabaseClient, cal *clients.CalendarClient, maps *clients.MapsClient, agent models.AgentInfo, dayStart, dayEnd time.Time) error {
+	token, err := supa.GetAccessToken(ctx, agent.Email)
+	if err != nil {
+		return err
+	}
+	events, err := cal.ListEvents(ctx, token, agent.Email, dayStart, dayEnd)
+	if err != nil {
+		return err
+	}
+
+	var stops []logic.Stop
+	var itinerary *models.CalendarEvent
+	for i, e := range events {
+		if strings.HasPrefix(e.Summary, itinerarySummaryPrefix) {
+			itinerary = &events[i]
+			continue
+		}
+		start, ok := e.Start.Time()
+		if !ok || !strings.HasPrefix(e.Summary, showingSummaryPrefix) {
+			continue
+		}
+		stop := logic.Stop{Label: e.Location, Start: start}
+		if stop.Label == "" {
+			stop.Label = strings.TrimPrefix(e.Summary, showingSummaryPrefix)
+		}
+		if maps != nil && e.Location != "" {
+			if loc, err := maps.Geocode(ctx, e.Location); err == nil {
+				stop.Lat, stop.Lng, stop.Located = loc.Lat, loc.Lng, true
+			}
+		}
+		stops = append(stops, stop)
+	}
+	if len(stops) == 0 {
+		return nil
+	}
+
+	description := formatItinerary(logic.OrderRoute(stops))
+	if itinerary != nil {
+		return cal.PatchEvent(ctx, token, agent.Email, itinerary.ID, models.CalendarEvent{Description: description})
+	}
+
+	_, err = cal.CreateEvent(ctx, token, agent.Email, models.CalendarEvent{
+		Summary:     fmt.Sprintf("%s (%d showings)", itinerarySummaryPrefix, len(stops)),
+		Description: description,
+		Start:       &models.CalendarEventTime{Date: dayStart.Format("2006-01-02")},
+		End:         &models.CalendarEventTime{Date: dayEnd.Format("2006-01-02")},
+	})
+	return err
+}
+
+func formatItinerary(route []logic.Stop) string {
+	var sb strings.Builder
+	sb.WriteString("Suggested route for today's showings:\n\n")
+	for i, stop := range route {
+		fmt.Fprintf(&sb, "%d. %s — %s", i+1, stop.Start.Format("3:04 PM"), stop.Label)
+		if i > 0 && stop.Located && route[i-1].Located {
+			fmt.Fprintf(&sb, " (%.1f km from previous)", logic.HaversineKm(route[i-1].Lat, route[i-1].Lng, stop.Lat, stop.Lng))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
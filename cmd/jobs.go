@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/clients"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/config"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/ratelimit"
+)
+
+// Background jobs are triggered by an EventBridge schedule whose constant
+// input is {"job": "<name>"}, or by a manual invoke with the same payload.
+const (
+	jobIngestListingFeed = "ingest_listing_feed"
+	jobAgentItineraries  = "agent_itineraries"
+	jobApplicationLinks  = "send_application_links"
+	jobValidateTokens    = "validate_agent_tokens"
+	jobReleaseHolds      = "release_unconfirmed_bookings"
+	jobSyncProperties    = "sync_properties"
+)
+
+// feedUpsertBatchSize bounds the rows sent per Supabase upsert
+const feedUpsertBatchSize = 500
+
+// jobResult is the response body for a job invocation
+type jobResult struct {
+	Job        string   `json:"job"`
+	Success    bool     `json:"success"`
+	Processed  int      `json:"processed"`
+	Errors     []string `json:"errors,omitempty"`
+	DurationMS int64    `json:"durationMs"`
+	// RunID identifies a run across the invocations of a checkpointed job;
+	// Continued is set when this invocation handed the rest to another
+	RunID     string `json:"runId,omitempty"`
+	Continued bool   `json:"continued,omitempty"`
+}
+
+// jobTrigger is a job invocation's event. RunID is set when a
+// checkpointed run re-invokes the function to carry on.
+type jobTrigger struct {
+	Job   string `json:"job"`
+	RunID string `json:"runId,omitempty"`
+}
+
+// detectJob returns the trigger if the event is one
+func detectJob(event json.RawMessage) (jobTrigger, bool) {
+	var trigger jobTrigger
+	if err := json.Unmarshal(event, &trigger); err != nil || trigger.Job == "" {
+		return jobTrigger{}, false
+	}
+	return trigger, true
+}
+
+func runJob(ctx context.Context, requestID string, cfg config.Config, trigger jobTrigger) LambdaResponse {
+	start := time.Now()
+	job := trigger.Job
+	slog.InfoContext(ctx, "job_started", "job", job, "run_id", trigger.RunID)
+	run := startJobRun(ctx, requestID, cfg, trigger)
+	// Bulk work drips out to dependencies at their configured rates
+	ctx = ratelimit.WithPacing(ctx)
+
+	var result jobResult
+	switch job {
+	case jobIngestListingFeed:
+		result = ingestListingFeeds(ctx, cfg)
+	case jobAgentItineraries:
+		result = buildItineraries(ctx, cfg, run)
+	case jobApplicationLinks:
+		result = sendApplicationLinks(ctx, cfg)
+	case jobValidateTokens:
+		result = validateAgentTokens(ctx, cfg, run)
+	case jobReleaseHolds:
+		result = releaseUnconfirmedHolds(ctx, requestID, cfg)
+	case jobSyncProperties:
+		result = syncProperties(ctx, requestID, cfg)
+	default:
+		return errorResponse(400, "Unknown job: "+job)
+	}
+
+	result.Job = job
+	result.Success = len(result.Errors) == 0
+	result.DurationMS = time.Since(start).Milliseconds()
+	run.finish(ctx, result)
+
+	slog.InfoContext(ctx, "job_complete", "job", job, "continued", result.Continued,
+		"processed", result.Processed, "errors", len(result.Errors), "duration_ms", result.DurationMS)
+	metrics.Record(ctx, "JobItemsProcessed", float64(result.Processed), metrics.Count, "Job", job)
+
+	body, _ := json.Marshal(result)
+	return LambdaResponse{StatusCode: 200, Headers: map[string]string{"Content-Type": "application/json"}, Body: string(body)}
+}
+
+// ingestListingFeeds downloads every configured syndication feed and stores
+// the listings in Supabase for the property-lookup fallback.
+func ingestListingFeeds(ctx context.Context, cfg config.Config) jobResult {
+	var result jobResult
+	feeds := clients.NewListingFeedClient()
+	supa := newSupabaseClient(cfg)
+
+	for source, feedURL := range cfg.ListingFeedURLs {
+		listings, err := feeds.Fetch(ctx, source, feedURL)
+		if err != nil {
+			slog.ErrorContext(ctx, "feed_fetch_failed", "feed_source", source, "error", err)
+			result.Errors = append(result.Errors, err.Error())
+			continue
+		}
+
+		for i := 0; i < len(listings); i += feedUpsertBatchSize {
+			end := min(i+feedUpsertBatchSize, len(listings))
+			if err := supa.UpsertFeedListings(ctx, listings[i:end]); err != nil {
+				slog.ErrorContext(ctx, "feed_upsert_failed", "feed_source", source, "error", err)
+				result.Errors = append(result.Errors, err.Error())
+				continue
+			}
+			result.Processed += end - i
+		}
+		slog.InfoContext(ctx, "feed_ingested", "feed_source", source, "listings", len(listings))
+	}
+	return result
+}
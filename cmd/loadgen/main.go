@@ -0,0 +1,310 @@
+// Command loadgen drives synthetic VAPI tool-call traffic at the scheduling
+// service, either its HTTP (container) mode or a deployed Lambda, and
+// reports p50/p95/p99 latency overall and per pipeline stage (from the
+// service's Server-Timing header).
+//
+//	go run ./cmd/loadgen -url http://localhost:8080/ -rps 20 -duration 1m
+//	go run ./cmd/loadgen -function scheduling-service-staging -rps 5
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/lambda"
+)
+
+// defaultQueries mimic how callers describe a property to the voice agent
+var defaultQueries = []string{
+	"123 Main Street",
+	"the two bedroom on Oak Avenue",
+	"4567 Sunset Blvd unit 3",
+	"I'm calling about the house on Maple Drive",
+	"apartment at 890 Pine St number 12",
+	"the listing on Elm Court in Pasadena",
+	"1500 Harbor Way",
+	"townhouse on Cedar Lane",
+	"the three bed on West 5th",
+	"unit B at 22 Birch Road",
+}
+
+// result is the outcome of one request
+type result struct {
+	total  time.Duration
+	stages map[string]time.Duration
+	status int
+	err    error
+}
+
+func main() {
+	url := flag.String("url", "", "HTTP mode endpoint, e.g. http://localhost:8080/")
+	function := flag.String("function", "", "Lambda function name or ARN to invoke instead of -url")
+	rps := flag.Float64("rps", 5, "requests per second")
+	duration := flag.Duration("duration", 30*time.Second, "test duration")
+	maxInFlight := flag.Int("max-inflight", 200, "maximum concurrent requests; ticks beyond this are dropped")
+	queriesFile := flag.String("queries", "", "file with one property query per line (default: built-in samples)")
+	tenant := flag.String("tenant", "", "TenantId to send with every request")
+	timeout := flag.Duration("timeout", 30*time.Second, "per-request timeout")
+	flag.Parse()
+
+	if (*url == "") == (*function == "") {
+		log.Fatal("exactly one of -url or -function is required")
+	}
+	if *rps <= 0 {
+		log.Fatal("-rps must be positive")
+	}
+
+	queries := defaultQueries
+	if *queriesFile != "" {
+		var err error
+		if queries, err = readLines(*queriesFile); err != nil {
+			log.Fatalf("reading queries: %v", err)
+		}
+	}
+
+	var send func(ctx context.Context, payload []byte) result
+	if *url != "" {
+		client := &http.Client{Timeout: *timeout}
+		send = func(ctx context.Context, payload []byte) result { return sendHTTP(ctx, client, *url, payload) }
+	} else {
+		svc := lambda.New(session.Must(session.NewSession()))
+		send = func(ctx context.Context, payload []byte) result { return invokeLambda(ctx, svc, *function, payload) }
+	}
+
+	var (
+		mu      sync.Mutex
+		results []result
+		dropped int
+		wg      sync.WaitGroup
+	)
+	sem := make(chan struct{}, *maxInFlight)
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / *rps))
+	defer ticker.Stop()
+	deadline := time.After(*duration)
+
+	log.Printf("sending %.1f req/s for %s", *rps, *duration)
+loop:
+	for {
+		select {
+		case <-deadline:
+			break loop
+		case <-ticker.C:
+			select {
+			case sem <- struct{}{}:
+			default:
+				dropped++
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+				defer cancel()
+
+				r := send(ctx, vapiPayload(queries[rand.IntN(len(queries))], *tenant))
+				mu.Lock()
+				results = append(results, r)
+				mu.Unlock()
+			}()
+		}
+	}
+	wg.Wait()
+
+	report(os.Stdout, results, dropped, *duration)
+}
+
+// vapiPayload builds a tool-calls webhook like the one VAPI sends mid-call
+func vapiPayload(query, tenant string) []byte {
+	args := map[string]string{
+		"Query": query,
+		"Phone": fmt.Sprintf("+1555%07d", rand.IntN(10_000_000)),
+	}
+	if tenant != "" {
+		args["TenantId"] = tenant
+	}
+	payload := map[string]interface{}{
+		"message": map[string]interface{}{
+			"type": "tool-calls",
+			"toolCalls": []map[string]interface{}{{
+				"id":   fmt.Sprintf("call_%016x", rand.Uint64()),
+				"type": "function",
+				"function": map[string]interface{}{
+					"name":      "check_availability",
+					"arguments": args,
+				},
+			}},
+			"artifact": map[string]interface{}{"messages": []interface{}{}},
+		},
+	}
+	body, _ := json.Marshal(payload)
+	return body
+}
+
+func sendHTTP(ctx context.Context, client *http.Client, url string, payload []byte) result {
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(payload))
+	if err != nil {
+		return result{err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return result{total: time.Since(start), err: err}
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	return result{
+		total:  time.Since(start),
+		stages: parseServerTiming(resp.Header.Get("Server-Timing")),
+		status: resp.StatusCode,
+	}
+}
+
+func invokeLambda(ctx context.Context, svc *lambda.Lambda, function string, payload []byte) result {
+	start := time.Now()
+	out, err := svc.InvokeWithContext(ctx, &lambda.InvokeInput{
+		FunctionName: aws.String(function),
+		Payload:      payload,
+	})
+	total := time.Since(start)
+	if err != nil {
+		return result{total: total, err: err}
+	}
+	if out.FunctionError != nil {
+		return result{total: total, err: fmt.Errorf("function error: %s", aws.StringValue(out.FunctionError))}
+	}
+
+	var resp struct {
+		StatusCode int               `json:"statusCode"`
+		Headers    map[string]string `json:"headers"`
+	}
+	if err := json.Unmarshal(out.Payload, &resp); err != nil {
+		return result{total: total, err: err}
+	}
+	return result{total: total, stages: parseServerTiming(resp.Headers["Server-Timing"]), status: resp.StatusCode}
+}
+
+// parseServerTiming reads "name;dur=12.5, other;dur=3" into durations
+func parseServerTiming(header string) map[string]time.Duration {
+	stages := make(map[string]time.Duration)
+	for _, metric := range strings.Split(header, ",") {
+		parts := strings.Split(strings.TrimSpace(metric), ";")
+		if parts[0] == "" {
+			continue
+		}
+		for _, p := range parts[1:] {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(p), "dur="); ok {
+				if ms, err := strconv.ParseFloat(v, 64); err == nil {
+					stages[parts[0]] += time.Duration(ms * float64(time.Millisecond))
+				}
+			}
+		}
+	}
+	return stages
+}
+
+func report(w io.Writer, results []result, dropped int, duration time.Duration) {
+	byStage := make(map[string][]time.Duration)
+	statuses := make(map[int]int)
+	var totals []time.Duration
+	errs := 0
+	for _, r := range results {
+		if r.err != nil {
+			errs++
+			continue
+		}
+		statuses[r.status]++
+		totals = append(totals, r.total)
+		for name, d := range r.stages {
+			byStage[name] = append(byStage[name], d)
+		}
+	}
+
+	fmt.Fprintf(w, "\nrequests: %d  achieved: %.1f req/s  errors: %d  dropped: %d\n",
+		len(results), float64(len(results))/duration.Seconds(), errs, dropped)
+	codes := make([]int, 0, len(statuses))
+	for code := range statuses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		fmt.Fprintf(w, "  HTTP %d: %d\n", code, statuses[code])
+	}
+
+	fmt.Fprintf(w, "\n%-12s %8s %10s %10s %10s\n", "stage", "count", "p50", "p95", "p99")
+	printRow(w, "total", totals)
+	// Pipeline order, then anything else the service reported
+	order := []string{"search", "property", "agent", "token", "calendar", "slots"}
+	seen := make(map[string]bool)
+	for _, name := range order {
+		seen[name] = true
+		if d, ok := byStage[name]; ok {
+			printRow(w, name, d)
+		}
+	}
+	var others []string
+	for name := range byStage {
+		if !seen[name] {
+			others = append(others, name)
+		}
+	}
+	sort.Strings(others)
+	for _, name := range others {
+		printRow(w, name, byStage[name])
+	}
+}
+
+func printRow(w io.Writer, name string, d []time.Duration) {
+	sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+	fmt.Fprintf(w, "%-12s %8d %10s %10s %10s\n", name, len(d),
+		percentile(d, 50), percentile(d, 95), percentile(d, 99))
+}
+
+// percentile returns the p-th percentile of sorted durations (nearest rank)
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(float64(len(sorted))*p/100+0.5) - 1
+	idx = max(0, min(idx, len(sorted)-1))
+	return sorted[idx].Round(100 * time.Microsecond)
+}
+
+func readLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("%s has no queries", path)
+	}
+	return lines, scanner.Err()
+}
@@ -3,19 +3,28 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log/slog"
 	"os"
+	"strings"
 	"time"
-	_ "time/tzdata"
+	_ "time/tzdata" // the provided.* runtimes (x86_64 and arm64) don't guarantee a zoneinfo database
 
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-lambda-go/lambdacontext"
 	"github.com/aws/aws-xray-sdk-go/xray"
 	"github.com/vishnuanilkumar/go-scheduling-service/internal/clients"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/config"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/egress"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/events"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/faultinject"
 	"github.com/vishnuanilkumar/go-scheduling-service/internal/logging"
 	"github.com/vishnuanilkumar/go-scheduling-service/internal/logic"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
 	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/ratelimit"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/usage"
 )
 
 // LambdaResponse wraps the output for API Gateway compatibility
@@ -23,16 +32,31 @@ type LambdaResponse struct {
 	StatusCode int               `json:"statusCode"`
 	Headers    map[string]string `json:"headers"`
 	Body       string            `json:"body"`
+	// IsBase64Encoded marks a binary (e.g. compressed) Body
+	IsBase64Encoded bool `json:"isBase64Encoded,omitempty"`
 }
 
 func init() {
 	logging.Init()
+	cfg := config.Load()
+	registerAlerting(cfg)
+	registerEventSinks(cfg)
+	registerUsageCounter(cfg)
+	for dependency, limit := range cfg.RateLimits {
+		ratelimit.Configure(dependency, limit.PerMinute, limit.Burst)
+	}
+	egress.SetAllowlist(cfg.EgressAllowlist)
+	registerClientCertificates(cfg)
+	if err := faultinject.Configure(cfg.FaultInject, cfg.Production()); err != nil {
+		slog.Error("fault_injection_config_invalid", "error", err)
+	}
 	xray.Configure(xray.Config{
 		LogLevel: "warn",
 	})
+	warmUp(cfg)
 }
 
-func HandleRequest(ctx context.Context, event json.RawMessage) (LambdaResponse, error) {
+func HandleRequest(ctx context.Context, event json.RawMessage) (resp LambdaResponse, err error) {
 	start := time.Now()
 
 	// Extract Lambda request ID
@@ -40,50 +64,118 @@ func HandleRequest(ctx context.Context, event json.RawMessage) (LambdaResponse,
 	if lc, ok := lambdacontext.FromContext(ctx); ok && lc != nil {
 		requestID = lc.AwsRequestID
 	}
-	ctx = context.WithValue(ctx, logging.RequestIDKey, requestID)
+	// Every record logged with ctx carries the request ID (and, once known,
+	// the tenant and call IDs)
+	ctx = logging.WithRequestID(ctx, requestID)
+	ctx, rec := events.WithRecorder(ctx, requestID)
+	ctx, timings := withStageTimings(ctx)
 
 	slog.InfoContext(ctx, "scheduling_service_invoked",
-		"request_id", requestID,
 		"event_size", len(event),
 	)
 
+	// Set once known; used to finish the response
+	var cfg config.Config
+	var tenantID string
+	origin := requestHeader(event, "Origin")
+
 	defer func() {
+		flushEvents(ctx, rec)
+		resp = offloadResponse(ctx, requestID, cfg, resp)
+		resp = compressResponse(resp, requestHeader(event, "Accept-Encoding"))
+		resp = applyCORS(resp, cfg, tenantID, origin)
+		stages := timings.header()
+		if stages != "" {
+			if resp.Headers == nil {
+				resp.Headers = make(map[string]string)
+			}
+			resp.Headers["Server-Timing"] = stages
+		}
 		slog.InfoContext(ctx, "invocation_complete",
-			"request_id", requestID,
 			"duration_ms", time.Since(start).Milliseconds(),
+			"stages", stages,
 		)
 	}()
 
 	// 1. Config
-	supaProj := os.Getenv("SUPABASE_PROJECT_ID")
-	supaKey := os.Getenv("SUPABASE_KEY")
-	appAuth := os.Getenv("APPFOLIO_AUTH_HEADER")
-	appDevID := os.Getenv("APPFOLIO_DEVELOPER_ID")
-	searchURL := os.Getenv("SEARCH_SERVICE_URL")
-	openaiKey := os.Getenv("OPENAI_API_KEY")
-
-	if supaProj == "" || supaKey == "" || appAuth == "" || appDevID == "" || searchURL == "" {
+	cfg = config.Load()
+	if !cfg.Valid() {
 		slog.ErrorContext(ctx, "missing_env_vars",
-			"request_id", requestID,
-			"supabase_project", supaProj != "",
-			"supabase_key", supaKey != "",
-			"appfolio_auth", appAuth != "",
-			"appfolio_dev_id", appDevID != "",
-			"search_url", searchURL != "",
+			"supabase_project", cfg.SupabaseProjectID != "",
+			"supabase_key", cfg.SupabaseKey != "",
+			"appfolio_auth", cfg.AppFolioAuthHeader != "",
+			"appfolio_dev_id", cfg.AppFolioDeveloperID != "",
+			"search_url", cfg.SearchServiceURL != "",
+			"property_source", cfg.PropertySource,
+			"property_source_configured", cfg.PropertySourceConfigured(),
 		)
 		return errorResponse(500, "Missing configuration"), nil
 	}
 
+	// X-Log-Level raises verbosity for one call. Debug records include
+	// caller content (e.g. LLM prompts), so only the admin token may ask.
+	if level, ok := logging.ParseLevel(requestHeader(event, "X-Log-Level")); ok {
+		if adminAuthorized(cfg, event) {
+			ctx = logging.WithLevel(ctx, level)
+		} else {
+			slog.WarnContext(ctx, "log_level_override_refused")
+		}
+	}
+
+	// Browser preflight for the web widget
+	if origin != "" && requestMethod(event) == "OPTIONS" {
+		return corsPreflight(cfg, origin), nil
+	}
+
+	if cfg.PropertySource == "appfolio" {
+		maybeProbeAppFolio(cfg)
+	}
+
+	// Scheduled / manual background jobs
+	if trigger, ok := detectJob(event); ok {
+		return runJob(ctx, requestID, cfg, trigger), nil
+	}
+
+	// Inbound SMS (Twilio webhook) is a separate conversation flow
+	if form, headers, ok := extractForm(event); ok {
+		if sms, ok := clients.ParseInboundSMS(form); ok {
+			return handleInboundSMS(ctx, requestID, cfg, sms, form, headers), nil
+		}
+	}
+
+	// Tracked application-link redirects and agent calendar onboarding
+	if path, query, ok := extractRoute(event); ok {
+		switch path {
+		case applicationLinkPath:
+			return handleApplicationClick(ctx, cfg, query), nil
+		case oauthStartPath:
+			return handleOAuthStart(ctx, cfg, query), nil
+		case oauthCallbackPath:
+			return handleOAuthCallback(ctx, cfg, query), nil
+		case bookingResponsePath:
+			return handleBookingResponse(ctx, requestID, cfg, event, query), nil
+		}
+		if strings.HasPrefix(path, adminPathPrefix) {
+			return handleAdmin(ctx, cfg, event, path, query), nil
+		}
+	}
+
+	// Stripe Identity verification results
+	if body, headers, ok := extractHTTP(event); ok && headers["stripe-signature"] != "" {
+		return handleIdentityWebhook(ctx, cfg, body, headers["stripe-signature"]), nil
+	}
+
 	// 2. Parse Event - handle multiple formats:
 	//    a) VAPI tool-calls (direct or wrapped in body)
 	//    b) n8n webhook envelope: {"headers":{}, "body":{VAPI payload}, "query":{}, ...}
 	//    c) API Gateway 1.0: {"body": "{stringified JSON}", ...}
 	//    d) Direct invoke: {"Query": "...", "Phone": "..."}
 	var req models.Request
-	var extractedPropertyID string
+	var match propertyMatch
 
 	// Extract the body to parse — could be the event itself, or nested in a "body" field
 	bodyToParse := extractBody(event)
+	ctx = logging.WithCallID(ctx, vapiCallID(bodyToParse))
 
 	// Log a preview of the extracted body for debugging
 	preview := string(bodyToParse)
@@ -91,13 +183,25 @@ func HandleRequest(ctx context.Context, event json.RawMessage) (LambdaResponse,
 		preview = preview[:200]
 	}
 	slog.InfoContext(ctx, "body_extracted",
-		"request_id", requestID,
 		"body_size", len(bodyToParse),
 		"body_preview", preview,
 	)
 
+	if cfg.VAPIStrictSchema {
+		if violations := validateVAPIPayload(bodyToParse); len(violations) > 0 {
+			slog.WarnContext(ctx, "vapi_schema_violation", "violations", violations)
+			metrics.Incr(ctx, "VAPISchemaViolation")
+			return schemaViolationResponse(violations), nil
+		}
+	}
+
+	// VAPI end-of-call reports carry the transcript, not a tool call
+	if report, ok := parseEndOfCallReport(bodyToParse); ok {
+		return handleEndOfCallReport(ctx, cfg, report), nil
+	}
+
 	// Try VAPI detection first (works for all envelope formats)
-	vapiParsed := tryParseVAPI(ctx, requestID, bodyToParse, openaiKey, &req, &extractedPropertyID)
+	vapiParsed := tryParseVAPI(ctx, bodyToParse, cfg, &req, &match)
 
 	if vapiParsed {
 		// VAPI payload handled
@@ -106,138 +210,102 @@ func HandleRequest(ctx context.Context, event json.RawMessage) (LambdaResponse,
 		if err := json.Unmarshal(bodyToParse, &req); err != nil {
 			// Last resort: try parsing the raw event
 			if err2 := json.Unmarshal(event, &req); err2 != nil {
-				slog.ErrorContext(ctx, "event_parse_failed", "request_id", requestID,
-					"body_error", err, "event_error", err2)
+				slog.ErrorContext(ctx, "event_parse_failed", "body_error", err, "event_error", err2)
 				return errorResponse(400, "Invalid request format"), nil
 			}
 		}
-		slog.InfoContext(ctx, "event_type_detected", "request_id", requestID, "type", "simple_request")
+		slog.InfoContext(ctx, "event_type_detected", "type", "simple_request")
 	}
 
-	slog.InfoContext(ctx, "request_parsed", "request_id", requestID, "query", req.Query)
+	slog.InfoContext(ctx, "request_parsed", "query", req.Query)
+	tenantID = req.TenantID
+	ctx = logging.WithTenantID(ctx, tenantID)
 
-	if req.Query == "" {
-		return errorResponse(400, "Query is required"), nil
+	if err := meterUsage(ctx, cfg, req.TenantID, usage.Invocations, 1); err != nil {
+		return quotaExceededResponse(cfg, req.TenantID), nil
+	}
+
+	if req.Source == "" {
+		if assistantID := vapiAssistantID(bodyToParse); assistantID != "" {
+			req.Source = "vapi:" + assistantID
+		}
+	}
+	pipelineFor(cfg).recordLeadContext(ctx, req.Phone, req.Source, req.Metadata)
+
+	if req.SMSConsent && req.Phone != "" {
+		pipelineFor(cfg).recordSMSConsent(ctx, req.Phone, models.ConsentVoice, true)
+	}
+
+	switch req.Action {
+	case "":
+	case actionCallback:
+		return scheduleCallback(ctx, cfg, req), nil
+	case actionBook:
+		if req.SlotStart == "" {
+			return errorResponse(400, "SlotStart is required"), nil
+		}
+	case actionConfirm:
+		if req.SlotID == "" {
+			return errorResponse(400, "SlotId is required"), nil
+		}
+		resp := pipelineFor(cfg).confirmSlot(ctx, requestID, req)
+		resp.Metadata = req.Metadata
+		return brandedResponse(cfg, req.TenantID, resp), nil
+	case actionCancel:
+		if req.ConfirmationID == "" && req.Phone == "" {
+			return errorResponse(400, "ConfirmationId or Phone is required"), nil
+		}
+		resp := pipelineFor(cfg).cancelBooking(ctx, req)
+		resp.Metadata = req.Metadata
+		return brandedResponse(cfg, req.TenantID, resp), nil
+	case actionReschedule:
+		if req.SlotStart == "" {
+			return errorResponse(400, "SlotStart is required"), nil
+		}
+		if req.ConfirmationID == "" && req.Phone == "" {
+			return errorResponse(400, "ConfirmationId or Phone is required"), nil
+		}
+		resp := pipelineFor(cfg).rescheduleBooking(ctx, requestID, req)
+		resp.Metadata = req.Metadata
+		return brandedResponse(cfg, req.TenantID, resp), nil
+	default:
+		return errorResponse(400, "Unknown action: "+req.Action), nil
 	}
 
 	// 3. Init Clients
-	searchClient := clients.NewSearchClient(searchURL)
-	appClient := clients.NewAppFolioClient(appAuth, appDevID)
-	supaClient := clients.NewSupabaseClient(supaProj, supaKey)
-	calClient := clients.NewCalendarClient()
-
-	// 4. Find Property ID (use OpenAI-matched ID if available)
-	var propID string
-	if extractedPropertyID != "" {
-		slog.InfoContext(ctx, "property_source", "request_id", requestID, "source", "openai", "property_id", extractedPropertyID)
-		propID = extractedPropertyID
-	} else {
-		var err error
-		propID, err = searchClient.FindPropertyID(ctx, req.Query)
-		if err != nil {
-			slog.WarnContext(ctx, "search_failed", "request_id", requestID, "error", err, "query", req.Query)
-			return successResponse(models.Response{
-				Success:      false,
-				Message:      "Could not find property matching query.",
-				FormattedMsg: fmt.Sprintf("I couldn't find a property matching '%s'. Could you verify the address?", req.Query),
-			}), nil
+	p := pipelineFor(cfg)
+
+	if len(req.Batch) > 0 {
+		if len(req.Batch) > maxBatchItems {
+			return errorResponse(400, fmt.Sprintf("Batch is limited to %d items", maxBatchItems)), nil
 		}
+		batch := p.batchAvailability(ctx, requestID, req)
+		batch.Metadata = req.Metadata
+		return jsonResponse(batch), nil
 	}
-	slog.InfoContext(ctx, "property_found", "request_id", requestID, "property_id", propID)
 
-	// 5. Fetch Property Details
-	prop, err := appClient.GetProperty(ctx, propID)
-	if err != nil {
-		slog.ErrorContext(ctx, "appfolio_property_failed", "request_id", requestID, "error", err, "property_id", propID)
-		return successResponse(models.Response{
-			Success:      false,
-			Message:      "Property found but details unavailable.",
-			FormattedMsg: "I found the property but couldn't access its details right now.",
-		}), nil
+	// Follow-ups to a needsConfirmation response name the property outright
+	if req.ConfirmedPropertyID != "" {
+		match = p.confirmedMatch(ctx, &req)
 	}
 
-	// 6. Fetch Property Groups (to find Agent)
-	groups, err := appClient.GetPropertyGroups(ctx, prop.PropertyGroupIds)
-	if err != nil {
-		slog.ErrorContext(ctx, "appfolio_groups_failed", "request_id", requestID, "error", err)
-		return successResponse(models.Response{
-			Success:      false,
-			Property:     mapPropertyInfo(prop),
-			Message:      "Could not determine agent.",
-			FormattedMsg: fmt.Sprintf("I have the details for %s, but I'm having trouble finding the assigned agent.", prop.Address1),
-		}), nil
-	}
-
-	// 7. Map Agent
-	agent := logic.MapAgent(groups)
-	if agent == nil {
-		slog.WarnContext(ctx, "agent_mapping_failed", "request_id", requestID)
-		return successResponse(models.Response{
-			Success:      false,
-			Property:     mapPropertyInfo(prop),
-			Message:      "No leasing agent assigned (No PD group).",
-			FormattedMsg: fmt.Sprintf("I checked %s, but there doesn't seem to be a leasing agent assigned to it yet.", prop.Address1),
-		}), nil
-	}
-	slog.InfoContext(ctx, "agent_mapped", "request_id", requestID, "name", agent.Name, "email", agent.Email, "zone", agent.Zone)
-
-	// 8. Get Calendar Access Token
-	token, err := supaClient.GetAccessToken(ctx, agent.Email)
-	if err != nil {
-		slog.ErrorContext(ctx, "token_fetch_failed", "request_id", requestID, "email", agent.Email, "error", err)
-		return successResponse(models.Response{
-			Success:      false,
-			Property:     mapPropertyInfo(prop),
-			Agent:        *agent,
-			Message:      "Agent calendar access unavailable.",
-			FormattedMsg: fmt.Sprintf("I'd love to schedule a viewing for %s, but I can't access %s's calendar right now. Please email them at %s.", prop.Address1, agent.Name, agent.Email),
-		}), nil
-	}
-
-	// 9. Get Busy Slots (in PST)
-	pstLoc, _ := time.LoadLocation("America/Los_Angeles")
-	now := time.Now().In(pstLoc)
-	timeMax := now.AddDate(0, 0, 7)
-	busySlots, err := calClient.GetBusySlots(ctx, token, agent.Email, now, timeMax)
-	if err != nil {
-		slog.ErrorContext(ctx, "calendar_fetch_failed", "request_id", requestID, "error", err)
-		return successResponse(models.Response{
-			Success:      false,
-			Property:     mapPropertyInfo(prop),
-			Agent:        *agent,
-			Message:      "Failed to read calendar.",
-			FormattedMsg: fmt.Sprintf("I'm having trouble checking %s's availability. Please contact them directly at %s.", agent.Name, agent.Email),
-		}), nil
-	}
-
-	// 10. Generate Availability
-	availableSlots, daysChecked, totalSlots := logic.GenerateAvailableSlots(busySlots, now)
-
-	// 11. Format Message
-	avail := models.Availability{
-		TotalSlotsAvailable: len(availableSlots),
-		DaysChecked:         daysChecked,
-		Slots:               limitSlots(availableSlots, 30),
-	}
-
-	formattedMsg := formatMessage(mapPropertyInfo(prop), *agent, avail, totalSlots)
-
-	slog.InfoContext(ctx, "scheduling_success",
-		"request_id", requestID,
-		"property_id", propID,
-		"agent", agent.Name,
-		"slots_available", len(availableSlots),
-		"days_checked", daysChecked,
-	)
+	if req.Query == "" {
+		return errorResponse(400, "Query is required"), nil
+	}
 
-	return successResponse(models.Response{
-		Success:      true,
-		Property:     mapPropertyInfo(prop),
-		Agent:        *agent,
-		Availability: avail,
-		Message:      "Success",
-		FormattedMsg: formattedMsg,
-	}), nil
+	// 4-11. Resolve property, agent and availability
+	result := answerOnce(ctx, req, func() availabilityResult {
+		result := p.findAvailability(ctx, requestID, req, match)
+		if req.Action == actionBook {
+			result.Response = p.bookShowing(ctx, requestID, req, result)
+		}
+		return result
+	})
+	if req.Action != actionBook {
+		p.rememberOffer(ctx, req, result)
+	}
+	result.Response.Metadata = req.Metadata
+	return brandedResponse(cfg, req.TenantID, result.Response), nil
 }
 
 // extractBody pulls the inner body from various event envelope formats.
@@ -287,10 +355,41 @@ func extractBodyRecursive(event json.RawMessage, depth int) json.RawMessage {
 	return extractBodyRecursive(extracted, depth+1)
 }
 
+// vapiCallID returns the call ID of a VAPI server message, if it is one
+func vapiCallID(body []byte) string {
+	var msg struct {
+		Message struct {
+			Call struct {
+				ID string `json:"id"`
+			} `json:"call"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return ""
+	}
+	return msg.Message.Call.ID
+}
+
+// vapiAssistantID returns the assistant handling a VAPI call, if the body
+// is a VAPI server message
+func vapiAssistantID(body []byte) string {
+	var msg struct {
+		Message struct {
+			Call struct {
+				AssistantID string `json:"assistantId"`
+			} `json:"call"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return ""
+	}
+	return msg.Message.Call.AssistantID
+}
+
 // tryParseVAPI attempts to detect and parse a VAPI tool-calls payload.
 // It uses a permissive two-stage parse: first detect the message type with
 // a minimal struct, then extract toolCalls and artifact with flexible types.
-func tryParseVAPI(ctx context.Context, requestID string, bodyToParse []byte, openaiKey string, req *models.Request, extractedPropertyID *string) bool {
+func tryParseVAPI(ctx context.Context, bodyToParse []byte, cfg config.Config, req *models.Request, match *propertyMatch) bool {
 	// Stage 1: Quick detect — only check message.type
 	var detect struct {
 		Message struct {
@@ -301,7 +400,7 @@ func tryParseVAPI(ctx context.Context, requestID string, bodyToParse []byte, ope
 		return false
 	}
 
-	slog.InfoContext(ctx, "event_type_detected", "request_id", requestID, "type", "vapi_tool_calls")
+	slog.InfoContext(ctx, "event_type_detected", "type", "vapi_tool_calls")
 
 	// Stage 2: Extract toolCalls with flexible argument parsing
 	var payload struct {
@@ -317,7 +416,7 @@ func tryParseVAPI(ctx context.Context, requestID string, bodyToParse []byte, ope
 		} `json:"message"`
 	}
 	if err := json.Unmarshal(bodyToParse, &payload); err != nil {
-		slog.ErrorContext(ctx, "vapi_payload_parse_failed", "request_id", requestID, "error", err)
+		slog.ErrorContext(ctx, "vapi_payload_parse_failed", "error", err)
 		return false
 	}
 
@@ -329,7 +428,7 @@ func tryParseVAPI(ctx context.Context, requestID string, bodyToParse []byte, ope
 		var args models.VAPIFunctionArgs
 		if err := json.Unmarshal(rawArgs, &args); err != nil {
 			// Fallback: try to extract Query/Phone from a generic map
-			slog.WarnContext(ctx, "vapi_args_struct_parse_failed", "request_id", requestID, "error", err)
+			slog.WarnContext(ctx, "vapi_args_struct_parse_failed", "error", err)
 			var argsMap map[string]interface{}
 			if err2 := json.Unmarshal(rawArgs, &argsMap); err2 == nil {
 				if q, ok := argsMap["Query"]; ok {
@@ -338,12 +437,56 @@ func tryParseVAPI(ctx context.Context, requestID string, bodyToParse []byte, ope
 				if p, ok := argsMap["Phone"]; ok {
 					req.Phone = fmt.Sprintf("%v", p)
 				}
+				if t, ok := argsMap["TenantId"]; ok {
+					req.TenantID = fmt.Sprintf("%v", t)
+				}
+				if u, ok := argsMap["UnitId"]; ok {
+					req.UnitID = fmt.Sprintf("%v", u)
+				}
+				if c, ok := argsMap["ConfirmedPropertyId"]; ok {
+					req.ConfirmedPropertyID = fmt.Sprintf("%v", c)
+				}
+				if s, ok := argsMap["Source"]; ok {
+					req.Source = fmt.Sprintf("%v", s)
+				}
+				if m, ok := argsMap["Metadata"].(map[string]interface{}); ok {
+					req.Metadata = m
+				}
 			}
 		} else {
 			req.Query = args.Query
 			req.Phone = args.Phone
+			req.TenantID = args.TenantID
+			req.CallbackAt = args.CallbackAt
+			req.Reason = args.Reason
+			req.SlotStart = args.SlotStart
+			req.SlotID = args.SlotID
+			req.ConfirmationID = args.ConfirmationID
+			req.Name = args.Name
+			req.SMSConsent = args.SMSConsent
+			req.UnitID = args.UnitID
+			req.ConfirmedPropertyID = args.ConfirmedPropertyID
+			req.Source = args.Source
+			req.Metadata = args.Metadata
+		}
+		switch payload.Message.ToolCalls[0].Function.Name {
+		case callbackToolName:
+			req.Action = actionCallback
+		case bookToolName:
+			req.Action = actionBook
+		case confirmToolName:
+			req.Action = actionConfirm
+		case cancelToolName:
+			req.Action = actionCancel
+		case rescheduleToolName:
+			req.Action = actionReschedule
 		}
-		slog.InfoContext(ctx, "vapi_params_extracted", "request_id", requestID, "query", req.Query, "phone", req.Phone)
+		// Voice queries are transcripts: write the address out before search
+		// and OpenAI matching see it
+		if normalized := logic.NormalizeSpokenAddress(req.Query); normalized != "" {
+			req.Query = normalized
+		}
+		slog.InfoContext(ctx, "vapi_params_extracted", "query", req.Query, "phone", req.Phone)
 	}
 
 	// Collect address candidates from tool_call_result messages
@@ -366,16 +509,21 @@ func tryParseVAPI(ctx context.Context, requestID string, bodyToParse []byte, ope
 		}
 	}
 
-	// Use OpenAI to match query to address if candidates exist
-	if len(candidates) > 0 && openaiKey != "" && req.Query != "" {
-		slog.InfoContext(ctx, "openai_matching_started", "request_id", requestID, "candidate_count", len(candidates))
-		openaiClient := clients.NewOpenAIClient(openaiKey)
-		matchedID, err := openaiClient.MatchAddressToQuery(ctx, req.Query, candidates)
+	// Use the LLM to match query to address if candidates exist; when the
+	// provider has been failing, leave it to the search service instead
+	if len(candidates) == 0 || req.Query == "" || req.ConfirmedPropertyID != "" {
+		return true
+	}
+	llm := newLLMClient(cfg, req.TenantID)
+	if llm != nil && !skipDegraded(ctx, llm.Provider.Name(), "address_matching") &&
+		meterUsage(ctx, cfg, req.TenantID, usage.OpenAICalls, 1) == nil {
+		slog.InfoContext(ctx, "llm_matching_started", "provider", llm.Provider.Name(), "candidate_count", len(candidates))
+		matchedID, err := llm.MatchAddressToQuery(ctx, req.Query, candidates)
 		if err != nil {
-			slog.WarnContext(ctx, "openai_matching_failed", "request_id", requestID, "error", err)
+			slog.WarnContext(ctx, "llm_matching_failed", "error", err)
 		} else {
-			*extractedPropertyID = matchedID
-			slog.InfoContext(ctx, "openai_matching_succeeded", "request_id", requestID, "property_id", *extractedPropertyID)
+			*match = propertyMatch{PropertyID: matchedID, Source: models.MatchOpenAI}
+			slog.InfoContext(ctx, "llm_matching_succeeded", "property_id", matchedID)
 		}
 	}
 
@@ -392,6 +540,41 @@ func mapPropertyInfo(p *models.AppFolioProperty) models.PropertyInfo {
 	}
 }
 
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
+
+const (
+	defaultPageSize = 30
+	maxPageSize     = 100
+)
+
+// pageSlots sets avail's slots to the requested page of slots. Page
+// defaults to 1 and PageSize to defaultPageSize.
+func pageSlots(avail *models.Availability, slots []models.TimeSlot, page, pageSize int) {
+	page, pageSize = pageBounds(page, pageSize)
+	start := min((page-1)*pageSize, len(slots))
+	end := min(start+pageSize, len(slots))
+	avail.Slots = slots[start:end]
+	avail.Page = page
+	avail.PageSize = pageSize
+	avail.HasMore = end < len(slots)
+}
+
+// pageBounds applies the page and page-size defaults and limits
+func pageBounds(page, pageSize int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	return page, min(pageSize, maxPageSize)
+}
+
 func limitSlots(slots []models.TimeSlot, max int) []models.TimeSlot {
 	if len(slots) > max {
 		return slots[:max]
@@ -399,9 +582,68 @@ func limitSlots(slots []models.TimeSlot, max int) []models.TimeSlot {
 	return slots
 }
 
+// formatEarliestMessage is formatMessage for the earliest-only fast path
+func formatEarliestMessage(prop models.PropertyInfo, agent models.AgentInfo, avail models.Availability) string {
+	msg := fmt.Sprintf("🏠 PROPERTY: %s\n📍 %s, %s, %s\n\n", prop.Name, prop.Address, prop.City, prop.State)
+	if len(avail.Slots) == 0 {
+		msg += fmt.Sprintf("📅 No showing times are open in the next %d days.\n", avail.DaysChecked)
+		msg += fmt.Sprintf("📞 Please contact %s directly at %s to schedule.", agent.Name, agent.Email)
+		return msg
+	}
+	msg += fmt.Sprintf("👤 LEASING AGENT: %s%s\n\n", agent.Name, coverageNote(agent))
+	msg += "📅 SOONEST AVAILABLE TIMES:\n"
+	for _, slot := range avail.Slots {
+		msg += fmt.Sprintf("  • %s at %s\n", slot.Date, slot.Time)
+	}
+	return msg
+}
+
+// coverageNote mentions the away agent a backup is standing in for
+func coverageNote(agent models.AgentInfo) string {
+	if agent.CoveringFor == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (covering for %s)", agent.CoveringFor)
+}
+
+// slotNotice returns the expectation-setting note for availability that
+// starts more than cfg.SlotNoticeDays out, or "" when it's sooner
+func slotNotice(cfg config.Config, prop models.PropertyInfo, agent models.AgentInfo, slots []models.TimeSlot, now time.Time) string {
+	if cfg.SlotNoticeDays <= 0 || len(slots) == 0 {
+		return ""
+	}
+	earliest := slots[0].Start
+	if earliest.Sub(now) <= time.Duration(cfg.SlotNoticeDays)*24*time.Hour {
+		return ""
+	}
+	return strings.NewReplacer(
+		"{property}", prop.Address,
+		"{earliest}", relativeDay(earliest, now),
+		"{agent}", agent.Name,
+		"{email}", agent.Email,
+	).Replace(cfg.SlotNoticeTemplate)
+}
+
+// relativeDay describes t the way a person would on the phone: "Friday at
+// 2:00 PM", "next Wednesday at 10:00 AM" or "Monday, Nov 3 at 9:00 AM"
+func relativeDay(t, now time.Time) string {
+	t = t.In(now.Location())
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	// Weeks start on Monday
+	weekStart := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+
+	switch {
+	case t.Before(weekStart.AddDate(0, 0, 7)):
+		return t.Format("Monday at 3:04 PM")
+	case t.Before(weekStart.AddDate(0, 0, 14)):
+		return "next " + t.Format("Monday at 3:04 PM")
+	}
+	return t.Format("Monday, Jan 2 at 3:04 PM")
+}
+
 func formatMessage(prop models.PropertyInfo, agent models.AgentInfo, avail models.Availability, totalGenerated int) string {
 	msg := fmt.Sprintf("🏠 PROPERTY: %s\n📍 %s, %s, %s\n\n", prop.Name, prop.Address, prop.City, prop.State)
-	msg += fmt.Sprintf("👤 LEASING AGENT: %s\n📧 Email: %s\n\n", agent.Name, agent.Email)
+	msg += fmt.Sprintf("👤 LEASING AGENT: %s%s\n📧 Email: %s\n\n", agent.Name, coverageNote(agent), agent.Email)
 
 	if len(avail.Slots) == 0 {
 		msg += fmt.Sprintf("📅 SHOWING AVAILABILITY:\nNo available time slots found in the next %d days.\n", avail.DaysChecked)
@@ -462,14 +704,37 @@ func errorResponse(status int, msg string) LambdaResponse {
 }
 
 func successResponse(resp models.Response) LambdaResponse {
-	body, _ := json.Marshal(resp)
+	return jsonResponse(resp)
+}
+
+// jsonResponse is a 200 response with v as its JSON body
+func jsonResponse(v any) LambdaResponse {
+	body, err := encodeBody(v)
+	if err != nil {
+		slog.Error("response_encode_failed", "error", err)
+		return errorResponse(500, "Failed to encode response")
+	}
 	return LambdaResponse{
 		StatusCode: 200,
 		Headers:    map[string]string{"Content-Type": "application/json"},
-		Body:       string(body),
+		Body:       body,
 	}
 }
 
 func main() {
-	lambda.Start(HandleRequest)
+	faultSpec := flag.String("fault-inject", "", "fault injection spec (non-production only), overrides FAULT_INJECT")
+	flag.Parse()
+
+	cfg := config.Load()
+	if *faultSpec != "" {
+		if err := faultinject.Configure(*faultSpec, cfg.Production()); err != nil {
+			slog.Error("fault_injection_config_invalid", "error", err)
+			os.Exit(2)
+		}
+	}
+	if cfg.HTTPListenAddr != "" {
+		runContainer(cfg)
+		return
+	}
+	lambda.Start(lambdaHandler)
 }
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/logging"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/logic"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+// confirmedMatch resolves a follow-up call answering a NeedsConfirmation
+// response: the confirmed property is used as given, without matching. The
+// query of the original call is restored if the follow-up left it out.
+func (p *pipeline) confirmedMatch(ctx context.Context, req *models.Request) propertyMatch {
+	match := propertyMatch{PropertyID: req.ConfirmedPropertyID, Source: models.MatchOverride}
+	callID := logging.CallID(ctx)
+	if callID == "" {
+		slog.InfoContext(ctx, "match_confirmed", "property_id", match.PropertyID, "pending", false)
+		return match
+	}
+
+	state, err := p.supabase.GetCallState(ctx, callID)
+	if err != nil {
+		slog.WarnContext(ctx, "call_state_fetch_failed", "error", err)
+	}
+	pending := state != nil && state.PendingPropertyID == match.PropertyID
+	if pending && req.Query == "" {
+		req.Query = state.Query
+	}
+	slog.InfoContext(ctx, "match_confirmed", "property_id", match.PropertyID, "pending", pending)
+	metrics.Incr(ctx, "MatchConfirmed", "Pending", fmt.Sprint(pending))
+
+	if state == nil {
+		state = &models.CallState{CallID: callID, TenantID: req.TenantID}
+	}
+	state.PendingPropertyID = ""
+	state.ConfirmedPropertyID = match.PropertyID
+	if err := p.supabase.SaveCallState(ctx, *state); err != nil {
+		slog.WarnContext(ctx, "call_state_save_failed", "error", err)
+	}
+	return match
+}
+
+// needsConfirmation returns a NeedsConfirmation response when a voice
+// caller's query matched prop with too little confidence to offer times for
+// it. The candidate is kept in the call state for the follow-up call.
+func (p *pipeline) needsConfirmation(ctx context.Context, req models.Request, match propertyMatch, prop propertyRecord) *availabilityResult {
+	callID := logging.CallID(ctx)
+	if callID == "" || match.Source == models.MatchOverride {
+		return nil
+	}
+	confidence := logic.MatchConfidence(match.Source, req.Query, prop.Address1)
+	if confidence >= p.cfg.MatchConfirmThreshold {
+		return nil
+	}
+
+	state := models.CallState{
+		CallID:            callID,
+		TenantID:          req.TenantID,
+		PendingPropertyID: match.PropertyID,
+		Query:             req.Query,
+	}
+	if err := p.supabase.SaveCallState(ctx, state); err != nil {
+		// Without the state the follow-up still works; it only loses the query
+		slog.WarnContext(ctx, "call_state_save_failed", "error", err)
+	}
+	slog.InfoContext(ctx, "match_confirmation_requested",
+		"property_id", match.PropertyID,
+		"source", match.Source,
+		"confidence", confidence,
+	)
+	metrics.Incr(ctx, "MatchConfirmationRequested", "Source", match.Source)
+
+	info := prop.info()
+	return &availabilityResult{
+		PropertyID: match.PropertyID,
+		Response: models.Response{
+			Success:           false,
+			Message:           "Property match needs confirmation.",
+			FormattedMsg:      fmt.Sprintf("Just to confirm, is it %s in %s?", info.Address, info.City),
+			Property:          info,
+			NeedsConfirmation: true,
+			NextActions:       []string{models.NextConfirmAddress},
+		},
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/config"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/mtls"
+)
+
+// clientCertSecret is the Secrets Manager JSON layout for an mTLS identity
+type clientCertSecret struct {
+	Cert string `json:"cert"` // PEM client certificate (chain)
+	Key  string `json:"key"`  // PEM private key
+	CA   string `json:"ca"`   // optional PEM CA bundle for the server
+}
+
+// registerClientCertificates registers a loader for each configured
+// client certificate. Each is fetched from Secrets Manager on the first
+// request to its host and refreshed periodically (see package mtls); a host
+// whose certificate can't be loaded fails its requests rather than silently
+// downgrading.
+func registerClientCertificates(cfg config.Config) {
+	for host, secretID := range cfg.MTLSSecrets {
+		mtls.Register(host, func(ctx context.Context) (tls.Certificate, *x509.CertPool, error) {
+			return loadClientCertificate(ctx, secretID)
+		})
+	}
+}
+
+func loadClientCertificate(ctx context.Context, secretID string) (tls.Certificate, *x509.CertPool, error) {
+	sess, err := awsSession()
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	sm := secretsmanager.New(sess)
+	xray.AWS(sm.Client)
+	out, err := sm.GetSecretValueWithContext(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(secretID)})
+	if err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("secret %s: %w", secretID, err)
+	}
+
+	var secret clientCertSecret
+	if err := json.Unmarshal([]byte(aws.StringValue(out.SecretString)), &secret); err != nil {
+		return tls.Certificate{}, nil, fmt.Errorf("secret %s is not valid JSON: %w", secretID, err)
+	}
+	return mtls.ParsePEM([]byte(secret.Cert), []byte(secret.Key), []byte(secret.CA))
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/config"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+)
+
+// offloadURLExpiry is how long the link to an offloaded response works
+const offloadURLExpiry = time.Hour
+
+// offloadedResponse replaces a response body too large to return directly
+type offloadedResponse struct {
+	Offloaded bool      `json:"offloaded"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	SizeBytes int       `json:"sizeBytes"`
+	// Summary is the original body's top-level scalar fields (e.g. a
+	// batch's succeeded and failed counts)
+	Summary map[string]any `json:"summary,omitempty"`
+}
+
+// offloadResponse moves a JSON body over cfg.ResponseOffloadBytes to the
+// offload bucket and returns a presigned link to it with a summary instead,
+// keeping bulk results under the Lambda payload limit. On failure the
+// response is returned unchanged.
+func offloadResponse(ctx context.Context, requestID string, cfg config.Config, resp LambdaResponse) LambdaResponse {
+	if cfg.ResponseOffloadBucket == "" || len(resp.Body) <= cfg.ResponseOffloadBytes || resp.IsBase64Encoded {
+		return resp
+	}
+	sess, err := awsSession()
+	if err != nil {
+		slog.ErrorContext(ctx, "aws_session_failed", "error", err)
+		return resp
+	}
+	client := s3.New(sess)
+	xray.AWS(client.Client)
+
+	key := fmt.Sprintf("responses/%s/%s.json", time.Now().UTC().Format("2006-01-02"), requestID)
+	if _, err := client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(cfg.ResponseOffloadBucket),
+		Key:         aws.String(key),
+		Body:        strings.NewReader(resp.Body),
+		ContentType: aws.String("application/json"),
+	}); err != nil {
+		slog.ErrorContext(ctx, "response_offload_failed", "key", key, "error", err)
+		return resp
+	}
+	get, _ := client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: aws.String(cfg.ResponseOffloadBucket),
+		Key:    aws.String(key),
+	})
+	url, err := get.Presign(offloadURLExpiry)
+	if err != nil {
+		slog.ErrorContext(ctx, "response_offload_presign_failed", "key", key, "error", err)
+		return resp
+	}
+
+	offloaded := offloadedResponse{
+		Offloaded: true,
+		URL:       url,
+		ExpiresAt: time.Now().Add(offloadURLExpiry).UTC(),
+		SizeBytes: len(resp.Body),
+		Summary:   bodySummary(resp.Body),
+	}
+	body, err := encodeBody(offloaded)
+	if err != nil {
+		slog.ErrorContext(ctx, "response_encode_failed", "error", err)
+		return resp
+	}
+	slog.InfoContext(ctx, "response_offloaded", "key", key, "size_bytes", len(resp.Body))
+	metrics.Record(ctx, "ResponseOffloadedBytes", float64(len(resp.Body)), metrics.Bytes)
+	resp.Body = body
+	return resp
+}
+
+// bodySummary returns the top-level scalar fields of a JSON object body
+func bodySummary(body string) map[string]any {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(body), &fields); err != nil {
+		return nil
+	}
+	summary := make(map[string]any)
+	for name, raw := range fields {
+		var v any
+		if err := json.Unmarshal(raw, &v); err != nil {
+			continue
+		}
+		switch v.(type) {
+		case map[string]any, []any, nil:
+			continue
+		}
+		summary[name] = v
+	}
+	return summary
+}
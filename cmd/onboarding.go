@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/clients"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/config"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+)
+
+// Agent calendar onboarding: ops sends a new agent to
+// /oauth/start?agent=<email>, Google sends them back to /oauth/callback,
+// and their tokens are stored in oauth_tokens.
+const (
+	oauthStartPath    = "/oauth/start"
+	oauthCallbackPath = "/oauth/callback"
+
+	calendarScope = "https://www.googleapis.com/auth/calendar"
+
+	// oauthStateMaxAge bounds how long an agent can sit on the consent page
+	oauthStateMaxAge = 30 * time.Minute
+)
+
+// handleOAuthStart redirects an agent on the roster to Google's consent page
+func handleOAuthStart(ctx context.Context, cfg config.Config, query url.Values) LambdaResponse {
+	if !oauthConfigured(cfg) {
+		slog.WarnContext(ctx, "oauth_onboarding_not_configured")
+		return errorResponse(404, "Not found")
+	}
+
+	email := strings.ToLower(strings.TrimSpace(query.Get("agent")))
+	agents, err := newSupabaseClient(cfg).ListAgents(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "agent_roster_fetch_failed", "error", err)
+		return errorResponse(503, "Please try again in a minute")
+	}
+	onRoster := false
+	for _, agent := range agents {
+		onRoster = onRoster || strings.EqualFold(agent.Email, email)
+	}
+	if email == "" || !onRoster {
+		slog.WarnContext(ctx, "oauth_agent_unknown")
+		return errorResponse(404, "Unknown agent")
+	}
+
+	// The signed state ties the callback to this agent and this link
+	state := signLinkToken(cfg.LinkSigningSecret, "oauth|"+email+"|"+strconv.FormatInt(time.Now().Unix(), 10))
+	oauth := clients.NewGoogleOAuthClient(cfg.GoogleClientID, cfg.GoogleClientSecret)
+
+	slog.InfoContext(ctx, "oauth_consent_started", "agent", email)
+	return LambdaResponse{
+		StatusCode: 302,
+		Headers:    map[string]string{"Location": oauth.AuthCodeURL(oauthRedirectURI(cfg), calendarScope, state, email)},
+	}
+}
+
+// handleOAuthCallback exchanges the authorization code for the agent's
+// tokens and stores them, after checking the consenting Google account is
+// the agent the link was issued for.
+func handleOAuthCallback(ctx context.Context, cfg config.Config, query url.Values) LambdaResponse {
+	if !oauthConfigured(cfg) {
+		slog.WarnContext(ctx, "oauth_onboarding_not_configured")
+		return errorResponse(404, "Not found")
+	}
+
+	email, ok := verifyOAuthState(cfg, query.Get("state"))
+	if !ok {
+		slog.WarnContext(ctx, "oauth_state_invalid")
+		return messagePage(400, "Calendar setup", "This link has expired. Please ask for a new one.")
+	}
+	if reason := query.Get("error"); reason != "" {
+		slog.WarnContext(ctx, "oauth_consent_declined", "agent", email, "reason", reason)
+		return messagePage(200, "Calendar setup", "Calendar access wasn't granted, so showings can't be booked on your calendar yet. Open your link again to retry.")
+	}
+
+	oauth := clients.NewGoogleOAuthClient(cfg.GoogleClientID, cfg.GoogleClientSecret)
+	grant, err := oauth.Exchange(ctx, query.Get("code"), oauthRedirectURI(cfg))
+	if errors.Is(err, clients.ErrRefreshRevoked) {
+		slog.WarnContext(ctx, "oauth_code_invalid", "agent", email)
+		return messagePage(400, "Calendar setup", "This sign-in has already been used or has expired. Open your link again to retry.")
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "oauth_exchange_failed", "agent", email, "error", err)
+		return messagePage(502, "Calendar setup", "Something went wrong connecting your calendar. Please try again in a minute.")
+	}
+
+	info, err := oauth.TokenInfo(ctx, grant.AccessToken)
+	if err != nil {
+		slog.ErrorContext(ctx, "oauth_tokeninfo_failed", "agent", email, "error", err)
+		return messagePage(502, "Calendar setup", "Something went wrong connecting your calendar. Please try again in a minute.")
+	}
+	if !strings.EqualFold(info.Email, email) {
+		slog.WarnContext(ctx, "oauth_account_mismatch", "agent", email)
+		return messagePage(403, "Calendar setup", fmt.Sprintf("You signed in with a different Google account. Please sign in as %s.", email))
+	}
+	if !strings.Contains(" "+info.Scope+" ", " "+calendarScope+" ") {
+		slog.WarnContext(ctx, "oauth_scope_missing", "agent", email, "scope", info.Scope)
+		return messagePage(200, "Calendar setup", "Calendar access wasn't granted, so showings can't be booked on your calendar yet. Open your link again to retry.")
+	}
+
+	validatedAt := time.Now().UTC()
+	token := clients.OAuthToken{
+		Email:        email,
+		AccessToken:  grant.AccessToken,
+		RefreshToken: grant.RefreshToken,
+		Status:       clients.OAuthTokenValid,
+		ValidatedAt:  &validatedAt,
+	}
+	if err := newSupabaseClient(cfg).SaveOAuthToken(ctx, token); err != nil {
+		slog.ErrorContext(ctx, "oauth_token_save_failed", "agent", email, "error", err)
+		return messagePage(502, "Calendar setup", "Something went wrong connecting your calendar. Please try again in a minute.")
+	}
+
+	slog.InfoContext(ctx, "agent_calendar_connected", "agent", email, "refresh_token", grant.RefreshToken != "")
+	metrics.Incr(ctx, "AgentCalendarConnected")
+	return messagePage(200, "Calendar setup", "Your calendar is connected. You can close this page.")
+}
+
+func oauthConfigured(cfg config.Config) bool {
+	return cfg.GoogleClientID != "" && cfg.GoogleClientSecret != "" && cfg.PublicBaseURL != "" && cfg.LinkSigningSecret != ""
+}
+
+// oauthRedirectURI must match a redirect URI registered on the OAuth client
+func oauthRedirectURI(cfg config.Config) string {
+	return strings.TrimRight(cfg.PublicBaseURL, "/") + oauthCallbackPath
+}
+
+// verifyOAuthState returns the agent email from a fresh state issued by handleOAuthStart
+func verifyOAuthState(cfg config.Config, state string) (string, bool) {
+	payload, ok := verifyLinkToken(cfg.LinkSigningSecret, state)
+	if !ok {
+		return "", false
+	}
+	parts := strings.Split(payload, "|")
+	if len(parts) != 3 || parts[0] != "oauth" {
+		return "", false
+	}
+	issued, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil || time.Since(time.Unix(issued, 0)) > oauthStateMaxAge {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// messagePage is the minimal page an agent lands on after following a link
+func messagePage(status int, title, msg string) LambdaResponse {
+	return LambdaResponse{
+		StatusCode: status,
+		Headers:    map[string]string{"Content-Type": "text/html; charset=utf-8"},
+		Body:       "<!doctype html><title>" + html.EscapeString(title) + "</title><p>" + html.EscapeString(msg) + "</p>",
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/logic"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/notify"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/usage"
+)
+
+// Business-initiated texts go through sendText, which enforces the
+// do-not-contact list, SMS consent and quiet hours. Replies to a prospect's
+// own text are sent as TwiML and don't pass through here.
+var (
+	errTextsNotConfigured = errors.New("twilio not configured")
+	errDoNotContact       = errors.New("phone is on the do-not-contact list")
+	errNoSMSConsent       = errors.New("no SMS consent")
+	errQuietHours         = errors.New("quiet hours")
+)
+
+// Inbound keywords that withdraw or restore SMS consent (Twilio's defaults)
+var (
+	optOutKeywords = []string{"STOP", "STOPALL", "UNSUBSCRIBE", "CANCEL", "END", "QUIT"}
+	optInKeywords  = []string{"START", "UNSTOP"}
+)
+
+// sendText texts msg to phone unless a compliance rule forbids it right
+// now. loc is the recipient's time zone for quiet hours. Callers that can
+// retry later (e.g. a scheduled job) should treat errQuietHours as "not yet".
+// Delivery goes through the notification dispatcher, so a tenant may route
+// purpose to other channels as well as (or instead of) SMS.
+func (p *pipeline) sendText(ctx context.Context, tenantID, phone string, loc *time.Location, msg, purpose string) error {
+	if !p.notifier.Has(notify.ChannelSMS) {
+		return errTextsNotConfigured
+	}
+	if !mayContact(ctx, p.supabase, tenantID, phone, purpose) {
+		return errDoNotContact
+	}
+	lead, err := p.supabase.GetLead(ctx, phone)
+	if err != nil {
+		// Without the consent record we can't show consent; don't send
+		slog.ErrorContext(ctx, "lead_fetch_failed", "purpose", purpose, "error", err)
+		return err
+	}
+	if !lead.SMSConsented() {
+		slog.InfoContext(ctx, "sms_suppressed", "purpose", purpose, "reason", "no_consent")
+		metrics.Incr(ctx, "SMSSuppressed", "Reason", "no_consent")
+		return errNoSMSConsent
+	}
+	if logic.InQuietHours(time.Now().In(loc)) {
+		slog.InfoContext(ctx, "sms_suppressed", "purpose", purpose, "reason", "quiet_hours")
+		metrics.Incr(ctx, "SMSSuppressed", "Reason", "quiet_hours")
+		return errQuietHours
+	}
+	if err := checkUsage(ctx, p.cfg, tenantID, usage.SMSSends); err != nil {
+		return err
+	}
+
+	err = p.notifier.Dispatch(ctx, notify.Message{
+		TenantID: tenantID,
+		Purpose:  purpose,
+		To:       notify.Recipient{Phone: phone},
+		Text:     signText(msg, p.cfg.BrandingFor(tenantID)),
+		Data:     map[string]interface{}{"phone": phone},
+	}, notify.ChannelSMS)
+	if err != nil {
+		return err
+	}
+	// Only texts that went out count; the quota was checked above
+	meterUsage(ctx, p.cfg, tenantID, usage.SMSSends, 1)
+	metrics.Incr(ctx, "SMSSent", "Purpose", purpose)
+	return nil
+}
+
+// recordSMSConsent stores phone's consent to texts, unless it is already on
+// file. Only explicit consent (START, or agreeing on a call) overrides an
+// earlier opt-out; texting us again doesn't. Failures are logged only; they
+// leave the number without consent.
+func (p *pipeline) recordSMSConsent(ctx context.Context, phone, source string, explicit bool) {
+	lead, err := p.supabase.GetLead(ctx, phone)
+	if err != nil {
+		slog.WarnContext(ctx, "lead_fetch_failed", "error", err)
+		return
+	}
+	if lead.SMSConsented() || (!explicit && lead != nil && lead.SMSOptedOutAt != nil) {
+		return
+	}
+	now := time.Now().UTC()
+	if err := p.supabase.SaveLead(ctx, models.Lead{Phone: phone, SMSConsentAt: &now, SMSConsentSource: source}); err != nil {
+		slog.WarnContext(ctx, "sms_consent_save_failed", "source", source, "error", err)
+		return
+	}
+	slog.InfoContext(ctx, "sms_consent_recorded", "source", source)
+	metrics.Incr(ctx, "SMSConsentRecorded", "Source", source)
+}
+
+// recordSMSOptOut withdraws phone's consent to texts
+func (p *pipeline) recordSMSOptOut(ctx context.Context, phone string) {
+	now := time.Now().UTC()
+	if err := p.supabase.SaveLead(ctx, models.Lead{Phone: phone, SMSOptedOutAt: &now}); err != nil {
+		slog.ErrorContext(ctx, "sms_opt_out_save_failed", "error", err)
+		return
+	}
+	slog.InfoContext(ctx, "sms_opted_out")
+	metrics.Incr(ctx, "SMSOptedOut")
+}
+
+func isKeyword(text string, keywords []string) bool {
+	for _, k := range keywords {
+		if strings.EqualFold(text, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// recordLeadContext keeps the marketing channel and tenant metadata of
+// phone's latest inquiry on its lead, for attributing bookings. Failures
+// are logged only.
+func (p *pipeline) recordLeadContext(ctx context.Context, phone, source string, metadata map[string]any) {
+	if phone == "" || (source == "" && len(metadata) == 0) {
+		return
+	}
+	if err := p.supabase.SaveLead(ctx, models.Lead{Phone: phone, Source: source, Metadata: metadata}); err != nil {
+		slog.WarnContext(ctx, "lead_context_save_failed", "source", source, "error", err)
+		return
+	}
+	if source != "" {
+		metrics.Incr(ctx, "LeadInquiry", "Source", source)
+	}
+}
@@ -0,0 +1,1355 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/clients"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/config"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/events"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/holds"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/lifecycle"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/logic"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/notify"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/scrub"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/store"
+)
+
+// pipeline holds the clients used to turn a property query into agent availability.
+// It is shared by the voice/direct path and the SMS conversation flow.
+type pipeline struct {
+	cfg        config.Config
+	search     *clients.SearchClient
+	properties clients.PropertyDataSource
+	supabase   *clients.SupabaseClient
+	calendar   *clients.CalendarClient
+	slack      *clients.SlackClient     // nil when Slack is not configured
+	locks      *clients.SmartLockClient // nil when no smart-lock provider is configured
+	identity   *clients.IdentityClient  // nil when ID verification is not required
+	notifier   *notify.Dispatcher
+	bookings   store.Bookings
+	holds      *holds.DynamoStore // nil when slot holds are not configured
+	lifecycle  *lifecycle.Machine
+}
+
+func newPipeline(cfg config.Config) *pipeline {
+	var slack *clients.SlackClient
+	if cfg.SlackBotToken != "" {
+		slack = clients.NewSlackClient(cfg.SlackBotToken)
+	}
+	var locks *clients.SmartLockClient
+	if cfg.SmartLockAPIURL != "" {
+		locks = clients.NewSmartLockClient(cfg.SmartLockAPIURL, cfg.SmartLockAPIKey)
+	}
+	var identity *clients.IdentityClient
+	if cfg.StripeSecretKey != "" {
+		identity = clients.NewIdentityClient(cfg.StripeSecretKey)
+	}
+	supa := newSupabaseClient(cfg)
+	p := &pipeline{
+		cfg:        cfg,
+		notifier:   newNotifier(cfg, slack),
+		bookings:   newBookingStore(cfg, supa),
+		holds:      newHoldStore(cfg),
+		slack:      slack,
+		locks:      locks,
+		identity:   identity,
+		search:     clients.NewSearchClient(cfg.SearchServiceURLs, cfg.SearchHedgeDelay, newSearchSigner(cfg)),
+		properties: newPropertySource(cfg, cfg.PropertySource),
+		supabase:   supa,
+		calendar:   clients.NewCalendarClient(),
+	}
+	p.lifecycle = newLifecycle(p)
+	return p
+}
+
+// newNotifier registers a notifier for each configured channel. Webhooks
+// need no deployment-wide setup, only a tenant's URL.
+func newNotifier(cfg config.Config, slack *clients.SlackClient) *notify.Dispatcher {
+	d := notify.NewDispatcher(cfg.NotifyFor, notify.RetryPolicy{Attempts: cfg.NotifyRetryAttempts, Backoff: notify.DefaultRetry.Backoff})
+	if cfg.TwilioAccountSID != "" {
+		d.Register(notify.SMS{Sender: clients.NewTwilioClient(cfg.TwilioAccountSID, cfg.TwilioAuthToken, cfg.TwilioFromNumber)})
+	}
+	if slack != nil {
+		d.Register(notify.Slack{Poster: slack})
+	}
+	if cfg.NotifyEmailFrom != "" {
+		if sess, err := awsSession(); err != nil {
+			slog.Error("aws_session_failed", "error", err)
+		} else {
+			d.Register(notify.NewEmail(sess, cfg.NotifyEmailFrom))
+		}
+	}
+	d.Register(notify.Webhook{Poster: clients.NewWebhookClient(cfg.NotifyWebhookSecret)})
+	return d
+}
+
+// newBookingStore returns the configured booking repository
+func newBookingStore(cfg config.Config, supa *clients.SupabaseClient) store.Bookings {
+	if cfg.BookingStore == "dynamodb" && cfg.BookingsTable != "" {
+		sess, err := awsSession()
+		if err == nil {
+			return store.NewDynamoBookings(sess, cfg.BookingsTable)
+		}
+		slog.Error("aws_session_failed", "error", err)
+	}
+	return store.NewSupabaseBookings(supa)
+}
+
+// newHoldStore returns the slot hold store, or nil when holds are off
+func newHoldStore(cfg config.Config) *holds.DynamoStore {
+	if cfg.SlotHoldTable == "" || cfg.SlotHoldCount <= 0 {
+		return nil
+	}
+	sess, err := awsSession()
+	if err != nil {
+		slog.Error("aws_session_failed", "error", err)
+		return nil
+	}
+	return holds.NewDynamoStore(sess, cfg.SlotHoldTable)
+}
+
+// newSupabaseClient returns the Supabase client, failing reads over to the
+// fallback project when one is configured
+func newSupabaseClient(cfg config.Config) *clients.SupabaseClient {
+	return newTenantSupabaseClient(cfg, "")
+}
+
+// newTenantSupabaseClient returns the client for the project holding
+// tenantID's data (see config.TenantDataResidency)
+func newTenantSupabaseClient(cfg config.Config, tenantID string) *clients.SupabaseClient {
+	residency := cfg.ResidencyFor(tenantID)
+	supa := clients.NewSupabaseClient(residency.SupabaseProjectID, residency.SupabaseKey)
+	if residency.SupabaseFallbackProjectID != "" {
+		supa.Fallback = clients.NewSupabaseReplica(residency.SupabaseFallbackProjectID, residency.SupabaseFallbackKey)
+		supa.OnFailover = func(ctx context.Context, path string, err error) {
+			table, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "?") // the query can hold PII
+			slog.WarnContext(ctx, "supabase_failover", "table", table, "error", err)
+			metrics.Incr(ctx, "SupabaseFailover")
+		}
+	}
+	return supa
+}
+
+// newSearchSigner returns the request signer for the search service, or nil
+// for unauthenticated calls. A scheme that can't be set up fails every call
+// rather than sending it unsigned.
+func newSearchSigner(cfg config.Config) clients.RequestSigner {
+	switch cfg.SearchAuth {
+	case "sigv4":
+		sess, err := awsSession()
+		if err != nil {
+			slog.Error("aws_session_failed", "error", err)
+			return clients.FailedSigner{Err: fmt.Errorf("sigv4 signer: %w", err)}
+		}
+		return clients.NewSigV4Signer(sess, "lambda", cfg.AWSRegion)
+	case "hmac":
+		return clients.HMACSigner{Secret: cfg.SearchHMACSecret}
+	default:
+		return nil
+	}
+}
+
+// newLLMClient returns the LLM client for tenantID's provider, scrubbing
+// caller text as configured by LLM_SCRUB, or nil if the provider isn't
+// configured
+func newLLMClient(cfg config.Config, tenantID string) *clients.LLMClient {
+	var provider clients.LLMProvider
+	switch name := cfg.LLMProviderFor(tenantID); name {
+	case "bedrock":
+		sess, err := awsSession()
+		if err != nil {
+			slog.ErrorContext(context.Background(), "aws_session_failed", "error", err)
+			return nil
+		}
+		bedrock := clients.NewBedrockClient(sess, cmp.Or(cfg.BedrockRegion, cfg.AWSRegion), cfg.BedrockModelID)
+		bedrock.ContentSampleRate = cfg.LLMLogSampleRate
+		provider = bedrock
+	case "openai":
+		if cfg.OpenAIAPIKey == "" {
+			return nil
+		}
+		openai := clients.NewOpenAIClient(cfg.OpenAIAPIKey)
+		openai.ContentSampleRate = cfg.LLMLogSampleRate
+		provider = openai
+	case clients.OpenAICompatibleProvider:
+		if cfg.LLMBaseURL == "" || cfg.LLMModel == "" {
+			return nil
+		}
+		compatible := clients.NewOpenAICompatibleClient(cfg.LLMBaseURL, cfg.LLMModel, cfg.OpenAIAPIKey, cfg.LLMHeaders)
+		compatible.ContentSampleRate = cfg.LLMLogSampleRate
+		provider = compatible
+	case clients.FixtureProviderName:
+		if cfg.Production() {
+			slog.Error("llm_fixture_provider_refused")
+			return nil
+		}
+		provider = clients.NewFixtureProvider(cfg.LLMFixtures)
+	default:
+		slog.Warn("llm_provider_unknown", "provider", name)
+		return nil
+	}
+
+	var scrubber *scrub.Scrubber
+	if len(cfg.LLMScrub) != 1 || cfg.LLMScrub[0] != "none" {
+		scrubber = scrub.New(cfg.LLMScrub, cfg.LLMScrubWords)
+	}
+	llm := clients.NewLLMClient(provider, scrubber)
+	llm.MatchMaxWait = cfg.LLMMaxWait
+	return llm
+}
+
+// newPropertySource returns the named property data source
+func newPropertySource(cfg config.Config, source string) clients.PropertyDataSource {
+	switch source {
+	case "buildium":
+		return clients.NewBuildiumClient(cfg.BuildiumClientID, cfg.BuildiumClientSecret)
+	case "yardi":
+		return clients.NewYardiClient(cfg.YardiServiceURL, clients.YardiCredentials{
+			UserName:        cfg.YardiUserName,
+			Password:        cfg.YardiPassword,
+			ServerName:      cfg.YardiServerName,
+			Database:        cfg.YardiDatabase,
+			Platform:        cfg.YardiPlatform,
+			InterfaceEntity: cfg.YardiInterfaceEntity,
+			License:         cfg.YardiLicense,
+		})
+	default:
+		return clients.NewAppFolioClient(cfg.AppFolioAuthHeader, cfg.AppFolioDeveloperID)
+	}
+}
+
+// forTenant returns a copy of the pipeline using the tenant's property data
+// source and, when the tenant has data residency, its Supabase project
+func (p *pipeline) forTenant(tenantID string) *pipeline {
+	source := p.cfg.PropertySourceFor(tenantID)
+	_, resident := p.cfg.TenantDataResidency[tenantID]
+	if source == p.properties.Name() && !resident {
+		return p
+	}
+	cp := *p
+	cp.properties = newPropertySource(p.cfg, source)
+	if resident {
+		cp.supabase = newTenantSupabaseClient(p.cfg, tenantID)
+		if _, ok := p.bookings.(*store.SupabaseBookings); ok {
+			cp.bookings = store.NewSupabaseBookings(cp.supabase)
+		}
+	}
+	cp.lifecycle = newLifecycle(&cp)
+	return &cp
+}
+
+// availabilityResult is the outcome of findAvailability. Besides the response
+// it carries the intermediate lookups so follow-up actions (e.g. booking a
+// slot over SMS) don't need to repeat them.
+type availabilityResult struct {
+	Response    models.Response
+	PropertyID  string
+	AccessToken string
+	Slots       []models.TimeSlot
+	LockID      string // set for self-guided properties
+	TimeZone    string // IANA zone of Slots
+	Source      string // marketing channel of the request
+	TenantID    string // tenant of the request
+}
+
+// propertyRecord is a resolved property plus, when the property system
+// lookup failed, the listings-feed row it was reconstructed from.
+type propertyRecord struct {
+	*models.AppFolioProperty
+	Feed *models.FeedListing
+	// Unit is the unit the showing is for, at a multi-unit property
+	Unit *models.UnitInfo
+}
+
+func (r propertyRecord) info() models.PropertyInfo {
+	info := mapPropertyInfo(r.AppFolioProperty)
+	if r.Feed != nil {
+		info.Rent = r.Feed.Rent
+	}
+	if r.Unit != nil {
+		info.UnitID = r.Unit.ID
+		info.Address = fmt.Sprintf("%s, Unit %s", info.Address, r.Unit.Name)
+		if r.Unit.Rent > 0 {
+			info.Rent = r.Unit.Rent
+		}
+	}
+	return info
+}
+
+func (r propertyRecord) provenance(source string) string {
+	if r.Feed != nil {
+		return "feed"
+	}
+	return source
+}
+
+// propertyMatch is a property already matched to the query before
+// findAvailability, and how (models.Match*)
+type propertyMatch struct {
+	PropertyID string
+	Source     string
+}
+
+func (p *pipeline) findAvailability(ctx context.Context, requestID string, req models.Request, match propertyMatch) (result availabilityResult) {
+	p = p.forTenant(req.TenantID)
+	defer func() {
+		result.Source, result.TenantID = req.Source, req.TenantID
+		if match.Source == "" || result.PropertyID == "" && result.Response.Property.ID == "" {
+			return
+		}
+		result.Response.MatchSource = match.Source
+		result.Response.MatchConfidence = logic.MatchConfidence(match.Source, req.Query, result.Response.Property.Address)
+	}()
+	events.Emit(ctx, events.Event{
+		Type:     events.TypeInquiry,
+		TenantID: req.TenantID,
+		Phone:    req.Phone,
+		Data:     events.InquiryReceived{Query: req.Query, Source: req.Source},
+	})
+
+	// 4. Find Property ID (use the LLM-matched or given ID if available)
+	propID := match.PropertyID
+	if propID != "" {
+		slog.InfoContext(ctx, "property_source", "source", match.Source, "property_id", propID)
+	} else {
+		var err error
+		done := timeStage(ctx, "search")
+		propID, err = p.search.FindPropertyID(ctx, req.Query)
+		done()
+		match.Source = models.MatchSearch
+		if err != nil {
+			slog.WarnContext(ctx, "search_failed", "error", err, "query", req.Query)
+			propID = p.inlinePropertySearch(ctx, req.Query)
+			match.Source = models.MatchFuzzy
+		}
+		if propID == "" {
+			reason := "no_match"
+			if err != nil {
+				reason = err.Error()
+			}
+			emitMatchFailed(ctx, req, "", "search", reason)
+			return availabilityResult{Response: models.Response{
+				Success:      false,
+				Message:      "Could not find property matching query.",
+				FormattedMsg: fmt.Sprintf("I couldn't find a property matching '%s'. Could you verify the address?", req.Query),
+				NextActions:  []string{models.NextConfirmAddress},
+			}}
+		}
+	}
+	slog.InfoContext(ctx, "property_found", "property_id", propID)
+
+	// 5. Fetch Property Details (listings feed as fallback)
+	done := timeStage(ctx, "property")
+	prop, fail := p.fetchProperty(ctx, propID)
+	done()
+	if fail != nil {
+		emitMatchFailed(ctx, req, propID, "property", fail.Response.Message)
+		return *fail
+	}
+	provenance := prop.provenance(p.properties.Name())
+
+	// 5a. Uncertain matches are confirmed with the caller before offering times
+	if confirm := p.needsConfirmation(ctx, req, propertyMatch{PropertyID: propID, Source: match.Source}, prop); confirm != nil {
+		confirm.Response.Provenance = provenance
+		return *confirm
+	}
+
+	// 5b. Existing tenants asking about another home are transfers, not showings
+	caller := p.identifyCaller(ctx, req.Phone)
+	if caller != nil && caller.Type == models.CallerTenant {
+		return p.tenantTransfer(ctx, requestID, req, caller, propID, prop, provenance)
+	}
+
+	// 5c. Multi-unit properties: the showing is for one vacant unit
+	unit, choose := p.chooseUnit(ctx, req, propID, prop)
+	if choose != nil {
+		choose.Response.Caller = caller
+		choose.Response.Provenance = provenance
+		return *choose
+	}
+	prop.Unit = unit
+
+	// 5d. Self-guided properties are toured with a lock code; no agent calendar involved
+	settings := p.propertySettings(ctx, propID)
+	if settings.SelfGuided {
+		if p.locks != nil && settings.LockID != "" {
+			result := p.selfGuidedAvailability(ctx, req, propID, prop, settings, provenance)
+			result.Response.Caller = caller
+			return result
+		}
+		slog.WarnContext(ctx, "self_guided_unavailable", "property_id", propID, "lock_configured", settings.LockID != "")
+	}
+
+	// 6-7. Find the leasing agent
+	done = timeStage(ctx, "agent")
+	agent, fail := p.resolveAgent(ctx, requestID, req, propID, prop)
+	done()
+	if fail != nil {
+		fail.Response.Provenance = provenance
+		emitMatchFailed(ctx, req, propID, "agent", fail.Response.Message)
+		return *fail
+	}
+	slog.InfoContext(ctx, "agent_mapped", "name", agent.Name, "email", agent.Email, "zone", agent.Zone)
+	events.Emit(ctx, events.Event{
+		Type:       events.TypeMatch,
+		TenantID:   req.TenantID,
+		PropertyID: propID,
+		Phone:      req.Phone,
+		Data:       events.PropertyMatched{Agent: agent.Email, Zone: agent.Zone, AssignedBy: agent.AssignedBy, Provenance: provenance},
+	})
+
+	// 8. Get Calendar Access Token
+	done = timeStage(ctx, "token")
+	token, err := p.supabase.GetAccessToken(ctx, agent.Email)
+	done()
+	if err != nil {
+		slog.ErrorContext(ctx, "token_fetch_failed", "email", agent.Email, "error", err)
+		p.notifyTeam(ctx, requestID, agent.Zone, fmt.Sprintf(":warning: Caller couldn't be helped: %s's calendar token is unavailable (%s).\nQuery: %q, phone: %s",
+			agent.Name, prop.Address1, req.Query, orUnknown(req.Phone)))
+		return availabilityResult{PropertyID: propID, Response: models.Response{
+			Success:      false,
+			Property:     prop.info(),
+			Agent:        *agent,
+			Provenance:   provenance,
+			Message:      "Agent calendar access unavailable.",
+			FormattedMsg: fmt.Sprintf("I'd love to schedule a viewing for %s, but I can't access %s's calendar right now. Please email them at %s.", prop.Address1, agent.Name, agent.Email),
+			NextActions:  []string{models.NextTransferToHuman},
+		}}
+	}
+
+	// 9. Get Busy Slots (in the tenant's time zone)
+	rules := p.cfg.ScheduleRulesFor(req.TenantID)
+	loc := logic.ScheduleLocation(rules)
+	now := time.Now().In(loc)
+	timeMin, timeMax, err := requestRange(req, now)
+	if err != nil {
+		slog.WarnContext(ctx, "date_range_invalid", "from", req.From, "to", req.To, "error", err)
+		return availabilityResult{PropertyID: propID, AccessToken: token, Response: models.Response{
+			Success:      false,
+			Property:     prop.info(),
+			Agent:        *agent,
+			Provenance:   provenance,
+			Message:      "Invalid date range.",
+			FormattedMsg: "I couldn't understand those dates. Could you give me the days you're available again?",
+			NextActions:  []string{models.NextAskDatePreference},
+		}}
+	}
+	if p.cfg.AgentWorkingHours {
+		rules = p.agentWorkingHours(ctx, token, agent.Email, rules, timeMin, timeMax)
+	}
+	scope := busyScope{Email: agent.Email, Holder: slotHolder(ctx, req.Phone), PropertyID: propID, Settings: settings, Loc: loc}
+	if prop.Unit != nil {
+		scope.UnitID = prop.Unit.ID
+	}
+	search, err := p.searchCalendar(ctx, token, scope, req, now, timeMin, timeMax, rules, logic.TourDuration(settings.TourMinutes))
+	if err != nil {
+		calendarFetchFailed(ctx, agent.Email, err)
+		p.notifyTeam(ctx, requestID, agent.Zone, fmt.Sprintf(":warning: Caller couldn't be helped: %s's calendar is unreachable (%s).\nQuery: %q, phone: %s",
+			agent.Name, prop.Address1, req.Query, orUnknown(req.Phone)))
+		return availabilityResult{PropertyID: propID, AccessToken: token, Response: models.Response{
+			Success:      false,
+			Property:     prop.info(),
+			Agent:        *agent,
+			Provenance:   provenance,
+			Message:      "Failed to read calendar.",
+			FormattedMsg: fmt.Sprintf("I'm having trouble checking %s's availability. Please contact them directly at %s.", agent.Name, agent.Email),
+			NextActions:  []string{models.NextTransferToHuman},
+		}}
+	}
+
+	// 10. Availability (generated by searchCalendar)
+	availableSlots, daysChecked, totalSlots := search.Slots, search.DaysChecked, search.TotalSlots
+	if timeMin.Equal(now) {
+		recordCapacity(ctx, *agent, now, search)
+	}
+	avail := models.Availability{
+		TotalSlotsAvailable: len(availableSlots),
+		DaysChecked:         daysChecked,
+		SearchedThrough:     search.Through.Add(-time.Nanosecond).Format(time.DateOnly),
+	}
+	// Callers who asked for a day get that day's slots, or the nearest days'
+	offer, preferredNote := offerPreferredDate(req, loc, availableSlots, &avail)
+
+	// 10b. Rank: travel feasibility from the previous appointment, clustering with nearby showings.
+	// The earliest-only fast path offers slots in time order instead.
+	avail.Suggestions = offer
+	if req.Mode != modeEarliest {
+		done = timeStage(ctx, "slots")
+		avail.Suggestions = p.rankSlots(ctx, token, agent.Email, prop, offer, timeMin, search.Through)
+		done()
+	}
+	avail.Suggestions = p.holdSlots(ctx, slotHolder(ctx, req.Phone), agent.Email, propID, avail.Suggestions)
+
+	// 11. Format Message
+	pageSlots(&avail, offer, req.Page, req.PageSize)
+
+	var formattedMsg string
+	if req.Mode == modeEarliest {
+		formattedMsg = formatEarliestMessage(prop.info(), *agent, avail)
+	} else {
+		formattedMsg = formatMessage(prop.info(), *agent, avail, totalSlots)
+	}
+	if notice := slotNotice(p.cfg, prop.info(), *agent, offer, now); notice != "" {
+		formattedMsg = notice + "\n\n" + formattedMsg
+	}
+	if preferredNote != "" {
+		formattedMsg = preferredNote + "\n\n" + formattedMsg
+	}
+	emitOffer(ctx, req, propID, avail)
+
+	slog.InfoContext(ctx, "scheduling_success",
+		"property_id", propID,
+		"agent", agent.Name,
+		"provenance", provenance,
+		"slots_available", len(availableSlots),
+		"days_checked", daysChecked,
+	)
+
+	return availabilityResult{
+		PropertyID:  propID,
+		AccessToken: token,
+		Slots:       availableSlots,
+		TimeZone:    loc.String(),
+		Response: models.Response{
+			Success:      true,
+			Property:     prop.info(),
+			Agent:        *agent,
+			Availability: avail,
+			Provenance:   provenance,
+			Message:      "Success",
+			FormattedMsg: formattedMsg,
+			NextActions:  offerActions(req, avail),
+			Caller:       caller,
+		},
+	}
+}
+
+// calendarFetchFailed logs a failed calendar read. When Google rejected the
+// token it is dropped from the warm cache, so the next call picks up the
+// refreshed one instead of failing until the cache expires.
+func calendarFetchFailed(ctx context.Context, email string, err error) {
+	slog.ErrorContext(ctx, "calendar_fetch_failed", "error", err,
+		"unauthorized", errors.Is(err, clients.ErrUnauthorized), "timeout", errors.Is(err, clients.ErrTimeout))
+	if errors.Is(err, clients.ErrUnauthorized) {
+		clients.InvalidateAccessToken(email)
+	}
+}
+
+// chooseUnit picks the unit of a multi-unit property the request is for: the
+// one named by req.UnitID or in the query ("unit 4"), or the only vacant
+// one. When the property has several vacant units and the request doesn't
+// say which, it returns a NextChooseUnit response listing them. Properties
+// whose source doesn't list units, or whose units can't be fetched, are
+// shown as a whole.
+func (p *pipeline) chooseUnit(ctx context.Context, req models.Request, propID string, prop propertyRecord) (*models.UnitInfo, *availabilityResult) {
+	directory, ok := p.properties.(clients.UnitDirectory)
+	if !ok || prop.Feed != nil {
+		return nil, nil
+	}
+	done := timeStage(ctx, "units")
+	vacant, err := directory.GetVacantUnits(ctx, propID)
+	done()
+	if err != nil {
+		slog.WarnContext(ctx, "units_fetch_failed", "property_id", propID, "error", err)
+		return nil, nil
+	}
+	units := make([]models.UnitInfo, len(vacant))
+	for i, u := range vacant {
+		units[i] = models.UnitInfo{ID: u.ID, Name: u.Name, Bedrooms: u.Bedrooms, Bathrooms: u.Bathrooms, Rent: u.MarketRent}
+	}
+	if len(units) == 1 {
+		return &units[0], nil
+	}
+	if len(units) == 0 {
+		p.waitlist(ctx, req.Phone, propID)
+		return nil, nil
+	}
+
+	named := logic.UnitFromQuery(req.Query)
+	for i, u := range units {
+		if (req.UnitID != "" && u.ID == req.UnitID) || (named != "" && strings.EqualFold(u.Name, named)) {
+			slog.InfoContext(ctx, "unit_chosen", "property_id", propID, "unit_id", u.ID)
+			return &units[i], nil
+		}
+	}
+	if req.UnitID != "" || named != "" {
+		slog.InfoContext(ctx, "unit_not_vacant", "property_id", propID, "unit_id", req.UnitID, "unit_name", named)
+	}
+
+	metrics.Incr(ctx, "UnitDisambiguation")
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s has %d available units: ", prop.Address1, len(units))
+	for i, u := range units {
+		if i > 0 {
+			sb.WriteString("; ")
+		}
+		sb.WriteString(describeUnit(u))
+	}
+	sb.WriteString(". Which one would you like to see?")
+	return nil, &availabilityResult{PropertyID: propID, Response: models.Response{
+		Success:      false,
+		Property:     prop.info(),
+		Units:        units,
+		Message:      "Property has multiple vacant units.",
+		FormattedMsg: sb.String(),
+		NextActions:  []string{models.NextChooseUnit},
+	}}
+}
+
+// describeUnit renders a unit for a disambiguation question: "Unit 2A, 2 bed,
+// $1,850/month"
+func describeUnit(u models.UnitInfo) string {
+	s := "Unit " + u.Name
+	if u.Bedrooms == 0 {
+		s += ", studio"
+	} else {
+		s += fmt.Sprintf(", %s bed", strconv.FormatFloat(u.Bedrooms, 'f', -1, 64))
+	}
+	if u.Rent > 0 {
+		s += ", " + formatRent(u.Rent) + "/month"
+	}
+	return s
+}
+
+// formatRent renders whole dollars with thousands separators: "$1,850"
+func formatRent(rent float64) string {
+	digits := strconv.FormatInt(int64(math.Round(rent)), 10)
+	for i := len(digits) - 3; i > 0; i -= 3 {
+		digits = digits[:i] + "," + digits[i:]
+	}
+	return "$" + digits
+}
+
+// inlinePropertySearch is the last matching tier, after the caller's
+// candidates and the search service: it asks the property system directly
+// for the street number and name in query. It returns "" unless exactly one
+// property matches.
+func (p *pipeline) inlinePropertySearch(ctx context.Context, query string) string {
+	searcher, ok := p.properties.(clients.PropertySearcher)
+	if !ok {
+		return ""
+	}
+	street, ok := logic.ParseStreet(query)
+	if !ok {
+		metrics.Incr(ctx, "PropertyInlineSearch", "Outcome", "unparsed")
+		return ""
+	}
+
+	done := timeStage(ctx, "inline_search")
+	props, err := searcher.SearchProperties(ctx, street.String())
+	done()
+	if err != nil {
+		slog.WarnContext(ctx, "inline_search_failed", "error", err)
+		metrics.Incr(ctx, "PropertyInlineSearch", "Outcome", "error")
+		return ""
+	}
+	var matches []string
+	for _, prop := range props {
+		if street.Matches(prop.Address1) {
+			matches = append(matches, prop.ID)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		metrics.Incr(ctx, "PropertyInlineSearch", "Outcome", "none")
+		return ""
+	case 1:
+		slog.InfoContext(ctx, "property_source", "source", models.MatchFuzzy, "property_id", matches[0])
+		metrics.Incr(ctx, "PropertyInlineSearch", "Outcome", "matched")
+		return matches[0]
+	default:
+		slog.InfoContext(ctx, "inline_search_ambiguous", "matches", len(matches))
+		metrics.Incr(ctx, "PropertyInlineSearch", "Outcome", "ambiguous")
+		return ""
+	}
+}
+
+// fetchProperty loads property details from the tenant's property system,
+// falling back to the nightly listings feed when that lookup fails.
+func (p *pipeline) fetchProperty(ctx context.Context, propID string) (propertyRecord, *availabilityResult) {
+	// While the property system is failing, try the feed first
+	if skipDegraded(ctx, p.properties.Name(), "property_fetch") {
+		if listing, err := p.supabase.GetFeedListing(ctx, propID); err == nil && listing != nil {
+			slog.InfoContext(ctx, "property_from_feed", "property_id", propID, "feed_source", listing.Source, "ingested_at", listing.IngestedAt)
+			metrics.Incr(ctx, "PropertyFeedFallback", "FeedSource", listing.Source)
+			return propertyRecord{AppFolioProperty: listing.Property(), Feed: listing}, nil
+		}
+	}
+
+	prop, err := p.properties.GetProperty(ctx, propID)
+	if err == nil {
+		return propertyRecord{AppFolioProperty: prop}, nil
+	}
+
+	credentialsRejected := errors.Is(err, clients.ErrAppFolioCredentials)
+	if credentialsRejected {
+		slog.ErrorContext(ctx, "appfolio_credentials_expired", "error", err, "property_id", propID)
+		metrics.Incr(ctx, "AppFolioCredentialsExpired", "Source", "request")
+	} else {
+		slog.ErrorContext(ctx, "property_fetch_failed", "source", p.properties.Name(), "error", err, "property_id", propID)
+	}
+
+	listing, feedErr := p.supabase.GetFeedListing(ctx, propID)
+	if feedErr != nil {
+		slog.WarnContext(ctx, "feed_lookup_failed", "property_id", propID, "error", feedErr)
+	}
+	if listing != nil {
+		slog.InfoContext(ctx, "property_from_feed", "property_id", propID, "feed_source", listing.Source, "ingested_at", listing.IngestedAt)
+		metrics.Incr(ctx, "PropertyFeedFallback", "FeedSource", listing.Source)
+		return propertyRecord{AppFolioProperty: listing.Property(), Feed: listing}, nil
+	}
+
+	if credentialsRejected {
+		return propertyRecord{}, &availabilityResult{PropertyID: propID, Response: models.Response{
+			Success:      false,
+			ErrorCode:    errCodeAppFolioCredentials,
+			Message:      "Property system credentials expired.",
+			FormattedMsg: "I found the property but our property system is temporarily unavailable. A team member will follow up with you shortly.",
+			NextActions:  []string{models.NextTransferToHuman},
+		}}
+	}
+	return propertyRecord{}, &availabilityResult{PropertyID: propID, Response: models.Response{
+		Success:      false,
+		Message:      "Property found but details unavailable.",
+		FormattedMsg: "I found the property but couldn't access its details right now.",
+		NextActions:  []string{models.NextTransferToHuman},
+	}}
+}
+
+// resolveAgent maps a property to its leasing agent: via an explicit
+// override, property groups (PD zones), the source's own agent directory,
+// or the feed's contact. Properties none of those can place escalate up the
+// zone hierarchy.
+func (p *pipeline) resolveAgent(ctx context.Context, requestID string, req models.Request, propID string, prop propertyRecord) (*models.AgentInfo, *availabilityResult) {
+	agents, err := p.supabase.ListAgents(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "agent_roster_fetch_failed", "error", err)
+	}
+	roster := logic.RosterByZone(agents)
+
+	var agent *models.AgentInfo
+	var zones []string // zones the property is known to be in, for escalation
+	if override := p.agentOverride(ctx, propID, agents); override != nil {
+		// An explicit property override beats zone-based assignment
+		agent = override
+	} else if prop.Feed != nil {
+		agent = prop.Feed.Agent(roster)
+		setAssignedBy(agent, "feed")
+	} else {
+		// 6. Fetch Property Groups (to find Agent)
+		groups, err := p.properties.GetPropertyGroups(ctx, prop.PropertyGroupIds)
+		if err != nil {
+			slog.ErrorContext(ctx, "property_groups_failed", "source", p.properties.Name(), "error", err)
+			return nil, &availabilityResult{PropertyID: propID, Response: models.Response{
+				Success:      false,
+				Property:     prop.info(),
+				Message:      "Could not determine agent.",
+				FormattedMsg: fmt.Sprintf("I have the details for %s, but I'm having trouble finding the assigned agent.", prop.Address1),
+				NextActions:  []string{models.NextTransferToHuman},
+			}}
+		}
+
+		// 7. Map Agent (agents table, falling back to the built-in roster)
+		agent = logic.MapAgentFrom(groups, roster)
+		setAssignedBy(agent, "group")
+		for _, g := range groups {
+			zones = append(zones, g.Name)
+		}
+		if dir, ok := p.properties.(clients.AgentDirectory); ok && agent == nil {
+			marketing, err := dir.GetMarketingAgents(ctx, propID)
+			if err != nil {
+				slog.WarnContext(ctx, "marketing_agents_failed", "source", p.properties.Name(), "error", err)
+			}
+			agent = logic.MatchRosterAgent(marketing, roster)
+			setAssignedBy(agent, "directory")
+		}
+	}
+
+	// Geocode the address into a zone when nothing else assigned an agent
+	if agent == nil {
+		var zone string
+		agent, zone = p.agentByGeocode(ctx, prop, roster)
+		if zone != "" {
+			zones = append(zones, zone)
+		}
+	}
+
+	// Agents on vacation hand their properties to their backup
+	if now := time.Now(); agent != nil && logic.OnVacation(*agent, now) {
+		away := *agent
+		agent = logic.CoverAgent(away, agents, now)
+		if agent == nil {
+			agent = p.escalate(ctx, append([]string{away.Zone}, zones...))
+		}
+		if agent == nil {
+			slog.WarnContext(ctx, "agent_vacation_uncovered", "agent", away.Email)
+			p.notifyTeam(ctx, requestID, away.Zone, fmt.Sprintf(":warning: Caller couldn't be helped: %s is on vacation with no available backup (%s).\nQuery: %q, phone: %s",
+				away.Name, prop.Address1, req.Query, orUnknown(req.Phone)))
+			return nil, &availabilityResult{PropertyID: propID, Response: models.Response{
+				Success:      false,
+				Property:     prop.info(),
+				Message:      "Leasing agent on vacation.",
+				FormattedMsg: fmt.Sprintf("The leasing agent for %s is away right now. A team member will follow up with you shortly.", prop.Address1),
+				NextActions:  []string{models.NextTransferToHuman},
+			}}
+		}
+		slog.InfoContext(ctx, "agent_vacation_covered", "agent", away.Email, "backup", agent.Email)
+		metrics.Incr(ctx, "AgentVacationCovered", "Zone", away.Zone)
+	} else if agent == nil {
+		agent = p.escalate(ctx, zones)
+	}
+
+	if agent == nil {
+		slog.WarnContext(ctx, "agent_mapping_failed")
+		p.notifyTeam(ctx, requestID, "", fmt.Sprintf(":warning: Caller couldn't be helped: no leasing agent mapped for %s (property %s).\nQuery: %q, phone: %s",
+			prop.Address1, propID, req.Query, orUnknown(req.Phone)))
+		return nil, &availabilityResult{PropertyID: propID, Response: models.Response{
+			Success:      false,
+			Property:     prop.info(),
+			Message:      "No leasing agent assigned (No PD group).",
+			FormattedMsg: fmt.Sprintf("I checked %s, but there doesn't seem to be a leasing agent assigned to it yet.", prop.Address1),
+			NextActions:  []string{models.NextTransferToHuman},
+		}}
+	}
+	return agent, nil
+}
+
+// agentOverride returns the agent explicitly assigned to the property, or
+// nil when there is none (or it can't be read)
+func (p *pipeline) agentOverride(ctx context.Context, propID string, agents []models.AgentInfo) *models.AgentInfo {
+	override, err := p.supabase.GetPropertyAgentOverride(ctx, propID)
+	if err != nil {
+		slog.WarnContext(ctx, "agent_override_fetch_failed", "property_id", propID, "error", err)
+		return nil
+	}
+	if override == nil || override.AgentEmail == "" {
+		return nil
+	}
+
+	agent := models.AgentInfo{Name: override.AgentName, Email: override.AgentEmail}
+	for _, a := range agents {
+		if strings.EqualFold(a.Email, override.AgentEmail) {
+			agent = a
+			break
+		}
+	}
+	agent.AssignedBy = "override"
+	metrics.Incr(ctx, "AgentOverrideApplied")
+	return &agent
+}
+
+// escalate hands a property no zone agent can take to its regional or
+// company contact, or returns nil when no hierarchy contact applies
+func (p *pipeline) escalate(ctx context.Context, zones []string) *models.AgentInfo {
+	contact := logic.Escalate(p.cfg.ZoneHierarchy, zones)
+	if contact == nil {
+		return nil
+	}
+	contact.AssignedBy = "escalation"
+	slog.InfoContext(ctx, "agent_escalated", "level", contact.EscalationLevel, "zones", zones, "contact", contact.Email)
+	metrics.Incr(ctx, "AgentEscalated", "Level", contact.EscalationLevel)
+	return contact
+}
+
+// agentByGeocode resolves the property address to coordinates and assigns
+// the zone whose configured polygon contains them. The zone is returned even
+// when it has no agent.
+func (p *pipeline) agentByGeocode(ctx context.Context, prop propertyRecord, roster map[string]models.AgentInfo) (*models.AgentInfo, string) {
+	if p.cfg.GoogleMapsAPIKey == "" || len(p.cfg.ZonePolygons) == 0 {
+		return nil, ""
+	}
+
+	address := fmt.Sprintf("%s, %s, %s", prop.Address1, prop.City, prop.State)
+	loc, err := clients.NewMapsClient(p.cfg.GoogleMapsAPIKey).Geocode(ctx, address)
+	if err != nil {
+		slog.WarnContext(ctx, "geocode_failed", "address", address, "error", err)
+		return nil, ""
+	}
+
+	zone := logic.ZoneForPoint(loc.Lat, loc.Lng, p.cfg.ZonePolygons)
+	agent, ok := roster[zone]
+	if zone == "" || !ok {
+		slog.WarnContext(ctx, "geo_zone_unmatched", "lat", loc.Lat, "lng", loc.Lng, "zone", zone)
+		return nil, zone
+	}
+
+	slog.InfoContext(ctx, "geo_zone_assigned", "zone", zone, "lat", loc.Lat, "lng", loc.Lng)
+	metrics.Incr(ctx, "ZoneAutoAssigned", "Zone", zone)
+	agent.AssignedBy = "geo"
+	return &agent, zone
+}
+
+func setAssignedBy(agent *models.AgentInfo, how string) {
+	if agent != nil {
+		agent.AssignedBy = how
+	}
+}
+
+// rankSlots annotates slots with travel risk (where the agent is before each
+// slot and how long the drive to the property takes) and clustering (whether
+// the slot is back-to-back with a nearby showing), then returns the ranked
+// suggestions. Annotation is best-effort: any failure leaves slots as-is.
+func (p *pipeline) rankSlots(ctx context.Context, token, email string, prop propertyRecord, slots []models.TimeSlot, timeMin, timeMax time.Time) []models.TimeSlot {
+	weights := logic.RankingWeights{ClusterBonus: p.cfg.RankingClusterWeight}
+	useMaps := p.cfg.GoogleMapsAPIKey != "" && prop.Address1 != "" && !skipDegraded(ctx, "google_maps", "travel_ranking")
+	if len(slots) == 0 || (!useMaps && weights.ClusterBonus == 0) {
+		return logic.RankSlots(slots, logic.SuggestionCount, weights)
+	}
+
+	events, err := p.calendar.ListEvents(ctx, token, email, timeMin.Add(-logic.PrecedingEventWindow), timeMax)
+	if err != nil {
+		slog.WarnContext(ctx, "calendar_events_failed", "error", err)
+		return logic.RankSlots(slots, logic.SuggestionCount, weights)
+	}
+
+	var maps *clients.MapsClient
+	if useMaps {
+		maps = clients.NewMapsClient(p.cfg.GoogleMapsAPIKey)
+	}
+	destination := fmt.Sprintf("%s, %s, %s", prop.Address1, prop.City, prop.State)
+
+	if maps != nil {
+		p.annotateTravel(ctx, maps, destination, events, slots)
+	}
+	if weights.ClusterBonus != 0 {
+		p.annotateClusters(ctx, maps, prop, destination, events, slots)
+	}
+	return logic.RankSlots(slots, logic.SuggestionCount, weights)
+}
+
+func (p *pipeline) annotateTravel(ctx context.Context, maps *clients.MapsClient, destination string, events []models.CalendarEvent, slots []models.TimeSlot) {
+	seen := map[string]bool{}
+	var origins []string
+	for _, e := range events {
+		if e.Location != "" && !seen[e.Location] {
+			seen[e.Location] = true
+			origins = append(origins, e.Location)
+		}
+	}
+	if len(origins) == 0 {
+		return
+	}
+
+	travel, err := maps.TravelTimes(ctx, origins, destination)
+	if err != nil {
+		slog.WarnContext(ctx, "travel_times_failed", "error", err)
+		return
+	}
+
+	logic.AnnotateTravel(slots, events, travel)
+
+	risky := 0
+	for _, s := range slots {
+		if s.TravelRisk != "" {
+			risky++
+		}
+	}
+	slog.InfoContext(ctx, "travel_annotated", "origins", len(origins), "risky_slots", risky)
+}
+
+// annotateClusters finds showings this service booked at the same property
+// or, when geocoding is available, within ClusterRadiusKm of it.
+func (p *pipeline) annotateClusters(ctx context.Context, maps *clients.MapsClient, prop propertyRecord, destination string, events []models.CalendarEvent, slots []models.TimeSlot) {
+	var origin clients.LatLng
+	located := false
+	if maps != nil {
+		if loc, err := maps.Geocode(ctx, destination); err == nil {
+			origin, located = loc, true
+		}
+	}
+
+	var nearby []logic.ShowingWindow
+	for _, e := range events {
+		start, okStart := e.Start.Time()
+		end, okEnd := e.End.Time()
+		if !okStart || !okEnd || !strings.HasPrefix(e.Summary, showingSummaryPrefix) || e.Location == "" {
+			continue
+		}
+
+		isNear := strings.Contains(strings.ToLower(e.Location), strings.ToLower(prop.Address1))
+		if !isNear && located {
+			if loc, err := maps.Geocode(ctx, e.Location); err == nil {
+				isNear = logic.HaversineKm(origin.Lat, origin.Lng, loc.Lat, loc.Lng) <= p.cfg.ClusterRadiusKm
+			}
+		}
+		if isNear {
+			nearby = append(nearby, logic.ShowingWindow{Start: start, End: end})
+		}
+	}
+	if len(nearby) == 0 {
+		return
+	}
+
+	logic.AnnotateClusters(slots, nearby)
+	slog.InfoContext(ctx, "clusters_annotated", "nearby_showings", len(nearby))
+}
+
+// requestRange returns the availability window for a request: From/To when
+// given, otherwise LookAheadDays (default logic.MaxDays) from now or From.
+func requestRange(req models.Request, now time.Time) (time.Time, time.Time, error) {
+	days := logic.MaxDays
+	if req.LookAheadDays > 0 {
+		days = req.LookAheadDays
+	}
+	from, to := now, now.AddDate(0, 0, days)
+	if preferred, ok, err := preferredDate(req, now.Location()); err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("preferred date: %w", err)
+	} else if ok {
+		from, to = preferred.AddDate(0, 0, -logic.PreferredDateSpan), preferred.AddDate(0, 0, 1+logic.PreferredDateSpan)
+	}
+	if req.From != "" {
+		t, err := parseRangeBound(req.From, now.Location(), false)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("from: %w", err)
+		}
+		from = t
+		if req.To == "" {
+			to = from.AddDate(0, 0, days)
+		}
+	}
+	if req.To != "" {
+		t, err := parseRangeBound(req.To, now.Location(), true)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("to: %w", err)
+		}
+		to = t
+	}
+	from, to = logic.ClampRange(now, from, to)
+	if !to.After(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("range %s to %s is empty or in the past", req.From, req.To)
+	}
+	return from, to, nil
+}
+
+// preferredDate returns the midnight starting the request's PreferredDate,
+// and false when it doesn't apply
+func preferredDate(req models.Request, loc *time.Location) (time.Time, bool, error) {
+	if req.PreferredDate == "" || req.From != "" || req.To != "" || req.Mode == modeEarliest {
+		return time.Time{}, false, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, req.PreferredDate, loc)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return t, true, nil
+}
+
+// offerPreferredDate narrows slots to the request's preferred date, or to
+// the nearest days with openings when it's full, recording the substitution
+// on avail. The returned note explains it to the caller ("" if none).
+func offerPreferredDate(req models.Request, loc *time.Location, slots []models.TimeSlot, avail *models.Availability) ([]models.TimeSlot, string) {
+	day, ok, _ := preferredDate(req, loc)
+	if !ok {
+		return slots, ""
+	}
+	avail.PreferredDate = req.PreferredDate
+
+	offer, alternates := logic.PreferredDaySlots(slots, day, logic.AlternateDayCount)
+	if alternates == nil {
+		return offer, ""
+	}
+	names := make([]string, len(alternates))
+	for i, d := range alternates {
+		avail.AlternateDates = append(avail.AlternateDates, d.Format(time.DateOnly))
+		names[i] = d.Format("Monday, January 2")
+	}
+
+	note := fmt.Sprintf("There are no showings open on %s.", day.Format("Monday, January 2"))
+	switch len(names) {
+	case 0:
+		return offer, note
+	case 1:
+		note += fmt.Sprintf(" The closest day with openings is %s.", names[0])
+	default:
+		note += fmt.Sprintf(" The closest days with openings are %s and %s.", strings.Join(names[:len(names)-1], ", "), names[len(names)-1])
+	}
+	return offer, note
+}
+
+// parseRangeBound parses a date or RFC 3339 time; an end date covers the whole day
+func parseRangeBound(s string, loc *time.Location, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.In(loc), nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, s, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// agentWorkingHours overlays the agent's calendar working hours on rules,
+// keeping rules unchanged when they can't be read.
+func (p *pipeline) agentWorkingHours(ctx context.Context, token, email string, rules *models.ScheduleRules, from, to time.Time) *models.ScheduleRules {
+	done := timeStage(ctx, "calendar")
+	hours, err := p.calendar.ListWorkingHours(ctx, token, email, from, to)
+	done()
+	if err != nil {
+		slog.WarnContext(ctx, "working_hours_fetch_failed", "error", err)
+		return rules
+	}
+	slog.InfoContext(ctx, "working_hours_loaded", "days", len(hours))
+	return logic.WithWorkingHours(rules, hours)
+}
+
+// modeEarliest is the Request.Mode for the soonest-slots fast path
+const modeEarliest = "earliest"
+
+// earliestCount is how many slots the earliest-only fast path looks for
+func earliestCount(req models.Request) int {
+	if req.PageSize > 0 {
+		return min(req.PageSize, maxPageSize)
+	}
+	return logic.SuggestionCount
+}
+
+// searchChunkDays is how much calendar one freeBusy call covers on searches
+// longer than a week
+const searchChunkDays = 7
+
+// calendarSearch is the outcome of searchCalendar
+type calendarSearch struct {
+	Slots       []models.TimeSlot
+	DaysChecked int
+	TotalSlots  int
+	Through     time.Time // end of the last window checked
+}
+
+// recordCapacity emits how many days ahead the agent's first open slot is,
+// per zone and per agent, so chronically full zones stand out. With no open
+// slot the searched horizon is recorded as a lower bound.
+func recordCapacity(ctx context.Context, agent models.AgentInfo, now time.Time, search calendarSearch) {
+	next := search.Through
+	if len(search.Slots) > 0 {
+		next = search.Slots[0].Start
+	}
+	days := math.Round(next.Sub(now).Hours()/24*10) / 10
+
+	zone := agent.Zone
+	if zone == "" {
+		zone = "none"
+	}
+	metrics.Record(ctx, "DaysUntilNextSlot", days, metrics.None, "Zone", zone)
+	metrics.Record(ctx, "DaysUntilNextSlot", days, metrics.None, "Agent", agent.Email)
+}
+
+// searchCalendar reads the agent's busy times over [from, to) and generates
+// the open slots. Ranges longer than a week are read a week at a time,
+// stopping once the requested page of slots can be filled; if a later week
+// fails, the weeks already read are returned.
+func (p *pipeline) searchCalendar(ctx context.Context, token string, scope busyScope, req models.Request, now, from, to time.Time, rules *models.ScheduleRules, slotDuration time.Duration) (calendarSearch, error) {
+	if !to.After(from.AddDate(0, 0, searchChunkDays)) {
+		done := timeStage(ctx, "calendar")
+		busy, err := p.busySlots(ctx, token, scope, from, to)
+		done()
+		if err != nil {
+			return calendarSearch{}, err
+		}
+		done = timeStage(ctx, "slots")
+		slots, days, total := generateSlots(busy, req, now, from, to, rules, slotDuration)
+		done()
+		return calendarSearch{Slots: slots, DaysChecked: days, TotalSlots: total, Through: to}, nil
+	}
+
+	page, pageSize := pageBounds(req.Page, req.PageSize)
+	want := page*pageSize + 1 // one more to know whether there's a next page
+	if req.Mode == modeEarliest {
+		want = earliestCount(req)
+	} else if req.PreferredDate != "" && req.From == "" && req.To == "" {
+		// Alternates can lie anywhere in the span around the preferred date
+		want = math.MaxInt
+	}
+
+	result := calendarSearch{Through: from}
+	for chunkStart := from; chunkStart.Before(to) && len(result.Slots) < want; {
+		midnight := time.Date(chunkStart.Year(), chunkStart.Month(), chunkStart.Day(), 0, 0, 0, 0, chunkStart.Location())
+		chunkEnd := midnight.AddDate(0, 0, searchChunkDays)
+		if chunkEnd.After(to) {
+			chunkEnd = to
+		}
+
+		done := timeStage(ctx, "calendar")
+		busy, err := p.busySlots(ctx, token, scope, chunkStart, chunkEnd)
+		done()
+		if err != nil {
+			if len(result.Slots) == 0 {
+				return calendarSearch{}, err
+			}
+			slog.WarnContext(ctx, "calendar_week_fetch_failed", "from", chunkStart, "error", err)
+			break
+		}
+
+		done = timeStage(ctx, "slots")
+		var slots []models.TimeSlot
+		var days, total int
+		if req.Mode == modeEarliest {
+			slots, days, total = logic.GenerateEarliestSlots(busy, now, chunkStart, chunkEnd, rules, slotDuration, want-len(result.Slots))
+		} else {
+			slots, days, total = logic.GenerateSlotsInRange(busy, now, chunkStart, chunkEnd, rules, slotDuration)
+		}
+		done()
+		result.Slots = append(result.Slots, slots...)
+		result.DaysChecked += days
+		result.TotalSlots += total
+		result.Through = chunkEnd
+		chunkStart = chunkEnd
+	}
+
+	slog.InfoContext(ctx, "calendar_search_complete",
+		"searched_days", int(result.Through.Sub(from).Hours()/24+0.5), "requested_days", int(to.Sub(from).Hours()/24+0.5), "slots", len(result.Slots))
+	return result, nil
+}
+
+// busyScope is whose busy time a slot search or booking check reads
+type busyScope struct {
+	Email  string // the agent's calendar
+	Holder string // the caller, whose own slot holds don't count
+	// PropertyID and UnitID are the showing's, for the bookings already
+	// made there; Settings.MaxShowingsPerDay caps those per day in Loc
+	PropertyID string
+	UnitID     string
+	Settings   models.PropertySettings
+	Loc        *time.Location
+	// Moving is a booking being moved: neither its record nor its own
+	// calendar event counts
+	Moving *models.Booking
+}
+
+// busySlots is the agent's calendar busy time over [from, to), plus the
+// slots other callers hold and what the property's bookings rule out:
+// days at its showing cap and, with SuppressPropertyOverlaps, the showings
+// themselves. Those extra sources failing to load doesn't fail the lookup;
+// the calendar is authoritative.
+func (p *pipeline) busySlots(ctx context.Context, token string, scope busyScope, from, to time.Time) ([]models.TimeRange, error) {
+	busy, err := p.calendar.GetBusySlots(ctx, token, scope.Email, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if scope.Moving != nil && scope.Moving.EventID != "" {
+		busy = p.withoutOwnEvent(ctx, token, scope.Email, *scope.Moving, busy)
+	}
+	if p.holds != nil {
+		held, err := p.holds.HeldByOthers(ctx, scope.Email, scope.Holder, from, to)
+		if err != nil {
+			slog.WarnContext(ctx, "slot_holds_read_failed", "error", err)
+		}
+		busy = append(busy, held...)
+	}
+	return append(busy, p.propertyBusy(ctx, scope, from, to)...), nil
+}
+
+// withoutOwnEvent frees booking's slot in the agent's busy time, keeping
+// whatever else on the calendar overlaps it. Free/busy doesn't say which
+// event made a period busy, so the slot's other events are read back in;
+// if they can't be, the slot stays busy.
+func (p *pipeline) withoutOwnEvent(ctx context.Context, token, email string, booking models.Booking, busy []models.TimeRange) []models.TimeRange {
+	events, err := p.calendar.ListEvents(ctx, token, email, booking.Start, booking.End)
+	if err != nil {
+		slog.WarnContext(ctx, "calendar_events_failed", "error", err)
+		return busy
+	}
+	freed := logic.FreeRange(busy, booking.Start, booking.End)
+	for _, e := range events {
+		if e.ID == booking.EventID {
+			continue
+		}
+		start, ok := e.Start.Time()
+		end, ok2 := e.End.Time()
+		if !ok || !ok2 {
+			// All-day events, which free/busy already counted as it saw fit
+			continue
+		}
+		freed = append(freed, models.TimeRange{Start: start, End: end})
+	}
+	return freed
+}
+
+// propertyBusy returns the busy periods the property's active bookings
+// make over [from, to): whole days that have reached
+// Settings.MaxShowingsPerDay (counting only UnitID's showings when set)
+// and, with SuppressPropertyOverlaps, the showings overlapping the range
+// whichever agent has them
+func (p *pipeline) propertyBusy(ctx context.Context, scope busyScope, from, to time.Time) []models.TimeRange {
+	limit := scope.Settings.MaxShowingsPerDay
+	if scope.PropertyID == "" || (limit <= 0 && !p.cfg.SuppressPropertyOverlaps) {
+		return nil
+	}
+	loc := scope.Loc
+	if loc == nil {
+		loc = time.UTC
+	}
+	// Whole days, for the cap; a showing starting up to the longest tour
+	// before from can still overlap
+	dayStart := func(t time.Time) time.Time {
+		t = t.In(loc)
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	}
+	first, last := dayStart(from), dayStart(to.Add(-time.Nanosecond)).AddDate(0, 0, 1)
+	bookings, err := p.bookings.ListByProperty(ctx, scope.PropertyID, first.Add(-logic.MaxSlotDuration), last)
+	if err != nil {
+		slog.WarnContext(ctx, "property_bookings_read_failed", "property_id", scope.PropertyID, "error", err)
+		return nil
+	}
+
+	var busy []models.TimeRange
+	perDay := make(map[time.Time]int)
+	for _, b := range bookings {
+		if !b.Active() || (scope.Moving != nil && b.ID == scope.Moving.ID) {
+			continue
+		}
+		if p.cfg.SuppressPropertyOverlaps && b.End.After(from) && b.Start.Before(to) {
+			busy = append(busy, models.TimeRange{Start: b.Start, End: b.End})
+		}
+		if scope.UnitID == "" || b.UnitID == scope.UnitID {
+			perDay[dayStart(b.Start)]++
+		}
+	}
+	if limit > 0 {
+		for day, n := range perDay {
+			if n >= limit && day.Before(last) && !day.Before(first) {
+				slog.InfoContext(ctx, "showing_cap_reached", "property_id", scope.PropertyID, "unit_id", scope.UnitID, "day", day.Format(time.DateOnly), "showings", n)
+				metrics.Incr(ctx, "ShowingCapReached")
+				busy = append(busy, models.TimeRange{Start: day, End: day.AddDate(0, 0, 1)})
+			}
+		}
+	}
+	return busy
+}
+
+// generateSlots uses the fixed next-MaxDays window unless the request gave
+// a date range, keeping the default path identical to before ranges existed.
+func generateSlots(busy []models.TimeRange, req models.Request, now, from, to time.Time, rules *models.ScheduleRules, slotDuration time.Duration) ([]models.TimeSlot, int, int) {
+	if req.Mode == modeEarliest {
+		return logic.GenerateEarliestSlots(busy, now, from, to, rules, slotDuration, earliestCount(req))
+	}
+	if req.From == "" && req.To == "" && req.LookAheadDays == 0 && req.PreferredDate == "" {
+		return logic.GenerateAvailableSlots(busy, now, rules, slotDuration)
+	}
+	return logic.GenerateSlotsInRange(busy, now, from, to, rules, slotDuration)
+}
+
+// offerActions returns the voice agent's next actions after an availability
+// lookup: offer the slots, or ask for other dates when there are none
+func offerActions(req models.Request, avail models.Availability) []string {
+	actions := []string{models.NextAskDatePreference}
+	if len(avail.Suggestions) > 0 {
+		actions = []string{models.NextOfferSlots}
+	}
+	if req.Phone == "" {
+		actions = append(actions, models.NextCollectPhone)
+	}
+	return actions
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/clients"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/config"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/logging"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/logic"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+func TestFindAvailabilitySearchHitWithoutPropertyID(t *testing.T) {
+	// A hit whose PropertyId metadata is empty: FindPropertyID returns ("", nil)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"count":1,"results":[{"metadata":{"PropertyId":""}}]}`))
+	}))
+	defer srv.Close()
+
+	cfg := config.Config{PropertySource: "appfolio"}
+	p := &pipeline{
+		cfg:        cfg,
+		search:     clients.NewSearchClient([]string{srv.URL}, 0, nil),
+		properties: newPropertySource(cfg, cfg.PropertySource),
+	}
+	result := p.findAvailability(context.Background(), "req-1", models.Request{Query: "123 Main St"}, propertyMatch{})
+	if result.Response.Success {
+		t.Fatal("Success = true, want false")
+	}
+	if len(result.Response.NextActions) != 1 || result.Response.NextActions[0] != models.NextConfirmAddress {
+		t.Errorf("NextActions = %v, want [%s]", result.Response.NextActions, models.NextConfirmAddress)
+	}
+}
+
+// calendarStub answers Google Calendar with an empty calendar, counting
+// the events created, and everything else (Supabase) with an empty list
+type calendarStub struct {
+	mu      sync.Mutex
+	created int
+}
+
+func (s *calendarStub) RoundTrip(r *http.Request) (*http.Response, error) {
+	body := `[]`
+	if r.URL.Host == "www.googleapis.com" {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/freeBusy"):
+			body = `{"calendars":{"agent@example.com":{"busy":[]}}}`
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/events"):
+			s.mu.Lock()
+			s.created++
+			s.mu.Unlock()
+			// Slow enough that the duplicate arrives while it's in flight
+			time.Sleep(50 * time.Millisecond)
+			body = `{"id":"evt-1","status":"confirmed"}`
+		}
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    r,
+	}, nil
+}
+
+func TestDuplicateBookingCreatesOneEvent(t *testing.T) {
+	stub := &calendarStub{}
+	defaultTransport := http.DefaultTransport
+	http.DefaultTransport = stub
+	defer func() { http.DefaultTransport = defaultTransport }()
+
+	cfg := config.Config{PropertySource: "appfolio", SupabaseProjectID: "test", SupabaseKey: "key"}
+	p := newPipeline(cfg)
+	loc := time.UTC
+	start := time.Now().Add(48 * time.Hour).Truncate(time.Hour)
+	slot := logic.NewTimeSlot(start, start.Add(30*time.Minute), loc)
+	result := availabilityResult{
+		Response: models.Response{
+			Success:  true,
+			Property: models.PropertyInfo{Address: "123 Main St"},
+			Agent:    models.AgentInfo{Name: "Jordan Lee", Email: "agent@example.com"},
+		},
+		PropertyID:  "prop-1",
+		AccessToken: "token",
+		Slots:       []models.TimeSlot{slot},
+		TimeZone:    loc.String(),
+	}
+	req := models.Request{Query: "123 Main St", Phone: "+15555550100", Action: actionBook, SlotStart: start.Format(time.RFC3339)}
+	ctx := logging.WithCallID(context.Background(), "call-duplicate-booking")
+
+	var wg sync.WaitGroup
+	responses := make([]models.Response, 2)
+	for i := range responses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			responses[i] = answerOnce(ctx, req, func() availabilityResult {
+				result := result
+				result.Response = p.bookShowing(ctx, "req-1", req, result)
+				return result
+			}).Response
+		}()
+	}
+	wg.Wait()
+
+	if stub.created != 1 {
+		t.Errorf("CreateEvent called %d times, want 1", stub.created)
+	}
+	for i, resp := range responses {
+		if !resp.Success || resp.Booking == nil || resp.Booking.CalendarEventID != "evt-1" {
+			t.Errorf("response %d = %+v, want the one booking", i, resp)
+		}
+	}
+}
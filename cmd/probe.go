@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/clients"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/config"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+)
+
+// appFolioProbeInterval is how often the AppFolio credentials are re-validated
+const appFolioProbeInterval = 5 * time.Minute
+
+// appFolioProbeTimeout bounds a single credentials probe
+const appFolioProbeTimeout = 10 * time.Second
+
+// errCodeAppFolioCredentials is the Response.ErrorCode for rejected AppFolio credentials
+const errCodeAppFolioCredentials = "appfolio_credentials_expired"
+
+var appFolioProbe struct {
+	mu        sync.Mutex
+	running   bool
+	done      chan struct{} // closed when the running probe finishes
+	lastRun   time.Time
+	lastError error
+}
+
+// maybeProbeAppFolio validates AppFolio credentials in the background when the
+// last probe is older than appFolioProbeInterval (always on the first
+// invocation of a container), so an expired key is reported as soon as
+// possible rather than as a generic 401 in the middle of a call. A Lambda
+// invocation waits for it with waitAppFolioProbe before returning.
+func maybeProbeAppFolio(cfg config.Config) {
+	appFolioProbe.mu.Lock()
+	if appFolioProbe.running || time.Since(appFolioProbe.lastRun) < appFolioProbeInterval {
+		appFolioProbe.mu.Unlock()
+		return
+	}
+	done := make(chan struct{})
+	appFolioProbe.running = true
+	appFolioProbe.done = done
+	appFolioProbe.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		ctx, cancel := context.WithTimeout(context.Background(), appFolioProbeTimeout)
+		defer cancel()
+		err := clients.NewAppFolioClient(cfg.AppFolioAuthHeader, cfg.AppFolioDeveloperID).CheckCredentials(ctx)
+
+		appFolioProbe.mu.Lock()
+		appFolioProbe.running = false
+		appFolioProbe.done = nil
+		appFolioProbe.lastRun = time.Now()
+		appFolioProbe.lastError = err
+		appFolioProbe.mu.Unlock()
+
+		switch {
+		case errors.Is(err, clients.ErrAppFolioCredentials):
+			slog.Error("appfolio_credentials_expired", "error", err)
+			metrics.Incr(ctx, "AppFolioCredentialsExpired", "Source", "probe")
+		case err != nil:
+			slog.Warn("appfolio_probe_failed", "error", err)
+		default:
+			slog.Info("appfolio_probe_ok")
+		}
+		metrics.Record(ctx, "AppFolioCredentialsValid", boolMetric(err == nil), metrics.None)
+	}()
+}
+
+// waitAppFolioProbe waits for a probe still running, until ctx ends. The
+// probe mostly overlaps the invocation's own work; without the wait a Lambda
+// environment frozen after the response would stall it past its timeout and
+// report a spurious failure on the next invocation.
+func waitAppFolioProbe(ctx context.Context) {
+	appFolioProbe.mu.Lock()
+	done := appFolioProbe.done
+	appFolioProbe.mu.Unlock()
+	if done == nil {
+		return
+	}
+	select {
+	case <-done:
+	case <-ctx.Done():
+		slog.WarnContext(ctx, "appfolio_probe_wait_abandoned")
+	}
+}
+
+// runAppFolioProbeLoop re-probes on a fixed interval in container mode
+func runAppFolioProbeLoop(ctx context.Context, cfg config.Config) {
+	maybeProbeAppFolio(cfg)
+	ticker := time.NewTicker(appFolioProbeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			maybeProbeAppFolio(cfg)
+		}
+	}
+}
+
+func boolMetric(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/clients"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/config"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/logic"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+// groupLookupBatch bounds the group IDs fetched per AppFolio request
+const groupLookupBatch = 50
+
+// syncProperties polls AppFolio for every property's address, zones and
+// vacancy, diffs that against the stored property snapshots, brings the
+// snapshots up to date and stores the drift as a reconciliation report.
+// Waitlisted leads of properties now vacant are then texted fresh times.
+func syncProperties(ctx context.Context, requestID string, cfg config.Config) jobResult {
+	var result jobResult
+	if cfg.PropertySource != "appfolio" {
+		result.Errors = append(result.Errors, "property sync supports the appfolio property source only")
+		return result
+	}
+	af := clients.NewAppFolioClient(cfg.AppFolioAuthHeader, cfg.AppFolioDeveloperID)
+	supa := newSupabaseClient(cfg)
+
+	current, err := currentSnapshots(ctx, af)
+	if err != nil {
+		slog.ErrorContext(ctx, "property_sync_fetch_failed", "error", err)
+		result.Errors = append(result.Errors, err.Error())
+		return result
+	}
+	stored, err := supa.ListPropertySnapshots(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "property_snapshots_fetch_failed", "error", err)
+		result.Errors = append(result.Errors, err.Error())
+		return result
+	}
+
+	drift := logic.DiffProperties(stored, current)
+	var removed []string
+	counts := make(map[string]int)
+	for _, d := range drift {
+		counts[d.Kind]++
+		if d.Kind == models.DriftRemoved {
+			removed = append(removed, d.PropertyID)
+		}
+		slog.InfoContext(ctx, "property_drift", "property_id", d.PropertyID, "kind", d.Kind, "was", d.Was, "now", d.Now)
+	}
+	for kind, n := range counts {
+		metrics.Record(ctx, "PropertyDrift", float64(n), metrics.Count, "Kind", kind)
+	}
+
+	for i := 0; i < len(current); i += feedUpsertBatchSize {
+		end := min(i+feedUpsertBatchSize, len(current))
+		if err := supa.UpsertPropertySnapshots(ctx, current[i:end]); err != nil {
+			slog.ErrorContext(ctx, "property_snapshots_upsert_failed", "error", err)
+			result.Errors = append(result.Errors, err.Error())
+			continue
+		}
+		result.Processed += end - i
+	}
+	if err := supa.DeletePropertySnapshots(ctx, removed); err != nil {
+		slog.ErrorContext(ctx, "property_snapshots_delete_failed", "error", err)
+		result.Errors = append(result.Errors, err.Error())
+	}
+
+	report := models.DriftReport{RunAt: time.Now().UTC(), Properties: len(current), Drift: drift}
+	if err := supa.SaveDriftReport(ctx, report); err != nil {
+		slog.ErrorContext(ctx, "drift_report_save_failed", "error", err)
+		result.Errors = append(result.Errors, err.Error())
+	}
+	slog.InfoContext(ctx, "property_sync_reconciled", "properties", len(current), "drift", len(drift), "by_kind", counts)
+
+	notifyVacancies(ctx, requestID, cfg, current, &result)
+	return result
+}
+
+// currentSnapshots builds a snapshot of every AppFolio property
+func currentSnapshots(ctx context.Context, af *clients.AppFolioClient) ([]models.PropertySnapshot, error) {
+	props, err := af.ListProperties(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list properties: %w", err)
+	}
+	units, err := af.ListUnits(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list units: %w", err)
+	}
+	vacant := make(map[string]bool)
+	for _, u := range units {
+		if u.Vacant {
+			vacant[u.PropertyID] = true
+		}
+	}
+
+	var groupIDs []string
+	for _, p := range props {
+		groupIDs = append(groupIDs, p.PropertyGroupIds...)
+	}
+	slices.Sort(groupIDs)
+	groupIDs = slices.Compact(groupIDs)
+	zones := make(map[string]string, len(groupIDs))
+	for batch := range slices.Chunk(groupIDs, groupLookupBatch) {
+		groups, err := af.GetPropertyGroups(ctx, batch)
+		if err != nil {
+			return nil, fmt.Errorf("property groups: %w", err)
+		}
+		for _, g := range groups {
+			zones[g.ID] = g.Name
+		}
+	}
+
+	now := time.Now().UTC()
+	snapshots := make([]models.PropertySnapshot, 0, len(props))
+	for _, p := range props {
+		snapshot := models.PropertySnapshot{
+			PropertyID: p.ID,
+			Address1:   p.Address1,
+			City:       p.City,
+			State:      p.State,
+			Zones:      []string{},
+			Vacant:     vacant[p.ID],
+			SyncedAt:   now,
+		}
+		for _, id := range p.PropertyGroupIds {
+			if name := zones[id]; name != "" {
+				snapshot.Zones = append(snapshot.Zones, name)
+			}
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, nil
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/events"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/logic"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+const (
+	actionReschedule = "reschedule"
+	// rescheduleToolName is the VAPI tool that maps to actionReschedule
+	rescheduleToolName = "reschedule_showing"
+)
+
+// rescheduleBooking moves the booking req.ConfirmationID (or Phone's one
+// upcoming showing) to req.SlotStart. Its own time counts as free when the
+// agent's availability is regenerated for the new day, and its calendar
+// event is moved rather than recreated; the record is only updated once the
+// event has moved, and the event is moved back if that fails.
+func (p *pipeline) rescheduleBooking(ctx context.Context, requestID string, req models.Request) models.Response {
+	p = p.forTenant(req.TenantID)
+	booking, resp := p.bookingFor(ctx, req, actionReschedule)
+	if booking == nil {
+		return resp
+	}
+	fail := models.Response{
+		Success:      false,
+		Message:      "Failed to reschedule showing.",
+		FormattedMsg: "I couldn't move your showing right now. It's still on as booked, and a team member will follow up with you.",
+		NextActions:  []string{models.NextTransferToHuman},
+		Booking:      bookingConfirmation(*booking),
+	}
+	if booking.EventID == "" {
+		// Self-guided tours have a lock code for their window, not an event
+		slog.InfoContext(ctx, "reschedule_unsupported", "booking_id", booking.ID, "channel", booking.Channel)
+		return fail
+	}
+
+	loc := logic.Location(booking.TimeZone)
+	start, err := parseCallbackTime(req.SlotStart, loc)
+	if err != nil {
+		slog.WarnContext(ctx, "reschedule_time_invalid", "slot_start", req.SlotStart, "error", err)
+		return models.Response{
+			Success:      false,
+			Message:      "Invalid SlotStart.",
+			FormattedMsg: "I didn't catch the new time. What day and time would work for you?",
+			NextActions:  []string{models.NextAskDatePreference},
+			Booking:      bookingConfirmation(*booking),
+		}
+	}
+	end := start.Add(booking.End.Sub(booking.Start))
+	if start.Equal(booking.Start) {
+		confirmation := bookingConfirmation(*booking)
+		return models.Response{
+			Success:      true,
+			Message:      "Showing already at SlotStart.",
+			FormattedMsg: fmt.Sprintf("Your showing at %s is already on %s at %s.", booking.PropertyAddress, confirmation.Date, confirmation.Time),
+			Booking:      confirmation,
+		}
+	}
+
+	token, err := p.supabase.GetAccessToken(ctx, booking.AgentEmail)
+	if err != nil {
+		slog.ErrorContext(ctx, "token_fetch_failed", "email", booking.AgentEmail, "error", err)
+		return fail
+	}
+
+	// Regenerate the new day's slots with the showing's current time freed
+	rules := p.cfg.ScheduleRulesFor(req.TenantID)
+	now := time.Now()
+	local := start.In(logic.ScheduleLocation(rules))
+	dayStart := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+	dayEnd := dayStart.AddDate(0, 0, 1)
+	if p.cfg.AgentWorkingHours {
+		rules = p.agentWorkingHours(ctx, token, booking.AgentEmail, rules, dayStart, dayEnd)
+	}
+	busy, err := p.busySlots(ctx, token, busyScope{
+		Email:      booking.AgentEmail,
+		Holder:     slotHolder(ctx, booking.Phone),
+		PropertyID: booking.PropertyID,
+		UnitID:     booking.UnitID,
+		Settings:   p.propertySettings(ctx, booking.PropertyID),
+		Loc:        loc,
+		Moving:     booking,
+	}, dayStart, dayEnd)
+	if err != nil {
+		calendarFetchFailed(ctx, booking.AgentEmail, err)
+		return fail
+	}
+	slots, _, _ := logic.GenerateSlotsInRange(busy, now, dayStart, dayEnd, rules, end.Sub(start))
+	var slot *models.TimeSlot
+	for i := range slots {
+		if slots[i].Start.Equal(start) {
+			slot = &slots[i]
+			break
+		}
+	}
+	if slot == nil {
+		slog.InfoContext(ctx, "reschedule_slot_unavailable", "booking_id", booking.ID, "slot_start", req.SlotStart, "open_that_day", len(slots))
+		metrics.Incr(ctx, "BookingSlotUnavailable")
+		resp := models.Response{
+			Success:      false,
+			Message:      "Requested time is not available.",
+			FormattedMsg: "Sorry, that day is fully booked. Is there another day that works for you?",
+			NextActions:  []string{models.NextAskDatePreference},
+			Booking:      bookingConfirmation(*booking),
+		}
+		if len(slots) > 0 {
+			offered := make([]models.TimeSlot, 0, logic.SuggestionCount)
+			for _, s := range slots[:min(len(slots), logic.SuggestionCount)] {
+				offered = append(offered, logic.NewTimeSlot(s.Start, s.End, loc))
+			}
+			resp.Availability.Suggestions = offered
+			resp.FormattedMsg = "Sorry, that time isn't available. That day I have"
+			for i, s := range offered {
+				if i > 0 {
+					resp.FormattedMsg += ","
+				}
+				resp.FormattedMsg += " " + s.Time
+			}
+			resp.FormattedMsg += ". Would one of those work?"
+			resp.NextActions = []string{models.NextOfferSlots}
+		}
+		return resp
+	}
+
+	// Move the event first: the agent's calendar is what blocks the slot
+	zone := loc.String()
+	moveTo := func(from, to time.Time) error {
+		return p.calendar.PatchEvent(ctx, token, booking.AgentEmail, booking.EventID, models.CalendarEvent{
+			Start: &models.CalendarEventTime{DateTime: from.In(loc).Format(time.RFC3339), TimeZone: zone},
+			End:   &models.CalendarEventTime{DateTime: to.In(loc).Format(time.RFC3339), TimeZone: zone},
+		})
+	}
+	if err := moveTo(slot.Start, slot.End); err != nil {
+		slog.ErrorContext(ctx, "calendar_event_move_failed", "booking_id", booking.ID, "event_id", booking.EventID, "error", err)
+		return fail
+	}
+	previous := *booking
+	updated, err := p.lifecycle.Reschedule(ctx, booking.ID, slot.Start.UTC(), slot.End.UTC())
+	if err != nil {
+		slog.ErrorContext(ctx, "booking_reschedule_failed", "booking_id", booking.ID, "error", err)
+		if err := moveTo(previous.Start, previous.End); err != nil {
+			// The event and the record now disagree until someone fixes one
+			slog.ErrorContext(ctx, "booking_reschedule_inconsistent", "booking_id", booking.ID, "event_id", booking.EventID, "error", err)
+			metrics.Incr(ctx, "BookingRescheduleInconsistent")
+		}
+		return fail
+	}
+
+	// An SMS booking's session shows its time in reminders and replies
+	if updated.Channel == "sms" {
+		if session, err := p.supabase.GetSMSSession(ctx, updated.Phone); err == nil && session != nil && session.BookingID == updated.ID {
+			session.BookedStart, session.BookedEnd = &updated.Start, &updated.End
+			if err := p.supabase.SaveSMSSession(ctx, *session); err != nil {
+				slog.WarnContext(ctx, "sms_session_save_failed", "error", err)
+			}
+		}
+	}
+
+	confirmation := bookingConfirmation(updated)
+	channel := requestChannel(ctx)
+	slog.InfoContext(ctx, "showing_rescheduled", "booking_id", updated.ID, "event_id", updated.EventID, "from", previous.Start, "to", updated.Start, "channel", channel)
+	metrics.Incr(ctx, "ShowingRescheduled", "Channel", channel)
+	events.Emit(ctx, events.Event{
+		Type:       events.TypeReschedule,
+		PropertyID: updated.PropertyID,
+		Phone:      updated.Phone,
+		Data:       events.ShowingRescheduled{Channel: channel, PreviousStart: previous.Start, Start: updated.Start, End: updated.End, CalendarEventID: updated.EventID},
+	})
+	text := fmt.Sprintf(":calendar: Showing rescheduled via %s: %s moved from %s to %s with %s (prospect %s).",
+		channel, updated.PropertyAddress, previous.Start.In(loc).Format("Mon, Jan 2 at 3:04 PM"), updated.Start.In(loc).Format("Mon, Jan 2 at 3:04 PM"),
+		updated.AgentName, updated.Phone)
+	agent := p.bookingAgent(ctx, updated)
+	p.notifyTeam(ctx, requestID, agent.Zone, text)
+
+	return models.Response{
+		Success: true,
+		Message: "Showing rescheduled.",
+		FormattedMsg: fmt.Sprintf("Done! Your showing at %s is now on %s at %s with %s. Your confirmation number is still %s.",
+			updated.PropertyAddress, confirmation.Date, confirmation.Time, updated.AgentName, confirmation.ConfirmationID),
+		Booking: confirmation,
+	}
+}
+
+// bookingAgent is the roster entry for booking's agent, whose zone routes
+// team notifications. Bookings don't keep the zone, so an agent missing
+// from the roster (or a roster that can't be read) has none and goes to the
+// default channel.
+func (p *pipeline) bookingAgent(ctx context.Context, booking models.Booking) models.AgentInfo {
+	agent := models.AgentInfo{Name: booking.AgentName, Email: booking.AgentEmail}
+	agents, err := p.supabase.ListAgents(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "agent_roster_fetch_failed", "error", err)
+		return agent
+	}
+	for _, a := range agents {
+		if strings.EqualFold(a.Email, booking.AgentEmail) {
+			return a
+		}
+	}
+	return agent
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/events"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/logic"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/usage"
+)
+
+// accessCodeGrace is how long before and after the booked slot a
+// self-guided access code works, so early or slow visitors aren't locked out.
+const accessCodeGrace = 15 * time.Minute
+
+// propertySettings returns the property's scheduling settings, falling back
+// to defaults when they can't be read.
+func (p *pipeline) propertySettings(ctx context.Context, propID string) models.PropertySettings {
+	settings, err := p.supabase.GetPropertySettings(ctx, propID)
+	if err != nil {
+		slog.WarnContext(ctx, "property_settings_fetch_failed", "property_id", propID, "error", err)
+		return models.PropertySettings{PropertyID: propID}
+	}
+	return settings
+}
+
+// selfGuidedAvailability offers every slot in the showing window for a
+// self-guided property; there is no agent calendar to check.
+func (p *pipeline) selfGuidedAvailability(ctx context.Context, req models.Request, propID string, prop propertyRecord, settings models.PropertySettings, provenance string) availabilityResult {
+	rules := p.cfg.ScheduleRulesFor(req.TenantID)
+	loc := logic.ScheduleLocation(rules)
+	now := time.Now().In(loc)
+	from, to, err := requestRange(req, now)
+	if err != nil {
+		slog.WarnContext(ctx, "date_range_invalid", "from", req.From, "to", req.To, "error", err)
+		from, to = now, now.AddDate(0, 0, logic.MaxDays)
+		req.From, req.To, req.PreferredDate = "", "", ""
+	}
+	availableSlots, daysChecked, _ := generateSlots(nil, req, now, from, to, rules, logic.TourDuration(settings.TourMinutes))
+
+	avail := models.Availability{
+		TotalSlotsAvailable: len(availableSlots),
+		DaysChecked:         daysChecked,
+	}
+	offer, preferredNote := offerPreferredDate(req, loc, availableSlots, &avail)
+	avail.Suggestions = limitSlots(offer, logic.SuggestionCount)
+	pageSlots(&avail, offer, req.Page, req.PageSize)
+
+	formattedMsg := formatSelfGuidedMessage(prop.info(), avail)
+	if preferredNote != "" {
+		formattedMsg = preferredNote + "\n\n" + formattedMsg
+	}
+
+	events.Emit(ctx, events.Event{
+		Type:       events.TypeMatch,
+		TenantID:   req.TenantID,
+		PropertyID: propID,
+		Phone:      req.Phone,
+		Data:       events.PropertyMatched{Provenance: provenance, SelfGuided: true},
+	})
+	emitOffer(ctx, req, propID, avail)
+
+	slog.InfoContext(ctx, "scheduling_success",
+		"property_id", propID,
+		"self_guided", true,
+		"provenance", provenance,
+		"slots_available", len(availableSlots),
+		"days_checked", daysChecked,
+	)
+
+	return availabilityResult{
+		PropertyID: propID,
+		Slots:      availableSlots,
+		LockID:     settings.LockID,
+		TimeZone:   loc.String(),
+		Response: models.Response{
+			Success:      true,
+			Property:     prop.info(),
+			Availability: avail,
+			Provenance:   provenance,
+			SelfGuided:   true,
+			Message:      "Success",
+			FormattedMsg: formattedMsg,
+			NextActions:  offerActions(req, avail),
+		},
+	}
+}
+
+// bookSelfGuided issues a smart-lock code covering the chosen slot and
+// returns the confirmation text containing it. Leads must have verified
+// their ID first (when verification is configured).
+func (p *pipeline) bookSelfGuided(ctx context.Context, requestID, phone string, session *models.SMSSession, choice int) string {
+	slot := session.OfferedSlots[choice-1]
+	if p.locks == nil || session.LockID == "" {
+		slog.ErrorContext(ctx, "self_guided_unavailable", "property_id", session.PropertyID)
+		return fmt.Sprintf("Self-guided showings at %s aren't available right now. Please try again later.", session.PropertyAddress)
+	}
+	if reply, verified := p.requireIDVerification(ctx, phone, choice); !verified {
+		return reply
+	}
+
+	code, err := p.locks.CreateAccessCode(ctx, session.LockID, "Showing "+phone, slot.Start.Add(-accessCodeGrace), slot.End.Add(accessCodeGrace))
+	if err != nil {
+		slog.ErrorContext(ctx, "access_code_create_failed", "property_id", session.PropertyID, "error", err)
+		metrics.Incr(ctx, "AccessCodeFailed")
+		return "I couldn't set up your access code for that time. Please try again in a minute."
+	}
+
+	meterUsage(ctx, p.cfg, "", usage.Bookings, 1)
+	session.AccessCodeID = code.ID
+	session.BookedStart = &slot.Start
+	session.BookedEnd = &slot.End
+	session.BookingID = p.recordBooking(ctx, smsBooking(session, models.BookingBooked)).ID
+	if err := p.supabase.SaveSMSSession(ctx, *session); err != nil {
+		// The code exists; losing the session only means "C" can't revoke it.
+		slog.ErrorContext(ctx, "sms_session_save_failed", "access_code_id", code.ID, "error", err)
+	}
+
+	slog.InfoContext(ctx, "sms_showing_booked", "property_id", session.PropertyID, "self_guided", true, "access_code_id", code.ID)
+	metrics.Incr(ctx, "ShowingBooked", "Channel", "sms")
+	events.Emit(ctx, events.Event{
+		Type:       events.TypeBooking,
+		PropertyID: session.PropertyID,
+		Phone:      phone,
+		Data:       events.ShowingBooked{Channel: "sms", Start: slot.Start, End: slot.End, SelfGuided: true, AccessCodeID: code.ID, Source: session.Source},
+	})
+	metrics.Incr(ctx, "AccessCodeIssued")
+	p.notifyTeam(ctx, requestID, session.AgentZone, fmt.Sprintf(":key: Self-guided showing booked via SMS: %s on %s (prospect %s).",
+		session.PropertyAddress, showingTime(slot.Start, session.TimeZone), phone)+p.leadTagsLine(ctx, phone))
+
+	loc := logic.Location(session.TimeZone)
+	return fmt.Sprintf("You're booked! Self-guided showing at %s on %s. Your lock code is %s; it works from %s to %s. Reply C to cancel.",
+		session.PropertyAddress, showingTime(slot.Start, session.TimeZone), code.Code,
+		slot.Start.Add(-accessCodeGrace).In(loc).Format("3:04 PM"), slot.End.Add(accessCodeGrace).In(loc).Format("3:04 PM"))
+}
+
+func formatSelfGuidedMessage(prop models.PropertyInfo, avail models.Availability) string {
+	msg := fmt.Sprintf("🏠 PROPERTY: %s\n📍 %s, %s, %s\n\n", prop.Name, prop.Address, prop.City, prop.State)
+	msg += "🔑 SELF-GUIDED TOUR: no agent needed. An access code is sent when you book.\n\n"
+
+	if len(avail.Suggestions) == 0 {
+		msg += fmt.Sprintf("📅 No self-guided showing times are open in the next %d days.", avail.DaysChecked)
+		return msg
+	}
+
+	msg += "📅 NEXT AVAILABLE TIMES:\n"
+	for _, slot := range avail.Suggestions {
+		msg += fmt.Sprintf("  • %s at %s\n", slot.Date, slot.Time)
+	}
+	return msg
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/clients"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/config"
+)
+
+// runContainer serves HandleRequest over plain HTTP for long-running
+// (ECS/Fargate or local) deployments. Each request is wrapped in a
+// Function URL-shaped event so the handler's envelope parsing applies
+// unchanged. It also keeps caches fresh via Supabase Realtime.
+func runContainer(cfg config.Config) {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	go listenForInvalidations(ctx, cfg)
+	if cfg.PropertySource == "appfolio" {
+		go runAppFolioProbeLoop(ctx, cfg)
+	}
+
+	srv := &http.Server{
+		Addr:              cfg.HTTPListenAddr,
+		Handler:           http.HandlerFunc(serveHTTP),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	slog.Info("http_server_starting", "addr", cfg.HTTPListenAddr)
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		slog.Error("http_server_failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+func serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/healthz" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 6<<20))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	headers := make(map[string]string, len(r.Header))
+	for k := range r.Header {
+		headers[strings.ToLower(k)] = r.Header.Get(k)
+	}
+	event, _ := json.Marshal(map[string]interface{}{
+		"rawPath":        r.URL.Path,
+		"rawQueryString": r.URL.RawQuery,
+		"headers":        headers,
+		"body":           string(body),
+		"requestContext": map[string]interface{}{
+			"http": map[string]string{"method": r.Method, "path": r.URL.Path},
+		},
+	})
+
+	requestID := r.Header.Get("X-Request-Id")
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+	ctx := lambdacontext.NewContext(r.Context(), &lambdacontext.LambdaContext{AwsRequestID: requestID})
+
+	resp, err := HandleRequest(ctx, event)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for k, v := range resp.Headers {
+		w.Header().Set(k, v)
+	}
+	w.Header().Set("X-Request-Id", requestID)
+	w.WriteHeader(resp.StatusCode)
+	if resp.IsBase64Encoded {
+		raw, _ := base64.StdEncoding.DecodeString(resp.Body)
+		w.Write(raw)
+		return
+	}
+	io.WriteString(w, resp.Body)
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// listenForInvalidations subscribes to oauth_tokens, agents and
+// property_agent_overrides changes so an agent re-authorizing or being
+// reassigned takes effect immediately instead of after the cache TTL.
+// Reconnects with capped backoff until ctx ends.
+func listenForInvalidations(ctx context.Context, cfg config.Config) {
+	rt := clients.NewSupabaseRealtime(cfg.SupabaseProjectID, cfg.SupabaseKey)
+	backoff := time.Second
+
+	for ctx.Err() == nil {
+		start := time.Now()
+		err := rt.Listen(ctx, []string{"oauth_tokens", "agents", "property_agent_overrides"}, handleRealtimeChange)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(start) > time.Minute {
+			backoff = time.Second
+		}
+		slog.Warn("realtime_disconnected", "error", err, "retry_in", backoff.String())
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff < time.Minute {
+			backoff *= 2
+		}
+	}
+}
+
+func handleRealtimeChange(change clients.RealtimeChange) {
+	switch change.Table {
+	case "oauth_tokens":
+		for _, rec := range []map[string]interface{}{change.Record, change.OldRecord} {
+			if email, ok := rec["email"].(string); ok && email != "" {
+				clients.InvalidateAccessToken(email)
+				slog.Info("token_cache_invalidated", "email", email, "change", change.Type)
+			}
+		}
+	case "agents":
+		clients.InvalidateAgentRoster()
+		slog.Info("agent_roster_invalidated", "change", change.Type)
+	case "property_agent_overrides":
+		clients.InvalidatePropertyAgentOverrides()
+		slog.Info("property_agent_overrides_invalidated", "change", change.Type)
+	}
+}
@@ -0,0 +1,422 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/clients"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/config"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/events"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/logic"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/usage"
+)
+
+// smsOfferCount is how many numbered slots are offered in a single text
+const smsOfferCount = 3
+
+// extractHTTP returns the raw body of an HTTP-sourced event (Function URL /
+// API Gateway), along with its lower-cased headers.
+func extractHTTP(event json.RawMessage) ([]byte, map[string]string, bool) {
+	var envelope struct {
+		Body            string            `json:"body"`
+		IsBase64Encoded bool              `json:"isBase64Encoded"`
+		Headers         map[string]string `json:"headers"`
+	}
+	if err := json.Unmarshal(event, &envelope); err != nil || envelope.Body == "" {
+		return nil, nil, false
+	}
+
+	headers := make(map[string]string, len(envelope.Headers))
+	for k, v := range envelope.Headers {
+		headers[strings.ToLower(k)] = v
+	}
+
+	body := []byte(envelope.Body)
+	if envelope.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(envelope.Body)
+		if err != nil {
+			return nil, nil, false
+		}
+		body = decoded
+	}
+	return body, headers, true
+}
+
+// extractForm returns the form-encoded body of an HTTP-sourced event,
+// along with its lower-cased headers.
+func extractForm(event json.RawMessage) (url.Values, map[string]string, bool) {
+	body, headers, ok := extractHTTP(event)
+	if !ok || !strings.HasPrefix(headers["content-type"], "application/x-www-form-urlencoded") {
+		return nil, nil, false
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, nil, false
+	}
+	return form, headers, true
+}
+
+// handleInboundSMS drives the conversational booking flow for a Twilio message:
+//   - a property address/query replies with numbered showing times
+//   - "1", "2", ... books the corresponding offered time
+//   - "YES" confirms a tentative booking (when BookingConfirmWindow is set)
+//   - "C" cancels the booked showing (or the pending offer)
+func handleInboundSMS(ctx context.Context, requestID string, cfg config.Config, sms clients.InboundSMS, form url.Values, headers map[string]string) LambdaResponse {
+	slog.InfoContext(ctx, "event_type_detected", "type", "twilio_sms")
+
+	// Texts book and cancel showings for the sending number, so one that
+	// can't be shown to come from Twilio is refused
+	if cfg.TwilioAuthToken == "" || cfg.TwilioWebhookURL == "" {
+		slog.ErrorContext(ctx, "twilio_signature_unconfigured", "message_sid", sms.MessageSID)
+		return errorResponse(403, "Signature validation is not configured")
+	}
+	if !clients.ValidateTwilioSignature(cfg.TwilioAuthToken, cfg.TwilioWebhookURL, headers["x-twilio-signature"], form) {
+		slog.WarnContext(ctx, "twilio_signature_invalid", "message_sid", sms.MessageSID)
+		return errorResponse(403, "Invalid signature")
+	}
+
+	p := pipelineFor(cfg)
+	text := strings.TrimSpace(sms.Body)
+
+	// Consent keywords are acknowledged by Twilio itself, so no reply here.
+	// Any other text is an opt-in to replies about the prospect's inquiry.
+	switch {
+	case isKeyword(text, optOutKeywords):
+		p.recordSMSOptOut(ctx, sms.From)
+		return twimlAck()
+	case isKeyword(text, optInKeywords):
+		p.recordSMSConsent(ctx, sms.From, models.ConsentSMSOptIn, true)
+		return twimlAck()
+	}
+	p.recordSMSConsent(ctx, sms.From, models.ConsentSMSOptIn, false)
+
+	// Numbers on a do-not-contact list get no reply; a cancellation is
+	// still honored so their showing doesn't stay booked
+	if !mayContact(ctx, p.supabase, "", sms.From, "sms_reply") {
+		if strings.EqualFold(text, "C") {
+			p.cancelSMS(ctx, sms.From)
+		}
+		return twimlAck()
+	}
+
+	// The SMS number isn't tenant-specific, so replies carry the default
+	// branding and count against the default tenant. Over quota, nothing is
+	// done: booking or cancelling without being able to say so would leave
+	// the prospect guessing.
+	if err := checkUsage(ctx, cfg, "", usage.SMSSends); err != nil {
+		return twimlAck()
+	}
+
+	var reply string
+	if strings.EqualFold(text, "C") {
+		reply = p.cancelSMS(ctx, sms.From)
+	} else if strings.EqualFold(text, "YES") || strings.EqualFold(text, "Y") {
+		reply = p.confirmSMS(ctx, requestID, sms.From)
+	} else if choice, err := strconv.Atoi(text); err == nil {
+		reply = p.bookSMSChoice(ctx, requestID, sms.From, choice)
+	} else {
+		reply = p.offerSMSSlots(ctx, requestID, sms.From, text, smsSource(sms))
+	}
+
+	// The reply goes out even if a concurrent text just used the last of the
+	// quota: whatever it answers has already been done
+	meterUsage(ctx, cfg, "", usage.SMSSends, 1)
+	reply = brandText(reply, cfg.BrandingFor(""))
+	slog.InfoContext(ctx, "sms_reply_sent", "message_sid", sms.MessageSID, "reply_length", len(reply))
+	return twimlResponse(reply)
+}
+
+// showingTime formats a showing's start for a text, in zone (the
+// property's, as kept on the session)
+func showingTime(t time.Time, zone string) string {
+	return t.In(logic.Location(zone)).Format("Mon, Jan 2 at 3:04 PM")
+}
+
+// offerSMSSlots runs the availability pipeline for a texted query and
+// remembers the offered slots so a numeric reply can book one.
+func (p *pipeline) offerSMSSlots(ctx context.Context, requestID, phone, query, source string) string {
+	if query == "" {
+		return "Text the address of the property you'd like to see and I'll send you showing times."
+	}
+
+	existing, err := p.supabase.GetSMSSession(ctx, phone)
+	if err != nil {
+		slog.WarnContext(ctx, "sms_session_fetch_failed", "error", err)
+	}
+	if existing != nil && existing.Booked() {
+		return fmt.Sprintf("You already have a showing booked at %s on %s. Reply C to cancel it first.",
+			existing.PropertyAddress, showingTime(*existing.BookedStart, existing.TimeZone))
+	}
+
+	p.recordLeadContext(ctx, phone, source, nil)
+	result := p.findAvailability(ctx, requestID, models.Request{Query: query, Phone: phone, Source: source}, propertyMatch{})
+	return p.offerAvailability(ctx, phone, result)
+}
+
+// offerAvailability texts up to smsOfferCount of result's slots and saves
+// them on the prospect's session so a numeric reply can book one.
+func (p *pipeline) offerAvailability(ctx context.Context, phone string, result availabilityResult) string {
+	resp := result.Response
+	if !resp.Success {
+		return resp.FormattedMsg
+	}
+	if len(result.Slots) == 0 && !resp.SelfGuided {
+		return fmt.Sprintf("%s has no open showing times at %s in the next %d days. Please email %s to schedule.",
+			resp.Agent.Name, resp.Property.Address, resp.Availability.DaysChecked, resp.Agent.Email)
+	}
+
+	offered := resp.Availability.Suggestions
+	if len(offered) == 0 {
+		offered = limitSlots(result.Slots, smsOfferCount)
+	}
+	offered = limitSlots(offered, smsOfferCount)
+
+	session := models.SMSSession{
+		Phone:           phone,
+		TenantID:        result.TenantID,
+		PropertyID:      result.PropertyID,
+		UnitID:          resp.Property.UnitID,
+		PropertyAddress: resp.Property.Address,
+		AgentName:       resp.Agent.Name,
+		AgentEmail:      resp.Agent.Email,
+		AgentZone:       resp.Agent.Zone,
+		OfferedSlots:    offered,
+		SelfGuided:      resp.SelfGuided,
+		LockID:          result.LockID,
+		TimeZone:        result.TimeZone,
+		Source:          result.Source,
+	}
+	if err := p.supabase.SaveSMSSession(ctx, session); err != nil {
+		slog.ErrorContext(ctx, "sms_session_save_failed", "error", err)
+		return fmt.Sprintf("I found times at %s but couldn't hold them for you. Please email %s at %s to schedule.",
+			resp.Property.Address, resp.Agent.Name, resp.Agent.Email)
+	}
+
+	if len(offered) == 0 {
+		return fmt.Sprintf("There are no open self-guided showing times at %s right now. Please try again later.", resp.Property.Address)
+	}
+
+	var sb strings.Builder
+	if resp.SelfGuided {
+		fmt.Fprintf(&sb, "Self-guided showing times for %s:\n", resp.Property.Address)
+	} else {
+		fmt.Fprintf(&sb, "Showing times for %s with %s%s:\n", resp.Property.Address, resp.Agent.Name, coverageNote(resp.Agent))
+	}
+	for i, slot := range offered {
+		fmt.Fprintf(&sb, "%d) %s\n", i+1, showingTime(slot.Start, session.TimeZone))
+	}
+	fmt.Fprintf(&sb, "Reply %s to book, or C to cancel.", choiceRange(len(offered)))
+	return sb.String()
+}
+
+// bookSMSChoice books the offered slot selected by a numeric reply after
+// re-checking the agent's calendar for conflicts.
+func (p *pipeline) bookSMSChoice(ctx context.Context, requestID, phone string, choice int) string {
+	session, err := p.supabase.GetSMSSession(ctx, phone)
+	if err != nil {
+		slog.ErrorContext(ctx, "sms_session_fetch_failed", "error", err)
+		return "Sorry, I couldn't look up your showing times right now. Please try again in a minute."
+	}
+	if session == nil || len(session.OfferedSlots) == 0 {
+		return "I don't have any showing times on file for you. Text the property address to get started."
+	}
+	if session.Booked() {
+		return fmt.Sprintf("Your showing at %s is already booked for %s. Reply C to cancel.",
+			session.PropertyAddress, showingTime(*session.BookedStart, session.TimeZone))
+	}
+	if choice < 1 || choice > len(session.OfferedSlots) {
+		return fmt.Sprintf("Please reply %s to pick a time, or C to cancel.", choiceRange(len(session.OfferedSlots)))
+	}
+
+	slot := session.OfferedSlots[choice-1]
+	if slot.Start.Before(time.Now()) {
+		return "That time has already passed. Text the address again for fresh showing times."
+	}
+	if err := checkUsage(ctx, p.cfg, "", usage.Bookings); err != nil {
+		return "Sorry, online booking isn't available right now. Please try again later."
+	}
+	if session.SelfGuided {
+		return p.bookSelfGuided(ctx, requestID, phone, session, choice)
+	}
+
+	token, err := p.supabase.GetAccessToken(ctx, session.AgentEmail)
+	if err != nil {
+		slog.ErrorContext(ctx, "token_fetch_failed", "email", session.AgentEmail, "error", err)
+		return fmt.Sprintf("I couldn't reach %s's calendar to book that time. Please email them at %s.", session.AgentName, session.AgentEmail)
+	}
+
+	holder := slotHolder(ctx, phone)
+	busy, err := p.busySlots(ctx, token, busyScope{
+		Email:      session.AgentEmail,
+		Holder:     holder,
+		PropertyID: session.PropertyID,
+		UnitID:     session.UnitID,
+		Settings:   p.propertySettings(ctx, session.PropertyID),
+		Loc:        logic.Location(session.TimeZone),
+	}, slot.Start, slot.End)
+	if err != nil {
+		calendarFetchFailed(ctx, session.AgentEmail, err)
+		return fmt.Sprintf("I couldn't confirm %s's availability right now. Please try again in a minute.", session.AgentName)
+	}
+	if logic.IsBusy(slot.Start, slot.End, busy) {
+		slog.InfoContext(ctx, "sms_slot_taken", "start", slot.Start)
+		return "Sorry, that time was just taken. Text the address again for updated showing times."
+	}
+
+	tentative := p.cfg.BookingConfirmWindow > 0
+	tz := logic.Location(session.TimeZone).String()
+	event := models.CalendarEvent{
+		Summary:     showingSummaryPrefix + session.PropertyAddress,
+		Description: fmt.Sprintf("Booked via SMS\nProspect phone: %s\nProperty ID: %s", phone, session.PropertyID),
+		Location:    session.PropertyAddress,
+		Start:       &models.CalendarEventTime{DateTime: slot.Start.Format(time.RFC3339), TimeZone: tz},
+		End:         &models.CalendarEventTime{DateTime: slot.End.Format(time.RFC3339), TimeZone: tz},
+	}
+	if tentative {
+		event.Status = models.EventTentative
+	}
+	created, err := p.calendar.CreateEvent(ctx, token, session.AgentEmail, event)
+	if err != nil {
+		slog.ErrorContext(ctx, "calendar_event_create_failed", "error", err)
+		return fmt.Sprintf("I couldn't book that time. Please email %s at %s to schedule.", session.AgentName, session.AgentEmail)
+	}
+
+	meterUsage(ctx, p.cfg, "", usage.Bookings, 1)
+	p.releaseSlotHold(ctx, holder, session.AgentEmail, slot.Start)
+	session.EventID = created.ID
+	session.BookedStart = &slot.Start
+	session.BookedEnd = &slot.End
+	if tentative {
+		confirmBy := time.Now().Add(p.cfg.BookingConfirmWindow).UTC()
+		session.ConfirmBy = &confirmBy
+	}
+	status := models.BookingBooked
+	if tentative {
+		status = models.BookingHeld
+	}
+	session.BookingID = p.recordBooking(ctx, smsBooking(session, status)).ID
+	if err := p.supabase.SaveSMSSession(ctx, *session); err != nil {
+		// The event exists; losing the session only means "C" can't find it.
+		slog.ErrorContext(ctx, "sms_session_save_failed", "event_id", created.ID, "error", err)
+	}
+
+	if tentative {
+		slog.InfoContext(ctx, "sms_showing_held", "property_id", session.PropertyID, "agent", session.AgentName, "event_id", created.ID)
+		metrics.Incr(ctx, "BookingHeld")
+		return fmt.Sprintf("I'm holding %s on %s with %s for you. Reply YES within %s to confirm, or C to cancel.",
+			session.PropertyAddress, showingTime(slot.Start, session.TimeZone), session.AgentName, formatWindow(p.cfg.BookingConfirmWindow))
+	}
+	p.announceSMSBooking(ctx, requestID, phone, session)
+	return fmt.Sprintf("You're booked! Showing at %s on %s with %s. Reply C to cancel.",
+		session.PropertyAddress, showingTime(slot.Start, session.TimeZone), session.AgentName)
+}
+
+// announceSMSBooking records a confirmed SMS booking and tells the team
+func (p *pipeline) announceSMSBooking(ctx context.Context, requestID, phone string, session *models.SMSSession) {
+	slog.InfoContext(ctx, "sms_showing_booked", "property_id", session.PropertyID, "agent", session.AgentName, "event_id", session.EventID)
+	metrics.Incr(ctx, "ShowingBooked", "Channel", "sms")
+	events.Emit(ctx, events.Event{
+		Type:       events.TypeBooking,
+		PropertyID: session.PropertyID,
+		Phone:      phone,
+		Data:       events.ShowingBooked{Channel: "sms", Start: *session.BookedStart, End: *session.BookedEnd, Agent: session.AgentEmail, CalendarEventID: session.EventID, Source: session.Source},
+	})
+	text := fmt.Sprintf(":calendar: Showing booked via SMS: %s on %s with %s (prospect %s).",
+		session.PropertyAddress, showingTime(*session.BookedStart, session.TimeZone), session.AgentName, phone)
+	text += p.leadTagsLine(ctx, phone)
+	if p.approvalEnabled() {
+		text += fmt.Sprintf("\n%s: <%s|Accept> or <%s|Decline>", session.AgentName,
+			bookingResponseLink(p.cfg, agentAccepted, phone, session.EventID), bookingResponseLink(p.cfg, agentDeclined, phone, session.EventID))
+	}
+	p.notifyTeam(ctx, requestID, session.AgentZone, text)
+}
+
+// cancelSMS cancels the booked showing for phone, or clears a pending offer
+func (p *pipeline) cancelSMS(ctx context.Context, phone string) string {
+	session, err := p.supabase.GetSMSSession(ctx, phone)
+	if err != nil {
+		slog.ErrorContext(ctx, "sms_session_fetch_failed", "error", err)
+		return "Sorry, I couldn't look up your showing right now. Please try again in a minute."
+	}
+	if session == nil {
+		return "You don't have a showing with us. Text a property address any time to get showing times."
+	}
+
+	if session.AccessCodeID != "" && p.locks != nil {
+		if err := p.locks.RevokeAccessCode(ctx, session.LockID, session.AccessCodeID); err != nil {
+			slog.ErrorContext(ctx, "access_code_revoke_failed", "access_code_id", session.AccessCodeID, "error", err)
+			return "I couldn't cancel your showing right now. Please try again in a minute."
+		}
+	}
+	if session.EventID != "" {
+		token, err := p.supabase.GetAccessToken(ctx, session.AgentEmail)
+		if err == nil {
+			err = p.calendar.DeleteEvent(ctx, token, session.AgentEmail, session.EventID)
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "calendar_event_delete_failed", "event_id", session.EventID, "error", err)
+			return fmt.Sprintf("I couldn't cancel your showing right now. Please email %s at %s.", session.AgentName, session.AgentEmail)
+		}
+	}
+
+	if err := p.supabase.DeleteSMSSession(ctx, phone); err != nil {
+		slog.WarnContext(ctx, "sms_session_delete_failed", "error", err)
+	}
+
+	if session.Booked() {
+		p.transitionBooking(ctx, session.BookingID, models.BookingCancelled, cancelByProspect)
+		slog.InfoContext(ctx, "sms_showing_cancelled", "event_id", session.EventID, "access_code_id", session.AccessCodeID)
+		events.Emit(ctx, events.Event{
+			Type:       events.TypeCancellation,
+			PropertyID: session.PropertyID,
+			Phone:      phone,
+			Data:       events.ShowingCancelled{Channel: "sms", Start: session.BookedStart, CalendarEventID: session.EventID, AccessCodeID: session.AccessCodeID},
+		})
+		return fmt.Sprintf("Your showing at %s on %s has been cancelled.",
+			session.PropertyAddress, showingTime(*session.BookedStart, session.TimeZone))
+	}
+	return "OK, I've cleared those times. Text a property address any time to get new showing times."
+}
+
+func choiceRange(n int) string {
+	if n <= 1 {
+		return "1"
+	}
+	return fmt.Sprintf("1-%d", n)
+}
+
+// twimlAck acknowledges an inbound text without replying
+func twimlAck() LambdaResponse {
+	return LambdaResponse{
+		StatusCode: 200,
+		Headers:    map[string]string{"Content-Type": "text/xml"},
+		Body:       clients.TwiMLEmpty(),
+	}
+}
+
+func twimlResponse(msg string) LambdaResponse {
+	return LambdaResponse{
+		StatusCode: 200,
+		Headers:    map[string]string{"Content-Type": "text/xml"},
+		Body:       clients.TwiMLMessage(msg),
+	}
+}
+
+// smsSource attributes a texted inquiry to the number it was sent to,
+// which marketing assigns per channel
+func smsSource(sms clients.InboundSMS) string {
+	if sms.To == "" {
+		return ""
+	}
+	return "sms:" + sms.To
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/notify"
+)
+
+// purposeTeam routes leasing-team notifications (see config.Notify)
+const purposeTeam = "team"
+
+// notifyTeam posts a message to the leasing team's Slack channel for zone
+// (or wherever the deployment routes team notifications), with a link to
+// the invocation's audit record. Failures are logged only — team
+// visibility must never break the caller's flow.
+func (p *pipeline) notifyTeam(ctx context.Context, requestID, zone, text string) {
+	channel := p.cfg.SlackZoneChannels[zone]
+	if channel == "" {
+		channel = p.cfg.SlackDefaultChannel
+	}
+
+	if p.cfg.AuditURLTemplate != "" {
+		text += fmt.Sprintf("\n<%s|Audit record>", strings.ReplaceAll(p.cfg.AuditURLTemplate, "{request_id}", requestID))
+	}
+
+	// The dispatcher logs and meters each channel's outcome
+	p.notifier.Dispatch(ctx, notify.Message{
+		Purpose: purposeTeam,
+		To:      notify.Recipient{SlackChannel: channel},
+		Subject: "Leasing team notification",
+		Text:    text,
+		Data:    map[string]interface{}{"zone": zone, "requestId": requestID},
+	}, notify.ChannelSlack)
+}
+
+// leadTagsLine returns the lead's scoring tags as a line for a team
+// notification ("\nLead: hot, asap-mover"), or "" if the lead is unscored.
+func (p *pipeline) leadTagsLine(ctx context.Context, phone string) string {
+	if p.slack == nil {
+		return ""
+	}
+	lead, err := p.supabase.GetLead(ctx, phone)
+	if err != nil {
+		slog.WarnContext(ctx, "lead_fetch_failed", "error", err)
+		return ""
+	}
+	if lead == nil || len(lead.Tags) == 0 {
+		return ""
+	}
+	return "\nLead: " + strings.Join(lead.Tags, ", ")
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+)
+
+// stageTimings collects how long each pipeline stage took during one
+// invocation. They are reported as a Server-Timing header (read by
+// cmd/loadgen) and as StageLatency metrics.
+type stageTimings struct {
+	mu     sync.Mutex
+	stages []stageTiming
+}
+
+type stageTiming struct {
+	name string
+	dur  time.Duration
+}
+
+type stageTimingsKey struct{}
+
+func withStageTimings(ctx context.Context) (context.Context, *stageTimings) {
+	t := &stageTimings{}
+	return context.WithValue(ctx, stageTimingsKey{}, t), t
+}
+
+// timeStage starts timing the named stage; call the returned func when it ends
+func timeStage(ctx context.Context, name string) func() {
+	start := time.Now()
+	return func() {
+		dur := time.Since(start)
+		metrics.Record(ctx, "StageLatency", float64(dur.Milliseconds()), metrics.Milliseconds, "Stage", name)
+
+		t, ok := ctx.Value(stageTimingsKey{}).(*stageTimings)
+		if !ok {
+			return
+		}
+		t.mu.Lock()
+		t.stages = append(t.stages, stageTiming{name: name, dur: dur})
+		t.mu.Unlock()
+	}
+}
+
+// header renders the timings in Server-Timing format ("search;dur=12.5, ...")
+func (t *stageTimings) header() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	parts := make([]string, 0, len(t.stages))
+	for _, s := range t.stages {
+		parts = append(parts, fmt.Sprintf("%s;dur=%.1f", s.name, float64(s.dur.Microseconds())/1000))
+	}
+	return strings.Join(parts, ", ")
+}
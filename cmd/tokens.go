@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/clients"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/config"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+)
+
+// tokenRefreshMargin refreshes access tokens that would expire within it,
+// so they last through the next stretch of calls
+const tokenRefreshMargin = 15 * time.Minute
+
+// validateAgentTokens checks every agent's Google token, refreshes the ones
+// that are expired or about to expire, and flags (and alerts on) the ones
+// that can't be refreshed, ahead of the day's first caller.
+func validateAgentTokens(ctx context.Context, cfg config.Config, run *jobRun) jobResult {
+	result := run.result()
+	supa := newSupabaseClient(cfg)
+	oauth := clients.NewGoogleOAuthClient(cfg.GoogleClientID, cfg.GoogleClientSecret)
+
+	tokens, err := supa.ListOAuthTokens(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "oauth_tokens_fetch_failed", "error", err)
+		result.Errors = append(result.Errors, err.Error())
+		return result
+	}
+
+	// A run handed over to another invocation alerts each invocation's
+	// invalid tokens separately
+	var invalid []string
+	tokenEmail := func(token clients.OAuthToken) string { return token.Email }
+	eachCheckpointed(ctx, run, &result, tokens, tokenEmail, func(token clients.OAuthToken) {
+		status, refreshed, err := checkAgentToken(ctx, oauth, cfg, token)
+		if err != nil {
+			// Google or the network failed; the token's state is unknown
+			slog.ErrorContext(ctx, "token_validation_failed", "agent", token.Email, "error", err)
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", token.Email, err))
+			return
+		}
+		if status == clients.OAuthTokenInvalid {
+			invalid = append(invalid, token.Email)
+		}
+
+		if err := supa.MarkOAuthToken(ctx, token.Email, status, refreshed, time.Now().UTC()); err != nil {
+			slog.ErrorContext(ctx, "oauth_token_save_failed", "agent", token.Email, "error", err)
+			result.Errors = append(result.Errors, err.Error())
+			return
+		}
+		slog.InfoContext(ctx, "token_validated", "agent", token.Email, "status", status, "refreshed", refreshed != "")
+		result.Processed++
+	})
+
+	metrics.Record(ctx, "AgentTokensInvalid", float64(len(invalid)), metrics.Count)
+	alertInvalidTokens(ctx, cfg, invalid)
+	return result
+}
+
+// checkAgentToken returns the token's status and, when it was refreshed,
+// the new access token
+func checkAgentToken(ctx context.Context, oauth *clients.GoogleOAuthClient, cfg config.Config, token clients.OAuthToken) (string, string, error) {
+	info, err := oauth.TokenInfo(ctx, token.AccessToken)
+	if err != nil && !errors.Is(err, clients.ErrTokenInvalid) {
+		return "", "", err
+	}
+	if err == nil && info.ExpiresIn > tokenRefreshMargin {
+		return clients.OAuthTokenValid, "", nil
+	}
+
+	if token.RefreshToken == "" || cfg.GoogleClientID == "" {
+		return clients.OAuthTokenInvalid, "", nil
+	}
+	refreshed, err := oauth.Refresh(ctx, token.RefreshToken)
+	if errors.Is(err, clients.ErrRefreshRevoked) {
+		return clients.OAuthTokenInvalid, "", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+	return clients.OAuthTokenValid, refreshed.AccessToken, nil
+}
+
+// alertInvalidTokens pages ops with the agents who must re-authorize, or
+// resolves the alert once there are none
+func alertInvalidTokens(ctx context.Context, cfg config.Config, emails []string) {
+	if cfg.PagerDutyRoutingKey == "" {
+		return
+	}
+	source := cfg.FunctionName
+	if source == "" {
+		source = "go-scheduling-service"
+	}
+
+	alert := clients.Alert{DedupKey: source + "/agent-tokens", Resolve: len(emails) == 0}
+	if len(emails) > 0 {
+		sort.Strings(emails)
+		alert.Summary = fmt.Sprintf("%d agent calendar token(s) can't be refreshed; the agents must re-authorize", len(emails))
+		alert.Source = source
+		alert.Component = "google_calendar"
+		alert.Severity = "warning"
+		alert.Details = map[string]interface{}{"agents": strings.Join(emails, ", ")}
+	}
+
+	if err := clients.NewPagerDutyClient(cfg.PagerDutyRoutingKey).SendAlert(ctx, alert); err != nil {
+		slog.ErrorContext(ctx, "alert_send_failed", "dedup_key", alert.DedupKey, "error", err)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/config"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/usage"
+)
+
+// minTranscriptLength skips summarizing calls that hung up before saying
+// anything useful
+const minTranscriptLength = 80
+
+// endOfCallReport is the part of VAPI's end-of-call-report we use
+type endOfCallReport struct {
+	CallID     string
+	Phone      string
+	Transcript string
+}
+
+// parseEndOfCallReport detects a VAPI end-of-call-report server message
+func parseEndOfCallReport(body []byte) (endOfCallReport, bool) {
+	var payload struct {
+		Message struct {
+			Type       string `json:"type"`
+			Transcript string `json:"transcript"`
+			Artifact   struct {
+				Transcript string `json:"transcript"`
+			} `json:"artifact"`
+			Customer struct {
+				Number string `json:"number"`
+			} `json:"customer"`
+			Call struct {
+				ID       string `json:"id"`
+				Customer struct {
+					Number string `json:"number"`
+				} `json:"customer"`
+			} `json:"call"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil || payload.Message.Type != "end-of-call-report" {
+		return endOfCallReport{}, false
+	}
+
+	msg := payload.Message
+	report := endOfCallReport{CallID: msg.Call.ID, Phone: msg.Customer.Number, Transcript: msg.Artifact.Transcript}
+	if report.Phone == "" {
+		report.Phone = msg.Call.Customer.Number
+	}
+	if report.Transcript == "" {
+		report.Transcript = msg.Transcript
+	}
+	return report, true
+}
+
+// handleEndOfCallReport summarizes and scores the call transcript and stores
+// both on the caller's lead so the agent has context before the showing.
+// VAPI ignores the response body, so failures are only logged.
+func handleEndOfCallReport(ctx context.Context, cfg config.Config, report endOfCallReport) LambdaResponse {
+	ack := LambdaResponse{StatusCode: 200, Headers: map[string]string{"Content-Type": "application/json"}, Body: "{}"}
+	slog.InfoContext(ctx, "event_type_detected", "type", "vapi_end_of_call_report")
+
+	llm := newLLMClient(cfg, "")
+	if llm == nil || report.Phone == "" || len(report.Transcript) < minTranscriptLength {
+		slog.InfoContext(ctx, "call_summary_skipped", "call_id", report.CallID,
+			"llm_configured", llm != nil, "has_phone", report.Phone != "", "transcript_length", len(report.Transcript))
+		return ack
+	}
+	if skipDegraded(ctx, llm.Provider.Name(), "call_summary") {
+		return ack
+	}
+	supa := newSupabaseClient(cfg)
+	if !mayContact(ctx, supa, "", report.Phone, "lead_summary") {
+		return ack
+	}
+	// Summary and score are two LLM calls
+	if err := meterUsage(ctx, cfg, "", usage.OpenAICalls, 2); err != nil {
+		return ack
+	}
+
+	now := time.Now().UTC()
+	lead := models.Lead{Phone: report.Phone}
+
+	// Summary and score are independent; either is worth saving alone
+	summary, err := llm.SummarizeCall(ctx, report.Transcript)
+	if err != nil {
+		slog.WarnContext(ctx, "call_summary_failed", "call_id", report.CallID, "error", err)
+		metrics.Incr(ctx, "CallSummaryFailed")
+	} else {
+		lead.CallSummary, lead.CallSummarizedAt = summary, &now
+	}
+	tags, err := llm.ScoreLead(ctx, report.Transcript)
+	if err != nil {
+		slog.WarnContext(ctx, "lead_scoring_failed", "call_id", report.CallID, "error", err)
+		metrics.Incr(ctx, "LeadScoringFailed")
+	} else {
+		lead.Tags, lead.ScoredAt = tags, &now
+	}
+	if lead.CallSummary == nil && lead.Tags == nil {
+		return ack
+	}
+
+	if err := supa.SaveLead(ctx, lead); err != nil {
+		slog.ErrorContext(ctx, "lead_save_failed", "call_id", report.CallID, "error", err)
+		return ack
+	}
+
+	if summary != nil {
+		slog.InfoContext(ctx, "call_summary_saved", "call_id", report.CallID,
+			"interest_level", summary.InterestLevel, "objections", len(summary.Objections))
+		metrics.Incr(ctx, "CallSummarized")
+	}
+	if tags != nil {
+		slog.InfoContext(ctx, "lead_scored", "call_id", report.CallID, "tags", tags)
+		metrics.Incr(ctx, "LeadScored", "Score", tags[0])
+	}
+	return ack
+}
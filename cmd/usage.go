@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/config"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/usage"
+)
+
+// usageCounter is nil when usage tracking is not configured
+var usageCounter usage.Counter
+
+// errQuotaExceeded means the tenant is past its hard monthly quota
+var errQuotaExceeded = errors.New("usage quota exceeded")
+
+// errCodeQuotaExceeded is the Response.ErrorCode for a tenant over its hard quota
+const errCodeQuotaExceeded = "quota_exceeded"
+
+// defaultUsageTenant counts usage that has no tenant (e.g. SMS)
+const defaultUsageTenant = "default"
+
+func registerUsageCounter(cfg config.Config) {
+	if cfg.UsageTable == "" {
+		return
+	}
+	sess, err := awsSession()
+	if err != nil {
+		slog.Error("aws_session_failed", "error", err)
+		return
+	}
+	usageCounter = usage.NewDynamoCounter(sess, cfg.UsageTable)
+}
+
+// meterUsage counts n of metric against the tenant's month and returns
+// errQuotaExceeded when that takes it past the hard quota. Counter failures
+// are logged and never block the request.
+func meterUsage(ctx context.Context, cfg config.Config, tenantID, metric string, n int64) error {
+	if usageCounter == nil {
+		return nil
+	}
+	key := usageTenant(tenantID)
+	total, err := usageCounter.Add(ctx, key, usage.Period(time.Now()), metric, n)
+	if err != nil {
+		slog.WarnContext(ctx, "usage_count_failed", "metric", metric, "error", err)
+		return nil
+	}
+
+	quota := cfg.UsageQuotaFor(tenantID, metric)
+	switch usage.Check(total, quota) {
+	case usage.OverHard:
+		slog.WarnContext(ctx, "usage_quota_exceeded", "tenant_id", key, "metric", metric, "total", total, "quota", quota.Hard)
+		metrics.Incr(ctx, "UsageQuotaExceeded", "Metric", metric)
+		return errQuotaExceeded
+	case usage.OverSoft:
+		// Warn once, as the total crosses the soft quota
+		if usage.Check(total-n, quota) == usage.WithinQuota {
+			slog.WarnContext(ctx, "usage_soft_quota_exceeded", "tenant_id", key, "metric", metric, "total", total, "quota", quota.Soft)
+			metrics.Incr(ctx, "UsageSoftQuotaExceeded", "Metric", metric)
+		}
+	}
+	return nil
+}
+
+// checkUsage returns errQuotaExceeded when the tenant has already used its
+// hard quota of metric, for usage that is only counted once it succeeds
+func checkUsage(ctx context.Context, cfg config.Config, tenantID, metric string) error {
+	quota := cfg.UsageQuotaFor(tenantID, metric)
+	if usageCounter == nil || quota.Hard <= 0 {
+		return nil
+	}
+	total, err := usageCounter.Get(ctx, usageTenant(tenantID), usage.Period(time.Now()), metric)
+	if err != nil {
+		slog.WarnContext(ctx, "usage_count_failed", "metric", metric, "error", err)
+		return nil
+	}
+	if total >= quota.Hard {
+		slog.WarnContext(ctx, "usage_quota_exceeded", "tenant_id", usageTenant(tenantID), "metric", metric, "total", total, "quota", quota.Hard)
+		metrics.Incr(ctx, "UsageQuotaExceeded", "Metric", metric)
+		return errQuotaExceeded
+	}
+	return nil
+}
+
+func usageTenant(tenantID string) string {
+	if tenantID == "" {
+		return defaultUsageTenant
+	}
+	return tenantID
+}
+
+// quotaExceededResponse is the 429 returned once a tenant is over its
+// invocation quota; the voice agent still gets something to say
+func quotaExceededResponse(cfg config.Config, tenantID string) LambdaResponse {
+	resp := brandedResponse(cfg, tenantID, models.Response{
+		Success:      false,
+		ErrorCode:    errCodeQuotaExceeded,
+		Message:      "Monthly usage quota exceeded.",
+		FormattedMsg: "I'm not able to check showing times right now. A team member will follow up with you shortly.",
+		NextActions:  []string{models.NextTransferToHuman},
+	})
+	resp.StatusCode = 429
+	return resp
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+// vapiArgKinds maps each tool-call argument VAPIFunctionArgs knows to the
+// JSON kind it must have
+var vapiArgKinds = func() map[string]string {
+	kinds := make(map[string]string)
+	t := reflect.TypeFor[models.VAPIFunctionArgs]()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch f.Type.Kind() {
+		case reflect.String:
+			kinds[name] = "string"
+		case reflect.Bool:
+			kinds[name] = "boolean"
+		case reflect.Map, reflect.Struct:
+			kinds[name] = "object"
+		default:
+			kinds[name] = "number"
+		}
+	}
+	return kinds
+}()
+
+// validateVAPIPayload checks a VAPI server message against the shape
+// tryParseVAPI and the end-of-call parser read, returning one violation per
+// problem ("message.toolCalls[0].function.name: missing"). Bodies that
+// aren't VAPI messages have none.
+func validateVAPIPayload(body []byte) []string {
+	var root map[string]json.RawMessage
+	if err := json.Unmarshal(body, &root); err != nil || root["message"] == nil {
+		return nil
+	}
+
+	var v []string
+	message, ok := objectAt(&v, "message", root["message"])
+	if !ok {
+		return v
+	}
+	msgType, _ := stringAt(&v, "message.type", message["type"], true)
+	if call, ok := message["call"]; ok {
+		if fields, ok := objectAt(&v, "message.call", call); ok {
+			stringAt(&v, "message.call.id", fields["id"], true)
+		}
+	}
+	if msgType != "tool-calls" {
+		return v
+	}
+
+	var toolCalls []json.RawMessage
+	if err := json.Unmarshal(message["toolCalls"], &toolCalls); err != nil || len(toolCalls) == 0 {
+		v = append(v, fmt.Sprintf("message.toolCalls: want a non-empty array, got %s", jsonKind(message["toolCalls"])))
+		return v
+	}
+	for i, raw := range toolCalls {
+		path := fmt.Sprintf("message.toolCalls[%d]", i)
+		toolCall, ok := objectAt(&v, path, raw)
+		if !ok {
+			continue
+		}
+		stringAt(&v, path+".id", toolCall["id"], true)
+		function, ok := objectAt(&v, path+".function", toolCall["function"])
+		if !ok {
+			continue
+		}
+		name, _ := stringAt(&v, path+".function.name", function["name"], true)
+		args, ok := objectAt(&v, path+".function.arguments", function["arguments"])
+		if !ok {
+			continue
+		}
+		validateVAPIArgs(&v, path+".function.arguments", name, args)
+	}
+	return v
+}
+
+// vapiRequiredArgs lists the arguments each tool needs. Every group must be
+// given, by any one of its names; tools not listed are availability checks.
+var vapiRequiredArgs = map[string][][]string{
+	callbackToolName:   nil,
+	bookToolName:       {{"Query", "ConfirmedPropertyId"}, {"SlotStart"}},
+	confirmToolName:    {{"SlotId"}},
+	cancelToolName:     {{"ConfirmationId", "Phone"}},
+	rescheduleToolName: {{"ConfirmationId"}, {"SlotStart"}},
+}
+
+// availabilityRequiredArgs is what a tool without its own list needs
+var availabilityRequiredArgs = [][]string{{"Query", "ConfirmedPropertyId"}}
+
+// validateVAPIArgs checks a tool call's arguments: every argument must be
+// one VAPIFunctionArgs knows, of its type, and the tool's required ones
+// (vapiRequiredArgs) must be given
+func validateVAPIArgs(v *[]string, path, tool string, args map[string]json.RawMessage) {
+	names := make([]string, 0, len(args))
+	for name := range args {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		want, ok := vapiArgKinds[name]
+		if !ok {
+			*v = append(*v, fmt.Sprintf("%s.%s: unknown argument", path, name))
+			continue
+		}
+		if got := jsonKind(args[name]); got != want && got != "null" {
+			*v = append(*v, fmt.Sprintf("%s.%s: want %s, got %s", path, name, want, got))
+		}
+	}
+
+	required, ok := vapiRequiredArgs[tool]
+	if !ok {
+		required = availabilityRequiredArgs
+	}
+	for _, group := range required {
+		if !slices.ContainsFunc(group, func(name string) bool { return argGiven(args[name]) }) {
+			msg := path + "." + group[0] + ": missing"
+			if len(group) > 1 {
+				msg += " (or " + strings.Join(group[1:], ", ") + ")"
+			}
+			*v = append(*v, msg)
+		}
+	}
+}
+
+// argGiven reports whether raw is a value other than null or a blank string
+func argGiven(raw json.RawMessage) bool {
+	switch jsonKind(raw) {
+	case "missing", "null":
+		return false
+	case "string":
+		var s string
+		return json.Unmarshal(raw, &s) == nil && strings.TrimSpace(s) != ""
+	}
+	return true
+}
+
+// objectAt decodes raw as an object, recording a violation at path if it
+// isn't one
+func objectAt(v *[]string, path string, raw json.RawMessage) (map[string]json.RawMessage, bool) {
+	var fields map[string]json.RawMessage
+	if jsonKind(raw) != "object" || json.Unmarshal(raw, &fields) != nil {
+		*v = append(*v, fmt.Sprintf("%s: want object, got %s", path, jsonKind(raw)))
+		return nil, false
+	}
+	return fields, true
+}
+
+// stringAt decodes raw as a string. A value of another kind is a violation,
+// and so is a missing or empty one when required.
+func stringAt(v *[]string, path string, raw json.RawMessage, required bool) (string, bool) {
+	kind := jsonKind(raw)
+	if kind == "missing" {
+		if required {
+			*v = append(*v, path+": missing")
+		}
+		return "", false
+	}
+	var s string
+	if kind != "string" || json.Unmarshal(raw, &s) != nil {
+		*v = append(*v, fmt.Sprintf("%s: want string, got %s", path, kind))
+		return "", false
+	}
+	if s == "" && required {
+		*v = append(*v, path+": empty")
+	}
+	return s, true
+}
+
+// jsonKind names the JSON type of raw: object, array, string, number,
+// boolean, null, or missing when there is no value
+func jsonKind(raw json.RawMessage) string {
+	s := strings.TrimSpace(string(raw))
+	if s == "" {
+		return "missing"
+	}
+	switch s[0] {
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	case '"':
+		return "string"
+	case 't', 'f':
+		return "boolean"
+	case 'n':
+		return "null"
+	}
+	return "number"
+}
+
+// schemaViolationResponse is the 422 a strict-mode VAPI message that
+// failed validation gets
+func schemaViolationResponse(violations []string) LambdaResponse {
+	body, _ := json.Marshal(map[string]any{
+		"error":      "VAPI payload does not match the expected schema",
+		"violations": violations,
+	})
+	return LambdaResponse{
+		StatusCode: 422,
+		Headers:    map[string]string{"Content-Type": "application/json"},
+		Body:       string(body),
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+// toolCallBody is a VAPI tool-calls message calling tool with args
+func toolCallBody(t *testing.T, tool string, args map[string]any) []byte {
+	t.Helper()
+	body, err := json.Marshal(map[string]any{
+		"message": map[string]any{
+			"type": "tool-calls",
+			"call": map[string]any{"id": "call-1"},
+			"toolCalls": []any{map[string]any{
+				"id":       "tc-1",
+				"function": map[string]any{"name": tool, "arguments": args},
+			}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+func TestStrictSchemaRequiredArgsPerTool(t *testing.T) {
+	const args = "message.toolCalls[0].function.arguments"
+	cases := []struct {
+		name string
+		tool string
+		args map[string]any
+		want []string
+	}{
+		{"availability with query", "check_availability", map[string]any{"Query": "123 Main St"}, nil},
+		{"availability by confirmed property", "check_availability", map[string]any{"ConfirmedPropertyId": "p1"}, nil},
+		{"availability without query", "check_availability", map[string]any{"Phone": "+15551234567"}, []string{args + ".Query: missing (or ConfirmedPropertyId)"}},
+		{"availability with blank query", "check_availability", map[string]any{"Query": "  "}, []string{args + ".Query: missing (or ConfirmedPropertyId)"}},
+		{"callback", callbackToolName, map[string]any{"CallbackAt": "2026-10-20T10:00", "Phone": "+15551234567"}, nil},
+		{"book", bookToolName, map[string]any{"Query": "123 Main St", "SlotStart": "2026-10-20T10:00"}, nil},
+		{"book without slot", bookToolName, map[string]any{"Query": "123 Main St"}, []string{args + ".SlotStart: missing"}},
+		{"confirm", confirmToolName, map[string]any{"SlotId": "20261020-1000", "Phone": "+15551234567"}, nil},
+		{"confirm without slot", confirmToolName, map[string]any{"Phone": "+15551234567"}, []string{args + ".SlotId: missing"}},
+		{"cancel by confirmation", cancelToolName, map[string]any{"ConfirmationId": "b1"}, nil},
+		{"cancel by phone", cancelToolName, map[string]any{"Phone": "+15551234567"}, nil},
+		{"cancel without either", cancelToolName, map[string]any{"Name": "Sam"}, []string{args + ".ConfirmationId: missing (or Phone)"}},
+		{"reschedule", rescheduleToolName, map[string]any{"ConfirmationId": "b1", "SlotStart": "2026-10-21T14:00"}, nil},
+		{"reschedule without slot", rescheduleToolName, map[string]any{"ConfirmationId": "b1"}, []string{args + ".SlotStart: missing"}},
+		{"reschedule without confirmation", rescheduleToolName, map[string]any{"Phone": "+15551234567", "SlotStart": "2026-10-21T14:00"}, []string{args + ".ConfirmationId: missing"}},
+		{"unknown argument", cancelToolName, map[string]any{"Phone": "+15551234567", "Bogus": 1}, []string{args + ".Bogus: unknown argument"}},
+		{"wrong kind", cancelToolName, map[string]any{"ConfirmationId": 42}, []string{args + ".ConfirmationId: want string, got number"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := validateVAPIPayload(toolCallBody(t, tc.tool, tc.args))
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("violations = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
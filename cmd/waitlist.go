@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/config"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/logic"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+// waitlist puts phone on the waitlist of a property without a vacant unit,
+// so the property sync texts them when one opens up
+func (p *pipeline) waitlist(ctx context.Context, phone, propID string) {
+	if phone == "" {
+		return
+	}
+	now := time.Now().UTC()
+	if err := p.supabase.SaveLead(ctx, models.Lead{Phone: phone, WaitlistPropertyID: propID, WaitlistedAt: &now}); err != nil {
+		slog.WarnContext(ctx, "waitlist_save_failed", "property_id", propID, "error", err)
+		return
+	}
+	slog.InfoContext(ctx, "lead_waitlisted", "property_id", propID)
+	metrics.Incr(ctx, "LeadWaitlisted")
+}
+
+// notifyVacancies texts fresh showing times to waitlisted leads whose
+// property the sync found vacant. Leads in quiet hours stay waitlisted for
+// the next run.
+func notifyVacancies(ctx context.Context, requestID string, cfg config.Config, snapshots []models.PropertySnapshot, result *jobResult) {
+	vacant := make(map[string]models.PropertySnapshot)
+	for _, s := range snapshots {
+		if s.Vacant {
+			vacant[s.PropertyID] = s
+		}
+	}
+	p := pipelineFor(cfg)
+	leads, err := p.supabase.ListWaitlistedLeads(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "waitlisted_leads_fetch_failed", "error", err)
+		result.Errors = append(result.Errors, err.Error())
+		return
+	}
+
+	for _, lead := range leads {
+		property, ok := vacant[lead.WaitlistPropertyID]
+		if !ok || !lead.AwaitingVacancy() {
+			continue
+		}
+		availability := p.findAvailability(ctx, requestID,
+			models.Request{Query: property.Address1, Phone: lead.Phone},
+			propertyMatch{PropertyID: property.PropertyID, Source: models.MatchOverride})
+		if !availability.Response.Success || len(availability.Slots) == 0 {
+			slog.InfoContext(ctx, "vacancy_outreach_skipped", "property_id", property.PropertyID, "reason", "no_slots")
+			continue
+		}
+
+		msg := "Good news: a unit just opened up at " + property.Address1 + ". " +
+			p.offerAvailability(ctx, lead.Phone, availability)
+		err := p.sendText(ctx, "", lead.Phone, logic.Location(availability.TimeZone), msg, "vacancy_outreach")
+		if errors.Is(err, errQuietHours) {
+			continue
+		}
+		if err != nil && !errors.Is(err, errDoNotContact) && !errors.Is(err, errNoSMSConsent) {
+			slog.ErrorContext(ctx, "vacancy_outreach_failed", "property_id", property.PropertyID, "error", err)
+			result.Errors = append(result.Errors, err.Error())
+			continue
+		}
+
+		// Leads we may not text are marked too, so they aren't retried
+		now := time.Now().UTC()
+		if err := p.supabase.SaveLead(ctx, models.Lead{Phone: lead.Phone, VacancyNotifiedAt: &now}); err != nil {
+			slog.ErrorContext(ctx, "lead_save_failed", "error", err)
+			result.Errors = append(result.Errors, err.Error())
+			continue
+		}
+		if err == nil {
+			slog.InfoContext(ctx, "vacancy_outreach_sent", "property_id", property.PropertyID)
+			metrics.Incr(ctx, "VacancyOutreachSent")
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/config"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/logic"
+)
+
+// warm is process-lifetime state built during init, so a pre-initialized
+// execution environment (provisioned concurrency, or one restored from a
+// snapshot) serves its first caller without constructing clients or
+// resolving time zones. Each piece records what it was built from and is
+// rebuilt when that no longer matches.
+var warm struct {
+	mu          sync.Mutex
+	fingerprint [sha256.Size]byte
+	pipeline    *pipeline
+}
+
+// warmUp does the expensive setup ahead of the first invocation
+func warmUp(cfg config.Config) {
+	start := time.Now()
+
+	logic.ScheduleLocation(cfg.ScheduleRules)
+	for _, rules := range cfg.TenantScheduleRules {
+		logic.ScheduleLocation(&rules)
+	}
+
+	warm.mu.Lock()
+	if cfg.Valid() {
+		warm.fingerprint = configFingerprint(cfg)
+		warm.pipeline = newPipeline(cfg)
+	}
+	warm.mu.Unlock()
+
+	slog.Info("warm_up_complete", "duration_ms", time.Since(start).Milliseconds())
+}
+
+// pipelineFor returns the pipeline built at init, or a new one when the
+// configuration has changed since (the rebuilt one is kept for later calls).
+func pipelineFor(cfg config.Config) *pipeline {
+	fingerprint := configFingerprint(cfg)
+
+	warm.mu.Lock()
+	defer warm.mu.Unlock()
+	if warm.pipeline != nil && warm.fingerprint == fingerprint {
+		return warm.pipeline
+	}
+	if warm.pipeline != nil {
+		slog.Info("config_changed_rebuilding_pipeline")
+	}
+	warm.fingerprint = fingerprint
+	warm.pipeline = newPipeline(cfg)
+	return warm.pipeline
+}
+
+// configFingerprint identifies a configuration; equal configs share a pipeline
+func configFingerprint(cfg config.Config) [sha256.Size]byte {
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		// Unreachable for a plain struct; a zero value forces a rebuild
+		return [sha256.Size]byte{}
+	}
+	return sha256.Sum256(b)
+}
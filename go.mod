@@ -3,20 +3,20 @@ module github.com/vishnuanilkumar/go-scheduling-service
 go 1.25.4
 
 require (
+	github.com/andybalholm/brotli v1.1.0
 	github.com/aws/aws-lambda-go v1.50.0
+	github.com/aws/aws-sdk-go v1.47.9
 	github.com/aws/aws-xray-sdk-go v1.8.5
+	golang.org/x/net v0.26.0
 	golang.org/x/time v0.14.0
 )
 
 require (
-	github.com/andybalholm/brotli v1.1.0 // indirect
-	github.com/aws/aws-sdk-go v1.47.9 // indirect
 	github.com/jmespath/go-jmespath v0.4.0 // indirect
 	github.com/klauspost/compress v1.17.6 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
 	github.com/valyala/fasthttp v1.52.0 // indirect
-	golang.org/x/net v0.26.0 // indirect
 	golang.org/x/sys v0.21.0 // indirect
 	golang.org/x/text v0.16.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20240318140521-94a12d6c2237 // indirect
@@ -0,0 +1,326 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/egress"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/faultinject"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/instrument"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/mtls"
+)
+
+const (
+	// FailureThreshold consecutive failures open the circuit
+	FailureThreshold = 5
+	// OpenCooldown is how long an open circuit rejects calls before probing again
+	OpenCooldown = 30 * time.Second
+	// ProbeTimeout is how long a half-open circuit waits on its probe before
+	// presuming it lost and letting another through
+	ProbeTimeout = 30 * time.Second
+
+	// ErrorRateWindow is the tumbling window over which the error rate is measured
+	ErrorRateWindow = time.Minute
+	// ErrorRateMinRequests is the minimum sample size before the rate is evaluated
+	ErrorRateMinRequests = 10
+	// ErrorRateThreshold is the failure ratio that triggers an error-rate event
+	ErrorRateThreshold = 0.5
+
+	// DegradedFailures failures within DegradedWindow mark a dependency
+	// degraded, so optional steps that use it can be skipped
+	DegradedFailures = 5
+	DegradedWindow   = 2 * time.Minute
+)
+
+// ErrOpen is returned when a call is rejected because the circuit is open
+var ErrOpen = errors.New("circuit breaker open")
+
+type State int
+
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// Event describes a breaker transition or error-rate breach for a dependency
+type Event struct {
+	Dependency string
+	From       State
+	To         State
+	ErrorRate  float64 // set for error-rate events
+	Requests   int
+	Failures   int
+	LastError  string
+}
+
+// IsErrorRate reports whether the event is an error-rate breach rather than a state change
+func (e Event) IsErrorRate() bool {
+	return e.ErrorRate > 0
+}
+
+// Breaker tracks the health of a single downstream dependency
+type Breaker struct {
+	name string
+
+	mu          sync.Mutex
+	state       State
+	consecutive int
+	openedAt    time.Time
+	probedAt    time.Time
+	lastErr     string
+
+	windowStart   time.Time
+	windowReqs    int
+	windowFails   int
+	windowAlerted bool
+
+	// recentFails holds the times of the last DegradedFailures failures
+	recentFails []time.Time
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]*Breaker{}
+	listeners  []func(Event)
+
+	// pending holds a channel per listener call still running, closed when
+	// it returns
+	pendingMu sync.Mutex
+	pending   = map[chan struct{}]struct{}{}
+)
+
+// Get returns the process-wide breaker for a dependency, creating it on first use
+func Get(name string) *Breaker {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	b, ok := registry[name]
+	if !ok {
+		b = &Breaker{name: name}
+		registry[name] = b
+	}
+	return b
+}
+
+// OnEvent registers a listener for state changes and error-rate breaches.
+// Listeners run on their own goroutine and must not block for long; Flush
+// waits for the ones still running.
+func OnEvent(fn func(Event)) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	listeners = append(listeners, fn)
+}
+
+func emit(e Event) {
+	registryMu.Lock()
+	ls := append([]func(Event){}, listeners...)
+	registryMu.Unlock()
+	for _, fn := range ls {
+		done := make(chan struct{})
+		pendingMu.Lock()
+		pending[done] = struct{}{}
+		pendingMu.Unlock()
+		go func() {
+			defer func() {
+				pendingMu.Lock()
+				delete(pending, done)
+				pendingMu.Unlock()
+				close(done)
+			}()
+			fn(e)
+		}()
+	}
+}
+
+// Flush waits until the listeners already handling events return or ctx
+// ends, and reports whether they all returned. A Lambda invocation calls it
+// before returning, since the environment may be frozen afterwards with an
+// alert still in flight.
+func Flush(ctx context.Context) bool {
+	pendingMu.Lock()
+	waiting := make([]chan struct{}, 0, len(pending))
+	for done := range pending {
+		waiting = append(waiting, done)
+	}
+	pendingMu.Unlock()
+	for _, done := range waiting {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// State returns the breaker's current state
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Degraded reports whether the dependency is open or has failed
+// DegradedFailures times within DegradedWindow
+func (b *Breaker) Degraded() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state != Closed {
+		return true
+	}
+	return len(b.recentFails) == DegradedFailures && time.Since(b.recentFails[0]) <= DegradedWindow
+}
+
+// Degraded reports whether the named dependency is degraded (see Breaker.Degraded)
+func Degraded(name string) bool {
+	return Get(name).Degraded()
+}
+
+// Allow returns ErrOpen if calls to the dependency should be rejected.
+// After the cooldown a single probe is let through (half-open), and another
+// once that one has gone ProbeTimeout without an outcome.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Open:
+		if time.Since(b.openedAt) < OpenCooldown {
+			return fmt.Errorf("%s: %w", b.name, ErrOpen)
+		}
+		b.probedAt = time.Now()
+		b.transition(HalfOpen)
+		return nil
+	case HalfOpen:
+		if time.Since(b.probedAt) >= ProbeTimeout {
+			// The probe in flight hung; its outcome still counts if it arrives
+			b.probedAt = time.Now()
+			return nil
+		}
+		return fmt.Errorf("%s: %w", b.name, ErrOpen)
+	}
+	return nil
+}
+
+// abandon frees the probe slot of a call whose outcome is unknown, without
+// counting it either way: the next call probes again. No event is emitted,
+// since the dependency's state hasn't changed.
+func (b *Breaker) abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == HalfOpen {
+		b.state = Open
+		b.openedAt = time.Now().Add(-OpenCooldown)
+	}
+}
+
+// Record updates the breaker with the outcome of a call (nil = success)
+func (b *Breaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(b.windowStart) > ErrorRateWindow {
+		b.windowStart = now
+		b.windowReqs, b.windowFails = 0, 0
+		b.windowAlerted = false
+	}
+	b.windowReqs++
+
+	if err == nil {
+		b.consecutive = 0
+		if b.state != Closed {
+			b.transition(Closed)
+		}
+		return
+	}
+
+	b.windowFails++
+	b.consecutive++
+	b.lastErr = err.Error()
+	if len(b.recentFails) == DegradedFailures {
+		b.recentFails = append(b.recentFails[:0], b.recentFails[1:]...)
+	}
+	b.recentFails = append(b.recentFails, now)
+
+	if b.state == HalfOpen || (b.state == Closed && b.consecutive >= FailureThreshold) {
+		b.openedAt = now
+		b.transition(Open)
+	}
+
+	if !b.windowAlerted && b.windowReqs >= ErrorRateMinRequests {
+		rate := float64(b.windowFails) / float64(b.windowReqs)
+		if rate >= ErrorRateThreshold {
+			b.windowAlerted = true
+			emit(Event{
+				Dependency: b.name, From: b.state, To: b.state,
+				ErrorRate: rate, Requests: b.windowReqs, Failures: b.windowFails, LastError: b.lastErr,
+			})
+		}
+	}
+}
+
+// transition must be called with b.mu held
+func (b *Breaker) transition(to State) {
+	from := b.state
+	b.state = to
+	emit(Event{
+		Dependency: b.name, From: from, To: to,
+		Requests: b.windowReqs, Failures: b.windowFails, LastError: b.lastErr,
+	})
+}
+
+// Transport wraps base (mtls.Transport if nil) so every round trip is
+// gated by and recorded against the named dependency's breaker. 5xx and 429
+// responses count as failures. Requests to hosts outside the egress
+// allowlist are refused before reaching the breaker. Injected faults
+// (non-production only) sit below the breaker so they trip it like real ones.
+// Every call, including ones the breaker or egress check rejects, is logged
+// and metered by instrument.Transport.
+func Transport(name string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = mtls.Transport
+	}
+	return instrument.Transport(name, egress.Transport(&transport{breaker: Get(name), base: faultinject.Wrap(name, base)}))
+}
+
+type transport struct {
+	breaker *Breaker
+	base    http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.breaker.Allow(); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	switch {
+	case err != nil && req.Context().Err() == context.Canceled:
+		// The caller gave up (e.g. a hedged request lost the race); that
+		// says nothing about the dependency
+		t.breaker.abandon()
+	case err != nil:
+		t.breaker.Record(err)
+	case resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests:
+		t.breaker.Record(fmt.Errorf("HTTP %s", resp.Status))
+	default:
+		t.breaker.Record(nil)
+	}
+	return resp, err
+}
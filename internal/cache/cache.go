@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// Cache is a concurrency-safe in-memory TTL cache. Entries live for the
+// lifetime of the warm container (Lambda) or process (container mode).
+type Cache[K comparable, V any] struct {
+	mu    sync.RWMutex
+	ttl   time.Duration
+	items map[K]entry[V]
+}
+
+type entry[V any] struct {
+	value   V
+	expires time.Time
+}
+
+// New creates a cache whose entries expire after ttl
+func New[K comparable, V any](ttl time.Duration) *Cache[K, V] {
+	return &Cache[K, V]{ttl: ttl, items: make(map[K]entry[V])}
+}
+
+// Get returns the cached value for key if present and not expired
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.RLock()
+	e, ok := c.items[key]
+	c.mu.RUnlock()
+	if !ok || time.Now().After(e.expires) {
+		var zero V
+		return zero, false
+	}
+	return e.value, true
+}
+
+// Set stores value under key with the cache's default TTL
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.SetWithTTL(key, value, c.ttl)
+}
+
+// SetWithTTL stores value under key with an explicit TTL
+func (c *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	c.mu.Lock()
+	c.items[key] = entry[V]{value: value, expires: time.Now().Add(ttl)}
+	c.mu.Unlock()
+}
+
+// Delete removes key from the cache
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	delete(c.items, key)
+	c.mu.Unlock()
+}
+
+// DeleteMatching removes every key for which match returns true
+func (c *Cache[K, V]) DeleteMatching(match func(K) bool) {
+	c.mu.Lock()
+	for key := range c.items {
+		if match(key) {
+			delete(c.items, key)
+		}
+	}
+	c.mu.Unlock()
+}
+
+// Purge removes every entry
+func (c *Cache[K, V]) Purge() {
+	c.mu.Lock()
+	c.items = make(map[K]entry[V])
+	c.mu.Unlock()
+}
@@ -0,0 +1,99 @@
+// Package checkpoint keeps the progress of long-running jobs, so a run cut
+// short by the Lambda timeout resumes where it stopped rather than
+// restarting.
+package checkpoint
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+)
+
+// Retention is how long an abandoned checkpoint is kept before DynamoDB's
+// TTL removes it
+const Retention = 7 * 24 * time.Hour
+
+// Checkpoint is a job run's progress. Items are processed in key order and
+// Cursor is the key of the last one done.
+type Checkpoint struct {
+	RunID     string    `json:"run_id"`
+	Job       string    `json:"job"`
+	Cursor    string    `json:"cursor"`
+	Processed int       `json:"processed"`
+	Errors    []string  `json:"errors,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DynamoStore keeps checkpoints in a DynamoDB table with partition key
+// "run_id" and TTL attribute "expires_at". Attributes use the Checkpoint
+// JSON names.
+type DynamoStore struct {
+	Table  string
+	client dynamodbiface.DynamoDBAPI
+}
+
+func NewDynamoStore(sess *session.Session, table string) *DynamoStore {
+	client := dynamodb.New(sess)
+	xray.AWS(client.Client)
+	return &DynamoStore{Table: table, client: client}
+}
+
+// Load returns the run's checkpoint, or nil if it has none
+func (s *DynamoStore) Load(ctx context.Context, runID string) (*Checkpoint, error) {
+	out, err := s.client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.Table),
+		Key:            s.key(runID),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("checkpoint load: %w", err)
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+	var cp Checkpoint
+	if err := dynamodbattribute.UnmarshalMap(out.Item, &cp); err != nil {
+		return nil, fmt.Errorf("checkpoint load: %w", err)
+	}
+	return &cp, nil
+}
+
+// Save stores cp, replacing the run's previous checkpoint
+func (s *DynamoStore) Save(ctx context.Context, cp Checkpoint) error {
+	cp.UpdatedAt = time.Now().UTC()
+	item, err := dynamodbattribute.MarshalMap(cp)
+	if err != nil {
+		return fmt.Errorf("checkpoint save: %w", err)
+	}
+	item["expires_at"] = &dynamodb.AttributeValue{N: aws.String(strconv.FormatInt(cp.UpdatedAt.Add(Retention).Unix(), 10))}
+	if _, err := s.client.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.Table),
+		Item:      item,
+	}); err != nil {
+		return fmt.Errorf("checkpoint save: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the run's checkpoint once the run has finished
+func (s *DynamoStore) Delete(ctx context.Context, runID string) error {
+	if _, err := s.client.DeleteItemWithContext(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.Table),
+		Key:       s.key(runID),
+	}); err != nil {
+		return fmt.Errorf("checkpoint delete: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoStore) key(runID string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{"run_id": {S: aws.String(runID)}}
+}
@@ -0,0 +1,71 @@
+package clients
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+const (
+	// errorBodyReadLimit bounds how much of an error response is read
+	errorBodyReadLimit = 16 << 10
+	// ErrorBodyMaxLen bounds the body excerpt kept on an APIError
+	ErrorBodyMaxLen = 512
+)
+
+// APIError is a non-2xx response from a dependency. Body is a capped
+// excerpt of the response with secrets and contact details masked, so it
+// is safe to log and to return to callers.
+type APIError struct {
+	// Msg is the client's description, e.g. "Google Calendar API error (Insert)"
+	Msg    string
+	Status string
+	Code   int
+	Body   string
+}
+
+func (e *APIError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("%s: %s", e.Msg, e.Status)
+	}
+	return fmt.Sprintf("%s: %s: %s", e.Msg, e.Status, e.Body)
+}
+
+// newAPIError reads a capped, sanitized excerpt of resp's body into an
+// APIError and logs it at warn, since the body is usually the only place a
+// dependency says what was wrong with the request.
+func newAPIError(resp *http.Response, msg string) *APIError {
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, errorBodyReadLimit))
+	apiErr := &APIError{Msg: msg, Status: resp.Status, Code: resp.StatusCode, Body: SanitizeErrorBody(string(raw))}
+
+	if resp.Request != nil {
+		ctx := resp.Request.Context()
+		slog.WarnContext(ctx, "dependency_error_body",
+			"error", msg, "status", resp.StatusCode, "body", apiErr.Body)
+	}
+	return apiErr
+}
+
+var (
+	secretFieldPattern = regexp.MustCompile(`(?i)("[^"]*(token|secret|password|key|authorization|code)[^"]*"\s*:\s*)"[^"]*"`)
+	bearerPattern      = regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9._~+/=-]+`)
+	emailPattern       = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	phonePattern       = regexp.MustCompile(`\+?\d[\d\s().-]{8,}\d`)
+)
+
+// SanitizeErrorBody masks credentials, emails and phone numbers in an error
+// response body, collapses whitespace, and truncates it to ErrorBodyMaxLen
+func SanitizeErrorBody(body string) string {
+	body = secretFieldPattern.ReplaceAllString(body, `$1"redacted"`)
+	body = bearerPattern.ReplaceAllString(body, "Bearer redacted")
+	body = emailPattern.ReplaceAllString(body, "[email]")
+	body = phonePattern.ReplaceAllString(body, "[phone]")
+	body = strings.Join(strings.Fields(body), " ")
+	if len(body) > ErrorBodyMaxLen {
+		body = strings.ToValidUTF8(body[:ErrorBodyMaxLen], "") + "…"
+	}
+	return body
+}
@@ -3,13 +3,31 @@ package clients
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	neturl "net/url"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/breaker"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/inflight"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
 	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/ratelimit"
+)
+
+// ErrAppFolioCredentials indicates AppFolio rejected our credentials (401/403),
+// typically because the API key was rotated or expired.
+var ErrAppFolioCredentials = errors.New("AppFolio credentials expired or invalid")
+
+// Concurrent lookups of the same property or groups, e.g. during a
+// marketing blast, share one AppFolio request
+var (
+	propertyLookups = inflight.New[*models.AppFolioProperty](0)
+	groupLookups    = inflight.New[[]models.AppFolioGroup](0)
 )
 
 type AppFolioClient struct {
@@ -24,11 +42,36 @@ func NewAppFolioClient(authHeader, developerID string) *AppFolioClient {
 		BaseURL:     "https://api.appfolio.com",
 		AuthHeader:  authHeader,
 		DeveloperID: developerID,
-		HTTPClient:  xray.Client(&http.Client{Timeout: 10 * time.Second}),
+		HTTPClient:  xray.Client(&http.Client{Timeout: 10 * time.Second, Transport: ratelimit.Transport("appfolio", breaker.Transport("appfolio", nil))}),
 	}
 }
 
+func (c *AppFolioClient) Name() string { return "appfolio" }
+
+// lookupKey scopes a coalesced lookup to the credentials making it, so
+// tenants never share each other's responses
+func (c *AppFolioClient) lookupKey(key string) string {
+	return c.BaseURL + "|" + c.DeveloperID + "|" + c.AuthHeader + "|" + key
+}
+
+// GetProperty fetches a property, sharing the request with concurrent
+// lookups of the same property
 func (c *AppFolioClient) GetProperty(ctx context.Context, propertyID string) (*models.AppFolioProperty, error) {
+	prop, err, shared := propertyLookups.Do(c.lookupKey(propertyID), func() (*models.AppFolioProperty, error) {
+		return c.getProperty(context.WithoutCancel(ctx), propertyID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if shared {
+		metrics.Incr(ctx, "LookupCoalesced", "Lookup", "AppFolioProperty")
+		copied := *prop
+		prop = &copied
+	}
+	return prop, nil
+}
+
+func (c *AppFolioClient) getProperty(ctx context.Context, propertyID string) (*models.AppFolioProperty, error) {
 	url := fmt.Sprintf("%s/api/v0/properties?filters[Id]=%s", c.BaseURL, propertyID)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
@@ -37,14 +80,14 @@ func (c *AppFolioClient) GetProperty(ctx context.Context, propertyID string) (*m
 	}
 	c.setHeaders(req)
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := send(c.HTTPClient, req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("AppFolio API error (Property): %s", resp.Status)
+	if err := checkAppFolioStatus(resp, "Property"); err != nil {
+		return nil, err
 	}
 
 	var result models.AppFolioPropertyResponse
@@ -59,11 +102,143 @@ func (c *AppFolioClient) GetProperty(ctx context.Context, propertyID string) (*m
 	return &result.Data[0], nil
 }
 
+// SearchProperties lists the properties whose address matches address
+// (e.g. "123 main"), up to one page
+func (c *AppFolioClient) SearchProperties(ctx context.Context, address string) ([]models.AppFolioProperty, error) {
+	url := fmt.Sprintf("%s/api/v0/properties?filters[Address1]=%s&page[size]=50", c.BaseURL, neturl.QueryEscape(address))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.setHeaders(req)
+
+	resp, err := send(c.HTTPClient, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkAppFolioStatus(resp, "PropertySearch"); err != nil {
+		return nil, err
+	}
+
+	var result models.AppFolioPropertyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// appFolioPageSize is the page size of full listings
+const appFolioPageSize = 1000
+
+// ListProperties returns every property, page by page
+func (c *AppFolioClient) ListProperties(ctx context.Context) ([]models.AppFolioProperty, error) {
+	var all []models.AppFolioProperty
+	for page := 1; ; page++ {
+		var result models.AppFolioPropertyResponse
+		if err := c.getPage(ctx, "/api/v0/properties", page, "Properties", &result); err != nil {
+			return nil, err
+		}
+		all = append(all, result.Data...)
+		if len(result.Data) < appFolioPageSize {
+			return all, nil
+		}
+	}
+}
+
+// ListUnits returns every unit of every property, page by page
+func (c *AppFolioClient) ListUnits(ctx context.Context) ([]models.AppFolioUnit, error) {
+	var all []models.AppFolioUnit
+	for page := 1; ; page++ {
+		var result models.AppFolioUnitResponse
+		if err := c.getPage(ctx, "/api/v0/units", page, "Units", &result); err != nil {
+			return nil, err
+		}
+		all = append(all, result.Data...)
+		if len(result.Data) < appFolioPageSize {
+			return all, nil
+		}
+	}
+}
+
+// getPage decodes one page of a listing endpoint into out
+func (c *AppFolioClient) getPage(ctx context.Context, path string, page int, what string, out any) error {
+	url := fmt.Sprintf("%s%s?page[size]=%d&page[number]=%d", c.BaseURL, path, appFolioPageSize, page)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	c.setHeaders(req)
+
+	resp, err := send(c.HTTPClient, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkAppFolioStatus(resp, what); err != nil {
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// GetVacantUnits lists the property's vacant units
+func (c *AppFolioClient) GetVacantUnits(ctx context.Context, propertyID string) ([]models.AppFolioUnit, error) {
+	url := fmt.Sprintf("%s/api/v0/units?filters[PropertyId]=%s", c.BaseURL, neturl.QueryEscape(propertyID))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.setHeaders(req)
+
+	resp, err := send(c.HTTPClient, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkAppFolioStatus(resp, "Units"); err != nil {
+		return nil, err
+	}
+
+	var result models.AppFolioUnitResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	var vacant []models.AppFolioUnit
+	for _, unit := range result.Data {
+		if unit.Vacant {
+			vacant = append(vacant, unit)
+		}
+	}
+	return vacant, nil
+}
+
+// GetPropertyGroups fetches property groups, sharing the request with
+// concurrent lookups of the same groups
 func (c *AppFolioClient) GetPropertyGroups(ctx context.Context, ids []string) ([]models.AppFolioGroup, error) {
 	if len(ids) == 0 {
 		return nil, nil
 	}
 
+	groups, err, shared := groupLookups.Do(c.lookupKey(strings.Join(ids, ",")), func() ([]models.AppFolioGroup, error) {
+		return c.getPropertyGroups(context.WithoutCancel(ctx), ids)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if shared {
+		metrics.Incr(ctx, "LookupCoalesced", "Lookup", "AppFolioGroups")
+		groups = slices.Clone(groups)
+	}
+	return groups, nil
+}
+
+func (c *AppFolioClient) getPropertyGroups(ctx context.Context, ids []string) ([]models.AppFolioGroup, error) {
 	idsStr := strings.Join(ids, ",")
 	url := fmt.Sprintf("%s/api/v0/property_groups?filters[Id]=%s", c.BaseURL, idsStr)
 
@@ -73,14 +248,14 @@ func (c *AppFolioClient) GetPropertyGroups(ctx context.Context, ids []string) ([
 	}
 	c.setHeaders(req)
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := send(c.HTTPClient, req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("AppFolio API error (Groups): %s", resp.Status)
+	if err := checkAppFolioStatus(resp, "Groups"); err != nil {
+		return nil, err
 	}
 
 	var result models.AppFolioGroupResponse
@@ -91,6 +266,118 @@ func (c *AppFolioClient) GetPropertyGroups(ctx context.Context, ids []string) ([
 	return result.Data, nil
 }
 
+// LookupCaller finds the tenant, or failing that the prospect (guest card),
+// with the given phone number. It returns nil when neither matches.
+func (c *AppFolioClient) LookupCaller(ctx context.Context, phone string) (*models.Caller, error) {
+	digits := phoneDigits(phone)
+	if digits == "" {
+		return nil, nil
+	}
+
+	tenants, err := c.searchPeople(ctx, "tenants", "Tenants", digits)
+	if err != nil {
+		return nil, err
+	}
+	if len(tenants) > 0 {
+		return appFolioCaller(tenants[0], models.CallerTenant), nil
+	}
+
+	prospects, err := c.searchPeople(ctx, "guest_cards", "GuestCards", digits)
+	if err != nil {
+		return nil, err
+	}
+	if len(prospects) > 0 {
+		return appFolioCaller(prospects[0], models.CallerProspect), nil
+	}
+	return nil, nil
+}
+
+// searchPeople queries an AppFolio people endpoint by phone number
+func (c *AppFolioClient) searchPeople(ctx context.Context, endpoint, op, digits string) ([]models.AppFolioPerson, error) {
+	url := fmt.Sprintf("%s/api/v0/%s?filters[PhoneNumber]=%s", c.BaseURL, endpoint, digits)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.setHeaders(req)
+
+	resp, err := send(c.HTTPClient, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkAppFolioStatus(resp, op); err != nil {
+		return nil, err
+	}
+
+	var result models.AppFolioPersonResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+func appFolioCaller(p models.AppFolioPerson, kind string) *models.Caller {
+	return &models.Caller{
+		ID:         p.ID,
+		Name:       strings.TrimSpace(p.FirstName + " " + p.LastName),
+		Type:       kind,
+		PropertyID: p.PropertyID,
+		UnitID:     p.UnitID,
+	}
+}
+
+// phoneDigits reduces a phone number to its 10-digit US form, the format
+// AppFolio's phone filter matches on
+func phoneDigits(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	digits := b.String()
+	if len(digits) == 11 && digits[0] == '1' {
+		digits = digits[1:]
+	}
+	if len(digits) != 10 {
+		return ""
+	}
+	return digits
+}
+
+// CheckCredentials performs the cheapest authenticated request available
+// (a single-row property page) to validate the configured credentials.
+func (c *AppFolioClient) CheckCredentials(ctx context.Context) error {
+	url := fmt.Sprintf("%s/api/v0/properties?page[size]=1", c.BaseURL)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	c.setHeaders(req)
+
+	resp, err := send(c.HTTPClient, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkAppFolioStatus(resp, "Probe")
+}
+
+func checkAppFolioStatus(resp *http.Response, op string) error {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("%w: %w", ErrAppFolioCredentials, newAPIError(resp, fmt.Sprintf("AppFolio API error (%s)", op)))
+	}
+	return newAPIError(resp, fmt.Sprintf("AppFolio API error (%s)", op))
+}
+
 func (c *AppFolioClient) setHeaders(req *http.Request) {
 	req.Header.Set("Authorization", c.AuthHeader)
 	req.Header.Set("X-AppFolio-Developer-ID", c.DeveloperID)
@@ -0,0 +1,121 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go/service/bedrockruntime/bedrockruntimeiface"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/breaker"
+)
+
+// DefaultBedrockModel is Claude 3 Haiku, which is ample for short
+// classification prompts
+const DefaultBedrockModel = "anthropic.claude-3-haiku-20240307-v1:0"
+
+// BedrockClient runs completions on an Anthropic model through AWS Bedrock,
+// so inference stays inside AWS (IAM-authenticated, no public API key)
+type BedrockClient struct {
+	ModelID string
+	Runtime bedrockruntimeiface.BedrockRuntimeAPI
+	// ContentSampleRate is the fraction of calls whose prompt and completion
+	// are logged, at debug level only
+	ContentSampleRate float64
+}
+
+// NewBedrockClient returns a client for modelID in region (the session's
+// region if empty). Calls go through the "bedrock" breaker.
+func NewBedrockClient(sess *session.Session, region, modelID string) *BedrockClient {
+	cfg := aws.NewConfig().WithHTTPClient(&http.Client{Timeout: 30 * time.Second, Transport: breaker.Transport("bedrock", nil)})
+	if region != "" {
+		cfg = cfg.WithRegion(region)
+	}
+	if modelID == "" {
+		modelID = DefaultBedrockModel
+	}
+	return &BedrockClient{ModelID: modelID, Runtime: bedrockruntime.New(sess, cfg)}
+}
+
+func (c *BedrockClient) Name() string { return "bedrock" }
+
+// anthropicMessage is a message of the Anthropic messages API
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicRequest struct {
+	AnthropicVersion string             `json:"anthropic_version"`
+	MaxTokens        int                `json:"max_tokens"`
+	Temperature      float64            `json:"temperature"`
+	Messages         []anthropicMessage `json:"messages"`
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// Complete runs req on the model; see LLMProvider. Anthropic models have no
+// JSON mode, so a JSON request prefills the response with "{".
+func (c *BedrockClient) Complete(ctx context.Context, op string, chat ChatRequest) (string, error) {
+	messages := []anthropicMessage{{Role: "user", Content: chat.Prompt}}
+	if chat.JSON {
+		messages = append(messages, anthropicMessage{Role: "assistant", Content: "{"})
+	}
+	body, _ := json.Marshal(anthropicRequest{
+		AnthropicVersion: "bedrock-2023-05-31",
+		MaxTokens:        chat.MaxTokens,
+		Messages:         messages,
+	})
+
+	call := llmCall{Provider: c.Name(), Op: op, Model: c.ModelID, Prompt: chat.Prompt, SampleRate: c.ContentSampleRate}
+	start := time.Now()
+	out, err := c.Runtime.InvokeModelWithContext(ctx, &bedrockruntime.InvokeModelInput{
+		ModelId:     aws.String(c.ModelID),
+		ContentType: aws.String("application/json"),
+		Accept:      aws.String("application/json"),
+		Body:        body,
+	})
+	call.Latency = time.Since(start)
+	if err != nil {
+		call.Err = err
+		logLLMCall(ctx, call)
+		return "", fmt.Errorf("bedrock invoke: %w", err)
+	}
+
+	var result anthropicResponse
+	if err := json.Unmarshal(out.Body, &result); err != nil {
+		return "", err
+	}
+	var text strings.Builder
+	if chat.JSON {
+		text.WriteString("{")
+	}
+	for _, block := range result.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	call.Completion, call.FinishReason = text.String(), result.StopReason
+	call.PromptTokens, call.CompletionTokens = result.Usage.InputTokens, result.Usage.OutputTokens
+	logLLMCall(ctx, call)
+
+	if len(result.Content) == 0 {
+		return "", fmt.Errorf("no response from bedrock")
+	}
+	return call.Completion, nil
+}
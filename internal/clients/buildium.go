@@ -0,0 +1,95 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/breaker"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+// BuildiumClient reads rental properties and property groups from the Buildium API
+type BuildiumClient struct {
+	BaseURL      string
+	ClientID     string
+	ClientSecret string
+	HTTPClient   *http.Client
+}
+
+func NewBuildiumClient(clientID, clientSecret string) *BuildiumClient {
+	return &BuildiumClient{
+		BaseURL:      "https://api.buildium.com/v1",
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		HTTPClient:   xray.Client(&http.Client{Timeout: 10 * time.Second, Transport: breaker.Transport("buildium", nil)}),
+	}
+}
+
+func (c *BuildiumClient) Name() string { return "buildium" }
+
+// GetProperty fetches a rental property and the IDs of the groups it belongs to
+func (c *BuildiumClient) GetProperty(ctx context.Context, propertyID string) (*models.AppFolioProperty, error) {
+	var rental models.BuildiumRental
+	if err := c.get(ctx, "/rentals/"+url.PathEscape(propertyID), &rental); err != nil {
+		return nil, err
+	}
+
+	var groups []models.BuildiumPropertyGroup
+	if err := c.get(ctx, "/propertygroups?propertyids="+url.QueryEscape(propertyID), &groups); err != nil {
+		return nil, err
+	}
+
+	prop := &models.AppFolioProperty{
+		ID:       strconv.Itoa(rental.ID),
+		Name:     rental.Name,
+		Address1: rental.Address.AddressLine1,
+		City:     rental.Address.City,
+		State:    rental.Address.State,
+	}
+	for _, g := range groups {
+		prop.PropertyGroupIds = append(prop.PropertyGroupIds, strconv.Itoa(g.ID))
+	}
+	return prop, nil
+}
+
+// GetPropertyGroups fetches groups by ID
+func (c *BuildiumClient) GetPropertyGroups(ctx context.Context, ids []string) ([]models.AppFolioGroup, error) {
+	groups := make([]models.AppFolioGroup, 0, len(ids))
+	for _, id := range ids {
+		var g models.BuildiumPropertyGroup
+		if err := c.get(ctx, "/propertygroups/"+url.PathEscape(id), &g); err != nil {
+			return nil, err
+		}
+		groups = append(groups, models.AppFolioGroup{ID: strconv.Itoa(g.ID), Name: g.Name})
+	}
+	return groups, nil
+}
+
+func (c *BuildiumClient) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.BaseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("x-buildium-client-id", c.ClientID)
+	req.Header.Set("x-buildium-client-secret", c.ClientSecret)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := send(c.HTTPClient, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		op := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0]
+		return newAPIError(resp, fmt.Sprintf("Buildium API error (%s)", op))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
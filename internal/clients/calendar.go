@@ -6,9 +6,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	neturl "net/url"
 	"time"
 
 	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/breaker"
 	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
 )
 
@@ -18,7 +20,7 @@ type CalendarClient struct {
 
 func NewCalendarClient() *CalendarClient {
 	return &CalendarClient{
-		HTTPClient: xray.Client(&http.Client{Timeout: 15 * time.Second}),
+		HTTPClient: xray.Client(&http.Client{Timeout: 15 * time.Second, Transport: breaker.Transport("google_calendar", nil)}),
 	}
 }
 
@@ -28,7 +30,7 @@ func (c *CalendarClient) GetBusySlots(ctx context.Context, accessToken, email st
 	reqBody := models.FreeBusyRequest{
 		TimeMin:  timeMin.Format(time.RFC3339),
 		TimeMax:  timeMax.Format(time.RFC3339),
-		TimeZone: "America/Los_Angeles",
+		TimeZone: zoneName(timeMin),
 		Items:    []models.FreeBusyReqItem{{ID: email}},
 	}
 	jsonBody, _ := json.Marshal(reqBody)
@@ -41,14 +43,14 @@ func (c *CalendarClient) GetBusySlots(ctx context.Context, accessToken, email st
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := send(c.HTTPClient, req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Google Calendar API error: %s", resp.Status)
+		return nil, newAPIError(resp, "Google Calendar API error")
 	}
 
 	var result models.FreeBusyResponse
@@ -67,3 +69,178 @@ func (c *CalendarClient) GetBusySlots(ctx context.Context, accessToken, email st
 
 	return calendar.Busy, nil
 }
+
+// CreateEvent inserts an event on the given calendar and returns the created resource
+func (c *CalendarClient) CreateEvent(ctx context.Context, accessToken, calendarID string, event models.CalendarEvent) (*models.CalendarEvent, error) {
+	url := fmt.Sprintf("https://www.googleapis.com/calendar/v3/calendars/%s/events", neturl.PathEscape(calendarID))
+
+	jsonBody, _ := json.Marshal(event)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := send(c.HTTPClient, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp, "Google Calendar API error (Insert)")
+	}
+
+	var created models.CalendarEvent
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// DeleteEvent removes an event from the given calendar. A missing event is not an error.
+func (c *CalendarClient) DeleteEvent(ctx context.Context, accessToken, calendarID, eventID string) error {
+	url := fmt.Sprintf("https://www.googleapis.com/calendar/v3/calendars/%s/events/%s", neturl.PathEscape(calendarID), neturl.PathEscape(eventID))
+
+	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := send(c.HTTPClient, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		return nil
+	}
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return newAPIError(resp, "Google Calendar API error (Delete)")
+	}
+	return nil
+}
+
+// ListEvents returns the single (expanded) events on a calendar between
+// timeMin and timeMax, ordered by start time
+func (c *CalendarClient) ListEvents(ctx context.Context, accessToken, calendarID string, timeMin, timeMax time.Time) ([]models.CalendarEvent, error) {
+	query := neturl.Values{}
+	query.Set("timeMin", timeMin.Format(time.RFC3339))
+	query.Set("timeMax", timeMax.Format(time.RFC3339))
+	query.Set("singleEvents", "true")
+	query.Set("orderBy", "startTime")
+	query.Set("maxResults", "250")
+	query.Set("fields", "items(id,summary,description,location,start,end)")
+	url := fmt.Sprintf("https://www.googleapis.com/calendar/v3/calendars/%s/events?%s", neturl.PathEscape(calendarID), query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := send(c.HTTPClient, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp, "Google Calendar API error (List)")
+	}
+
+	var result struct {
+		Items []models.CalendarEvent `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return result.Items, nil
+}
+
+// ListWorkingHours returns the timed working-location entries on a
+// calendar between timeMin and timeMax. Google doesn't expose the
+// working-hours setting itself, but with working location enabled each
+// day's entry spans the hours the owner works; all-day entries carry no
+// hours and are skipped.
+func (c *CalendarClient) ListWorkingHours(ctx context.Context, accessToken, calendarID string, timeMin, timeMax time.Time) ([]models.TimeRange, error) {
+	query := neturl.Values{}
+	query.Set("timeMin", timeMin.Format(time.RFC3339))
+	query.Set("timeMax", timeMax.Format(time.RFC3339))
+	query.Set("singleEvents", "true")
+	query.Set("eventTypes", "workingLocation")
+	query.Set("maxResults", "250")
+	query.Set("fields", "items(start,end)")
+	url := fmt.Sprintf("https://www.googleapis.com/calendar/v3/calendars/%s/events?%s", neturl.PathEscape(calendarID), query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := send(c.HTTPClient, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp, "Google Calendar API error (WorkingHours)")
+	}
+
+	var result struct {
+		Items []models.CalendarEvent `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	var hours []models.TimeRange
+	for _, item := range result.Items {
+		start, okStart := item.Start.Time()
+		end, okEnd := item.End.Time()
+		if okStart && okEnd && end.After(start) {
+			hours = append(hours, models.TimeRange{Start: start, End: end})
+		}
+	}
+	return hours, nil
+}
+
+// PatchEvent updates the non-empty fields of an existing event
+func (c *CalendarClient) PatchEvent(ctx context.Context, accessToken, calendarID, eventID string, patch models.CalendarEvent) error {
+	url := fmt.Sprintf("https://www.googleapis.com/calendar/v3/calendars/%s/events/%s", neturl.PathEscape(calendarID), neturl.PathEscape(eventID))
+
+	jsonBody, _ := json.Marshal(patch)
+	req, err := http.NewRequestWithContext(ctx, "PATCH", url, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := send(c.HTTPClient, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newAPIError(resp, "Google Calendar API error (Patch)")
+	}
+	return nil
+}
+
+// zoneName returns t's IANA zone for Google's timeZone fields. Times decoded
+// from JSON carry only an offset, so those fall back to UTC (the API's
+// default); the RFC 3339 offsets keep the instants exact either way.
+func zoneName(t time.Time) string {
+	if name := t.Location().String(); name != "" && name != "Local" {
+		return name
+	}
+	return "UTC"
+}
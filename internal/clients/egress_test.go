@@ -0,0 +1,70 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/bedrockruntime"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/egress"
+)
+
+func TestClientsRefuseHostsOutsideAllowlist(t *testing.T) {
+	egress.SetAllowlist([]string{"allowed.example.com"})
+	t.Cleanup(func() { egress.SetAllowlist(nil) })
+
+	sess := session.Must(session.NewSession(aws.NewConfig().
+		WithRegion("us-east-1").
+		WithCredentials(credentials.NewStaticCredentials("id", "secret", ""))))
+	bedrock := NewBedrockClient(sess, "", "")
+
+	clients := map[string]*http.Client{
+		"appfolio":          NewAppFolioClient("auth", "dev").HTTPClient,
+		"bedrock":           bedrock.Runtime.(*bedrockruntime.BedrockRuntime).Client.Config.HTTPClient,
+		"buildium":          NewBuildiumClient("id", "secret").HTTPClient,
+		"calendar":          NewCalendarClient().HTTPClient,
+		"google_oauth":      NewGoogleOAuthClient("id", "secret").HTTPClient,
+		"identity":          NewIdentityClient("key").HTTPClient,
+		"listing_feed":      NewListingFeedClient().HTTPClient,
+		"maps":              NewMapsClient("key").HTTPClient,
+		"openai":            NewOpenAIClient("key").HTTPClient,
+		"openai_compatible": NewOpenAICompatibleClient("https://llm.example.com", "model", "key", nil).HTTPClient,
+		"pagerduty":         NewPagerDutyClient("key").HTTPClient,
+		"search":            NewSearchClient([]string{"https://search.example.com"}, 0, nil).Endpoints[0].HTTPClient,
+		"slack":             NewSlackClient("token").HTTPClient,
+		"smartlock":         NewSmartLockClient("https://lock.example.com", "key").HTTPClient,
+		"supabase":          NewSupabaseClient("project", "key").HTTPClient,
+		"supabase_replica":  NewSupabaseReplica("project", "key").HTTPClient,
+		"twilio":            NewTwilioClient("sid", "token", "+15550000000").HTTPClient,
+		"vapi":              NewVAPIClient("key").HTTPClient,
+		"webhook":           NewWebhookClient("secret").HTTPClient,
+		"yardi":             NewYardiClient("https://yardi.example.com", YardiCredentials{}).HTTPClient,
+	}
+	for name, client := range clients {
+		t.Run(name, func(t *testing.T) {
+			req, err := http.NewRequestWithContext(context.Background(), "GET", "https://denied.example.net/", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.Do(req)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if !errors.Is(err, egress.ErrDenied) {
+				t.Errorf("request to denied host: got %v, want %v", err, egress.ErrDenied)
+			}
+		})
+	}
+
+	t.Run("supabase_realtime", func(t *testing.T) {
+		err := NewSupabaseRealtime("project", "key").Listen(context.Background(), []string{"agents"}, func(RealtimeChange) {})
+		if !errors.Is(err, egress.ErrDenied) {
+			t.Errorf("realtime dial to denied host: got %v, want %v", err, egress.ErrDenied)
+		}
+	})
+}
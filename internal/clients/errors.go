@@ -0,0 +1,56 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// Errors every client reports in a form errors.Is can match, so callers pick
+// a fallback by kind instead of by message. *APIError matches them by status
+// code; transport timeouts are wrapped with ErrTimeout.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrTimeout      = errors.New("request timed out")
+	// ErrConflict means a conditional write lost to a concurrent one; re-read
+	// and retry
+	ErrConflict = errors.New("conflicting concurrent write")
+)
+
+// Is maps the response status onto the sentinel errors above
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.Code == http.StatusNotFound || e.Code == http.StatusGone
+	case ErrUnauthorized:
+		return e.Code == http.StatusUnauthorized || e.Code == http.StatusForbidden
+	case ErrRateLimited:
+		return e.Code == http.StatusTooManyRequests
+	case ErrTimeout:
+		return e.Code == http.StatusRequestTimeout || e.Code == http.StatusGatewayTimeout
+	case ErrConflict:
+		return e.Code == http.StatusConflict
+	}
+	return false
+}
+
+// send performs req on client, marking timeouts with ErrTimeout
+func send(client *http.Client, req *http.Request) (*http.Response, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, transportError(err)
+	}
+	return resp, nil
+}
+
+func transportError(err error) error {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+	return err
+}
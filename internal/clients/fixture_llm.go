@@ -0,0 +1,64 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FixtureProvider is the LLM provider for tests: it answers from fixtures
+// instead of a model, so end-to-end runs of the voice path are
+// deterministic and need no API key. Address matching returns the
+// candidate index fixed for the query (-1, no match, for unknown queries);
+// call summaries and lead scores are fixed.
+type FixtureProvider struct {
+	// Matches maps a query (compared case- and space-insensitively) to the
+	// index of the candidate it matches
+	Matches map[string]int
+}
+
+// FixtureProviderName is the LLM_PROVIDER value selecting FixtureProvider
+const FixtureProviderName = "fixture"
+
+// Fixed completions
+const (
+	fixtureSummary = `{"interest_level":"medium","objections":[],"preferred_move_in":"","summary":"Fixture call summary."}`
+	fixtureScore   = `{"tags":["warm"]}`
+)
+
+func NewFixtureProvider(matches map[string]int) *FixtureProvider {
+	normalized := make(map[string]int, len(matches))
+	for query, index := range matches {
+		normalized[fixtureKey(query)] = index
+	}
+	return &FixtureProvider{Matches: normalized}
+}
+
+func (p *FixtureProvider) Name() string { return FixtureProviderName }
+
+// Complete answers op from the fixtures; see LLMProvider. Address matching
+// keys on chat.Input, the caller's query.
+func (p *FixtureProvider) Complete(ctx context.Context, op string, chat ChatRequest) (string, error) {
+	var content string
+	switch op {
+	case "match_address":
+		index, ok := p.Matches[fixtureKey(chat.Input)]
+		if !ok {
+			index = -1
+		}
+		content = strconv.Itoa(index)
+	case "summarize_call":
+		content = fixtureSummary
+	case "score_lead":
+		content = fixtureScore
+	default:
+		return "", fmt.Errorf("fixture provider: no fixture for %s", op)
+	}
+	logLLMCall(ctx, llmCall{Provider: p.Name(), Op: op, Model: FixtureProviderName, Prompt: chat.Prompt, Completion: content})
+	return content, nil
+}
+
+func fixtureKey(query string) string {
+	return strings.Join(strings.Fields(strings.ToLower(query)), " ")
+}
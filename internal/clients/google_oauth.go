@@ -0,0 +1,158 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/breaker"
+)
+
+// ErrTokenInvalid is returned by TokenInfo for an expired or revoked access token
+var ErrTokenInvalid = errors.New("google access token invalid or expired")
+
+// ErrRefreshRevoked is returned by Refresh when the agent must re-authorize,
+// and by Exchange for an expired or already-used authorization code
+var ErrRefreshRevoked = errors.New("google refresh token revoked or expired")
+
+// GoogleOAuthClient talks to Google's OAuth 2.0 endpoints for agent calendar tokens
+type GoogleOAuthClient struct {
+	ClientID     string
+	ClientSecret string
+	AuthURL      string
+	TokenURL     string
+	TokenInfoURL string
+	HTTPClient   *http.Client
+}
+
+func NewGoogleOAuthClient(clientID, clientSecret string) *GoogleOAuthClient {
+	return &GoogleOAuthClient{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		AuthURL:      "https://accounts.google.com/o/oauth2/v2/auth",
+		TokenURL:     "https://oauth2.googleapis.com/token",
+		TokenInfoURL: "https://oauth2.googleapis.com/tokeninfo",
+		HTTPClient:   xray.Client(&http.Client{Timeout: 10 * time.Second, Transport: breaker.Transport("google_oauth", nil)}),
+	}
+}
+
+// TokenInfo describes a live access token
+type TokenInfo struct {
+	Email     string
+	Scope     string
+	ExpiresIn time.Duration
+}
+
+// TokenInfo introspects an access token without touching the agent's calendar
+func (c *GoogleOAuthClient) TokenInfo(ctx context.Context, accessToken string) (*TokenInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.TokenInfoURL+"?access_token="+neturl.QueryEscape(accessToken), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := send(c.HTTPClient, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusBadRequest {
+		return nil, ErrTokenInvalid
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp, "Google OAuth API error (TokenInfo)")
+	}
+
+	var info struct {
+		Email     string `json:"email"`
+		Scope     string `json:"scope"`
+		ExpiresIn string `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, err
+	}
+	seconds, _ := time.ParseDuration(info.ExpiresIn + "s")
+	return &TokenInfo{Email: info.Email, Scope: info.Scope, ExpiresIn: seconds}, nil
+}
+
+// AuthCodeURL returns the consent page an agent is sent to. It asks for
+// offline access and always prompts, so Google returns a refresh token even
+// for an agent re-authorizing.
+func (c *GoogleOAuthClient) AuthCodeURL(redirectURI, scope, state, loginHint string) string {
+	query := neturl.Values{}
+	query.Set("client_id", c.ClientID)
+	query.Set("redirect_uri", redirectURI)
+	query.Set("response_type", "code")
+	query.Set("scope", scope)
+	query.Set("state", state)
+	query.Set("access_type", "offline")
+	query.Set("prompt", "consent")
+	if loginHint != "" {
+		query.Set("login_hint", loginHint)
+	}
+	return c.AuthURL + "?" + query.Encode()
+}
+
+// Grant is the token set minted by the token endpoint. RefreshToken is only
+// set by Exchange.
+type Grant struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    time.Duration
+}
+
+// Exchange trades an authorization code from the consent redirect for tokens
+func (c *GoogleOAuthClient) Exchange(ctx context.Context, code, redirectURI string) (*Grant, error) {
+	form := neturl.Values{}
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURI)
+	form.Set("grant_type", "authorization_code")
+	return c.token(ctx, "Exchange", form)
+}
+
+// Refresh exchanges a refresh token for a new access token
+func (c *GoogleOAuthClient) Refresh(ctx context.Context, refreshToken string) (*Grant, error) {
+	form := neturl.Values{}
+	form.Set("refresh_token", refreshToken)
+	form.Set("grant_type", "refresh_token")
+	return c.token(ctx, "Refresh", form)
+}
+
+func (c *GoogleOAuthClient) token(ctx context.Context, op string, form neturl.Values) (*Grant, error) {
+	form.Set("client_id", c.ClientID)
+	form.Set("client_secret", c.ClientSecret)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := send(c.HTTPClient, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		ExpiresIn    int    `json:"expires_in"`
+		Error        string `json:"error"`
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+
+	if resp.StatusCode == http.StatusBadRequest && body.Error == "invalid_grant" {
+		return nil, ErrRefreshRevoked
+	}
+	if resp.StatusCode != http.StatusOK || body.AccessToken == "" {
+		return nil, fmt.Errorf("Google OAuth API error (%s): %s %s", op, resp.Status, body.Error)
+	}
+	return &Grant{AccessToken: body.AccessToken, RefreshToken: body.RefreshToken, ExpiresIn: time.Duration(body.ExpiresIn) * time.Second}, nil
+}
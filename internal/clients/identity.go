@@ -0,0 +1,138 @@
+package clients
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/breaker"
+)
+
+// StripeSignatureTolerance is how old a signed webhook may be before it is rejected
+const StripeSignatureTolerance = 5 * time.Minute
+
+// IdentityClient starts Stripe Identity document verifications for leads
+type IdentityClient struct {
+	BaseURL    string
+	SecretKey  string
+	HTTPClient *http.Client
+}
+
+func NewIdentityClient(secretKey string) *IdentityClient {
+	return &IdentityClient{
+		BaseURL:    "https://api.stripe.com/v1",
+		SecretKey:  secretKey,
+		HTTPClient: xray.Client(&http.Client{Timeout: 10 * time.Second, Transport: breaker.Transport("stripe", nil)}),
+	}
+}
+
+// VerificationSession is a Stripe Identity verification session
+type VerificationSession struct {
+	ID       string            `json:"id"`
+	Status   string            `json:"status"`
+	URL      string            `json:"url"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+// CreateVerificationSession starts a document + selfie check for the lead
+// identified by phone. The phone is echoed back in webhook metadata.
+func (c *IdentityClient) CreateVerificationSession(ctx context.Context, phone string) (*VerificationSession, error) {
+	form := url.Values{}
+	form.Set("type", "document")
+	form.Set("options[document][require_matching_selfie]", "true")
+	form.Set("metadata[phone]", phone)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"/identity/verification_sessions", strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+c.SecretKey)
+
+	resp, err := send(c.HTTPClient, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp, "Stripe API error (CreateVerificationSession)")
+	}
+
+	var session VerificationSession
+	if err := json.NewDecoder(resp.Body).Decode(&session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// IdentityEvent is a Stripe identity.verification_session.* webhook event
+type IdentityEvent struct {
+	Type    string
+	Session VerificationSession
+}
+
+// ParseIdentityEvent decodes a Stripe webhook payload. Returns false for
+// events that are not about identity verification sessions.
+func ParseIdentityEvent(payload []byte) (IdentityEvent, bool) {
+	var event struct {
+		Type string `json:"type"`
+		Data struct {
+			Object VerificationSession `json:"object"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return IdentityEvent{}, false
+	}
+	if !strings.HasPrefix(event.Type, "identity.verification_session.") || event.Data.Object.ID == "" {
+		return IdentityEvent{}, false
+	}
+	return IdentityEvent{Type: event.Type, Session: event.Data.Object}, true
+}
+
+// ValidateStripeSignature checks the Stripe-Signature header ("t=...,v1=...")
+// against the raw payload, rejecting signatures older than tolerance.
+func ValidateStripeSignature(secret, header string, payload []byte, tolerance time.Duration) bool {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "t":
+			timestamp = v
+		case "v1":
+			signatures = append(signatures, v)
+		}
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return false
+	}
+	if time.Since(time.Unix(ts, 0)) > tolerance {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	for _, sig := range signatures {
+		if hmac.Equal([]byte(expected), []byte(sig)) {
+			return true
+		}
+	}
+	return false
+}
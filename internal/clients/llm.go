@@ -0,0 +1,247 @@
+package clients
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/scrub"
+)
+
+// ChatRequest is a single-message completion
+type ChatRequest struct {
+	Prompt    string
+	MaxTokens int
+	// JSON asks for a JSON object response
+	JSON bool
+	// Input is the caller text the prompt was built around (after
+	// scrubbing); providers only use it for logging or fixtures
+	Input string
+	// MaxWait bounds how long the call may queue behind a rate limit
+	// before failing with ratelimit.ErrSaturated; zero waits as long as
+	// ctx allows
+	MaxWait time.Duration
+}
+
+// LLMProvider runs completions for the LLM features (address matching,
+// call summaries, lead scoring). Name is also its breaker name.
+type LLMProvider interface {
+	Name() string
+	// Complete runs req for op (e.g. "match_address") and returns the text
+	// of the response
+	Complete(ctx context.Context, op string, req ChatRequest) (string, error)
+}
+
+var (
+	_ LLMProvider = (*OpenAIClient)(nil)
+	_ LLMProvider = (*BedrockClient)(nil)
+	_ LLMProvider = (*FixtureProvider)(nil)
+)
+
+// LLMClient holds the prompts of the LLM features and runs them on a
+// provider
+type LLMClient struct {
+	Provider LLMProvider
+	// Scrubber masks personal data in caller text before it is sent; nil
+	// sends it as is
+	Scrubber *scrub.Scrubber
+	// MatchMaxWait is the ChatRequest.MaxWait of address matching, which a
+	// voice caller waits on
+	MatchMaxWait time.Duration
+}
+
+func NewLLMClient(provider LLMProvider, scrubber *scrub.Scrubber) *LLMClient {
+	return &LLMClient{Provider: provider, Scrubber: scrubber}
+}
+
+// scrub masks personal data in caller-supplied text (the field named by
+// input) and logs which kinds were found, never the text itself
+func (c *LLMClient) scrub(ctx context.Context, input, text string) string {
+	scrubbed, found := c.Scrubber.Scrub(text)
+	if len(found) == 0 {
+		return text
+	}
+	categories := make([]string, 0, len(found))
+	for category, n := range found {
+		categories = append(categories, category)
+		metrics.Record(ctx, "LLMInputScrubbed", float64(n), metrics.Count, "Category", category)
+	}
+	slices.Sort(categories)
+	slog.InfoContext(ctx, "llm_input_scrubbed", "input", input, "categories", categories)
+	return scrubbed
+}
+
+// llmCall is one completion, as logged by logLLMCall
+type llmCall struct {
+	Provider         string
+	Op               string
+	Model            string
+	Prompt           string
+	Completion       string
+	Latency          time.Duration
+	PromptTokens     int
+	CompletionTokens int
+	FinishReason     string
+	Err              error
+	// SampleRate is the fraction of calls whose content is logged at debug
+	SampleRate float64
+}
+
+// logLLMCall logs a completion as "llm_call" with its model, a hash of the
+// prompt, latency, token counts and finish reason, and meters LLMTokens.
+// The prompt and completion themselves are only logged for a SampleRate
+// sample of calls made at debug level.
+func logLLMCall(ctx context.Context, call llmCall) {
+	hash := sha256.Sum256([]byte(call.Prompt))
+	promptHash := hex.EncodeToString(hash[:6])
+	args := []any{
+		"provider", call.Provider,
+		"op", call.Op,
+		"model", call.Model,
+		"prompt_hash", promptHash,
+		"latency_ms", call.Latency.Milliseconds(),
+	}
+	if call.Err != nil {
+		slog.WarnContext(ctx, "llm_call", append(args, "error", call.Err)...)
+		return
+	}
+	slog.InfoContext(ctx, "llm_call", append(args,
+		"prompt_tokens", call.PromptTokens,
+		"completion_tokens", call.CompletionTokens,
+		"total_tokens", call.PromptTokens+call.CompletionTokens,
+		"finish_reason", call.FinishReason)...)
+
+	metrics.Record(ctx, "LLMTokens", float64(call.PromptTokens), metrics.Count, "Provider", call.Provider, "Op", call.Op, "Kind", "prompt")
+	metrics.Record(ctx, "LLMTokens", float64(call.CompletionTokens), metrics.Count, "Provider", call.Provider, "Op", call.Op, "Kind", "completion")
+	// OpenAI says "length", Anthropic "max_tokens"
+	if call.FinishReason == "length" || call.FinishReason == "max_tokens" {
+		metrics.Incr(ctx, "LLMTruncated", "Provider", call.Provider, "Op", call.Op)
+	}
+	if call.SampleRate > 0 && slog.Default().Enabled(ctx, slog.LevelDebug) && rand.Float64() < call.SampleRate {
+		slog.DebugContext(ctx, "llm_call_content", "op", call.Op, "prompt_hash", promptHash, "prompt", call.Prompt, "completion", call.Completion)
+	}
+}
+
+// AddressCandidate represents a property address option
+type AddressCandidate struct {
+	Index      int
+	Address1   string
+	PropertyId string
+}
+
+// MatchAddressToQuery uses the LLM to find the best matching address for a query
+func (c *LLMClient) MatchAddressToQuery(ctx context.Context, query string, candidates []AddressCandidate) (string, error) {
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("no address candidates provided")
+	}
+
+	query = c.scrub(ctx, "query", query)
+
+	// Build the prompt
+	addressList := ""
+	for i, cand := range candidates {
+		addressList += fmt.Sprintf("%d. %s\n", i, cand.Address1)
+	}
+
+	prompt := fmt.Sprintf(`Given the user's spoken query about a property address, find the best matching address from the list.
+
+User Query: "%s"
+
+Available Addresses:
+%sReturn ONLY the index number (0, 1, 2, etc.) of the best matching address. If no address matches at all, return -1.
+
+Important: The query may contain spoken numbers (like "eight twenty eight" for "828") or slight variations. Match based on the most likely intended address.`, query, addressList)
+
+	content, err := c.Provider.Complete(ctx, "match_address", ChatRequest{Prompt: prompt, MaxTokens: 10, Input: query, MaxWait: c.MatchMaxWait})
+	if err != nil {
+		return "", err
+	}
+
+	// Parse the index from response
+	var matchedIndex int
+	if _, err := fmt.Sscanf(content, "%d", &matchedIndex); err != nil {
+		return "", fmt.Errorf("failed to parse %s response: %s", c.Provider.Name(), content)
+	}
+
+	if matchedIndex < 0 || matchedIndex >= len(candidates) {
+		return "", fmt.Errorf("no matching address found")
+	}
+
+	return candidates[matchedIndex].PropertyId, nil
+}
+
+// SummarizeCall uses the LLM to summarize a call transcript into the
+// prospect's interest level, objections and preferred move-in
+func (c *LLMClient) SummarizeCall(ctx context.Context, transcript string) (*models.CallSummary, error) {
+	transcript = c.scrub(ctx, "transcript", transcript)
+	prompt := fmt.Sprintf(`Summarize this phone call between a rental property scheduling assistant and a prospective renter, for the leasing agent who will show them the property.
+
+Transcript:
+%s
+
+Return ONLY a JSON object with these fields:
+- "interest_level": "high", "medium" or "low"
+- "objections": list of concerns the prospect raised (price, location, pets, etc.), empty if none
+- "preferred_move_in": the move-in date or timeframe the prospect mentioned, empty if none
+- "summary": one or two sentences on what the prospect wants`, transcript)
+
+	content, err := c.Provider.Complete(ctx, "summarize_call", ChatRequest{Prompt: prompt, MaxTokens: 300, JSON: true, Input: transcript})
+	if err != nil {
+		return nil, err
+	}
+	var summary models.CallSummary
+	if err := json.Unmarshal([]byte(content), &summary); err != nil {
+		return nil, fmt.Errorf("failed to parse %s response: %s", c.Provider.Name(), content)
+	}
+	if summary.Objections == nil {
+		summary.Objections = []string{}
+	}
+	return &summary, nil
+}
+
+// ScoreLead uses the LLM to tag a prospect from their call transcript for
+// showing prioritization. Only tags in models.LeadTags are returned.
+func (c *LLMClient) ScoreLead(ctx context.Context, transcript string) ([]string, error) {
+	transcript = c.scrub(ctx, "transcript", transcript)
+	prompt := fmt.Sprintf(`Score this prospective renter from their phone call with a rental property scheduling assistant.
+
+Transcript:
+%s
+
+Return ONLY a JSON object {"tags": [...]} using these tags:
+- exactly one of "hot" (ready to rent soon), "warm" (interested but undecided) or "cold" (browsing or unlikely to rent)
+- "price-sensitive" if rent, fees or deposits are a concern
+- "asap-mover" if they need to move within about two weeks`, transcript)
+
+	content, err := c.Provider.Complete(ctx, "score_lead", ChatRequest{Prompt: prompt, MaxTokens: 50, JSON: true, Input: transcript})
+	if err != nil {
+		return nil, err
+	}
+	var scored struct {
+		Tags []string `json:"tags"`
+	}
+	if err := json.Unmarshal([]byte(content), &scored); err != nil {
+		return nil, fmt.Errorf("failed to parse %s response: %s", c.Provider.Name(), content)
+	}
+
+	tags := []string{}
+	for _, tag := range scored.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if slices.Contains(models.LeadTags, tag) && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	if !slices.ContainsFunc(tags, func(t string) bool { return t == models.LeadHot || t == models.LeadWarm || t == models.LeadCold }) {
+		return nil, fmt.Errorf("failed to parse %s response: %s", c.Provider.Name(), content)
+	}
+	return tags, nil
+}
@@ -0,0 +1,136 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/breaker"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/cache"
+)
+
+// GeocodeCacheTTL is how long a resolved address is reused; addresses don't move
+const GeocodeCacheTTL = 24 * time.Hour
+
+var geocodeCache = cache.New[string, LatLng](GeocodeCacheTTL)
+
+// LatLng is a WGS84 coordinate
+type LatLng struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// MapsClient wraps the Google Maps Geocoding and Distance Matrix APIs
+type MapsClient struct {
+	BaseURL    string
+	APIKey     string
+	HTTPClient *http.Client
+}
+
+func NewMapsClient(apiKey string) *MapsClient {
+	return &MapsClient{
+		BaseURL:    "https://maps.googleapis.com/maps/api",
+		APIKey:     apiKey,
+		HTTPClient: xray.Client(&http.Client{Timeout: 5 * time.Second, Transport: breaker.Transport("google_maps", nil)}),
+	}
+}
+
+// Geocode returns the coordinates of the best match for address
+func (c *MapsClient) Geocode(ctx context.Context, address string) (LatLng, error) {
+	key := strings.ToLower(strings.TrimSpace(address))
+	if loc, ok := geocodeCache.Get(key); ok {
+		return loc, nil
+	}
+
+	reqURL := fmt.Sprintf("%s/geocode/json?address=%s&key=%s", c.BaseURL, url.QueryEscape(address), url.QueryEscape(c.APIKey))
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return LatLng{}, err
+	}
+
+	resp, err := send(c.HTTPClient, req)
+	if err != nil {
+		return LatLng{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return LatLng{}, newAPIError(resp, "Geocoding API error")
+	}
+
+	var result struct {
+		Status  string `json:"status"`
+		Results []struct {
+			Geometry struct {
+				Location LatLng `json:"location"`
+			} `json:"geometry"`
+		} `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return LatLng{}, err
+	}
+	if result.Status != "OK" || len(result.Results) == 0 {
+		return LatLng{}, fmt.Errorf("no geocoding result for %q: %s", address, result.Status)
+	}
+
+	loc := result.Results[0].Geometry.Location
+	geocodeCache.Set(key, loc)
+	return loc, nil
+}
+
+// TravelTimes returns the driving duration from each origin to destination
+// using the Distance Matrix API. Origins that can't be routed are omitted.
+func (c *MapsClient) TravelTimes(ctx context.Context, origins []string, destination string) (map[string]time.Duration, error) {
+	if len(origins) == 0 {
+		return nil, nil
+	}
+
+	reqURL := fmt.Sprintf("%s/distancematrix/json?mode=driving&origins=%s&destinations=%s&key=%s", c.BaseURL,
+		url.QueryEscape(strings.Join(origins, "|")), url.QueryEscape(destination), url.QueryEscape(c.APIKey))
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := send(c.HTTPClient, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp, "Distance Matrix API error")
+	}
+
+	var result struct {
+		Status string `json:"status"`
+		Rows   []struct {
+			Elements []struct {
+				Status   string `json:"status"`
+				Duration struct {
+					Value int `json:"value"` // seconds
+				} `json:"duration"`
+			} `json:"elements"`
+		} `json:"rows"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if result.Status != "OK" {
+		return nil, fmt.Errorf("Distance Matrix API error: %s", result.Status)
+	}
+
+	durations := make(map[string]time.Duration, len(origins))
+	for i, row := range result.Rows {
+		if i >= len(origins) || len(row.Elements) == 0 || row.Elements[0].Status != "OK" {
+			continue
+		}
+		durations[origins[i]] = time.Duration(row.Elements[0].Duration.Value) * time.Second
+	}
+	return durations, nil
+}
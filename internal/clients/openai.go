@@ -2,116 +2,156 @@ package clients
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/breaker"
 	"github.com/vishnuanilkumar/go-scheduling-service/internal/ratelimit"
 )
 
+// OpenAIChatModel is the model completions use on OpenAI itself
+const OpenAIChatModel = "gpt-4o-mini"
+
+const openAIBaseURL = "https://api.openai.com/v1"
+
+// OpenAICompatibleProvider names LLM servers speaking the OpenAI chat
+// completions API (vLLM, Ollama, ...); it is also their breaker name
+const OpenAICompatibleProvider = "openai_compatible"
+
 type OpenAIClient struct {
-	APIKey     string
+	// BaseURL is the API root, e.g. "http://ollama.internal:11434/v1"
+	BaseURL string
+	Model   string
+	// APIKey is sent as a bearer token unless empty
+	APIKey string
+	// Headers are added to every request (e.g. a gateway's auth header)
+	Headers    map[string]string
 	HTTPClient *http.Client
+	// ContentSampleRate is the fraction of calls whose prompt and completion
+	// are logged, at debug level only; by default no content is logged
+	ContentSampleRate float64
+
+	provider string
 }
 
 func NewOpenAIClient(apiKey string) *OpenAIClient {
 	return &OpenAIClient{
+		BaseURL:    openAIBaseURL,
+		Model:      OpenAIChatModel,
 		APIKey:     apiKey,
-		HTTPClient: xray.Client(&http.Client{Timeout: 30 * time.Second}),
+		HTTPClient: xray.Client(&http.Client{Timeout: 30 * time.Second, Transport: breaker.Transport("openai", nil)}),
+		provider:   "openai",
 	}
 }
 
-// AddressCandidate represents a property address option
-type AddressCandidate struct {
-	Index      int
-	Address1   string
-	PropertyId string
-}
-
-// MatchAddressToQuery uses OpenAI to find the best matching address for a query
-func (c *OpenAIClient) MatchAddressToQuery(ctx context.Context, query string, candidates []AddressCandidate) (string, error) {
-	if len(candidates) == 0 {
-		return "", fmt.Errorf("no address candidates provided")
+// NewOpenAICompatibleClient returns a client for a self-hosted server
+// speaking the OpenAI API. It isn't subject to the OpenAI rate limit and
+// calls go through the OpenAICompatibleProvider breaker; the host must be
+// on the egress allowlist if one is set.
+func NewOpenAICompatibleClient(baseURL, model, apiKey string, headers map[string]string) *OpenAIClient {
+	return &OpenAIClient{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		Model:      model,
+		APIKey:     apiKey,
+		Headers:    headers,
+		HTTPClient: xray.Client(&http.Client{Timeout: 30 * time.Second, Transport: breaker.Transport(OpenAICompatibleProvider, nil)}),
+		provider:   OpenAICompatibleProvider,
 	}
+}
 
-	// Rate limit check
-	if err := ratelimit.WaitForOpenAI(ctx); err != nil {
-		return "", err
-	}
+func (c *OpenAIClient) Name() string { return c.provider }
+
+// chatResponse is the part of a chat completion response we use
+type chatResponse struct {
+	Model   string `json:"model"`
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+}
 
-	// Build the prompt
-	addressList := ""
-	for i, cand := range candidates {
-		addressList += fmt.Sprintf("%d. %s\n", i, cand.Address1)
+// Complete runs a chat completion on c.Model; see LLMProvider
+func (c *OpenAIClient) Complete(ctx context.Context, op string, chat ChatRequest) (string, error) {
+	if c.provider == "openai" {
+		wait := ratelimit.WaitForOpenAI
+		if chat.MaxWait > 0 {
+			wait = func(ctx context.Context) error { return ratelimit.TryOpenAI(ctx, chat.MaxWait) }
+		}
+		if err := wait(ctx); err != nil {
+			return "", err
+		}
 	}
 
-	prompt := fmt.Sprintf(`Given the user's spoken query about a property address, find the best matching address from the list.
-
-User Query: "%s"
-
-Available Addresses:
-%sReturn ONLY the index number (0, 1, 2, etc.) of the best matching address. If no address matches at all, return -1.
-
-Important: The query may contain spoken numbers (like "eight twenty eight" for "828") or slight variations. Match based on the most likely intended address.`, query, addressList)
-
-	// OpenAI API request
 	reqBody := map[string]interface{}{
-		"model": "gpt-4o-mini",
+		"model": c.Model,
 		"messages": []map[string]string{
-			{"role": "user", "content": prompt},
+			{"role": "user", "content": chat.Prompt},
 		},
-		"max_tokens":  10,
+		"max_tokens":  chat.MaxTokens,
 		"temperature": 0,
 	}
+	if chat.JSON {
+		reqBody["response_format"] = map[string]string{"type": "json_object"}
+	}
 
 	jsonBody, _ := json.Marshal(reqBody)
-	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"/chat/completions", bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return "", err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+	for k, v := range c.Headers {
+		req.Header.Set(k, v)
+	}
 
-	resp, err := c.HTTPClient.Do(req)
+	call := llmCall{Provider: c.Name(), Op: op, Model: c.Model, Prompt: chat.Prompt, SampleRate: c.ContentSampleRate}
+	start := time.Now()
+	resp, err := send(c.HTTPClient, req)
 	if err != nil {
+		call.Latency, call.Err = time.Since(start), err
+		logLLMCall(ctx, call)
 		return "", err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("OpenAI API error: %s", resp.Status)
-	}
-
-	var result struct {
-		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
-		} `json:"choices"`
+		apiErr := newAPIError(resp, fmt.Sprintf("%s API error", c.provider))
+		call.Latency, call.Err = time.Since(start), apiErr
+		logLLMCall(ctx, call)
+		return "", apiErr
 	}
 
+	var result chatResponse
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return "", err
 	}
-
-	if len(result.Choices) == 0 {
-		return "", fmt.Errorf("no response from OpenAI")
-	}
-
-	// Parse the index from response
-	content := result.Choices[0].Message.Content
-	var matchedIndex int
-	if _, err := fmt.Sscanf(content, "%d", &matchedIndex); err != nil {
-		return "", fmt.Errorf("failed to parse OpenAI response: %s", content)
+	call.Latency = time.Since(start)
+	call.Model = cmp.Or(result.Model, c.Model)
+	call.PromptTokens, call.CompletionTokens = result.Usage.PromptTokens, result.Usage.CompletionTokens
+	if len(result.Choices) > 0 {
+		call.Completion, call.FinishReason = result.Choices[0].Message.Content, result.Choices[0].FinishReason
 	}
+	logLLMCall(ctx, call)
 
-	if matchedIndex < 0 || matchedIndex >= len(candidates) {
-		return "", fmt.Errorf("no matching address found")
+	if len(result.Choices) == 0 {
+		return "", fmt.Errorf("no response from %s", c.provider)
 	}
-
-	return candidates[matchedIndex].PropertyId, nil
+	return call.Completion, nil
 }
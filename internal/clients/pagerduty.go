@@ -0,0 +1,80 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/egress"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/instrument"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/mtls"
+)
+
+type PagerDutyClient struct {
+	EventsURL  string
+	RoutingKey string
+	HTTPClient *http.Client
+}
+
+func NewPagerDutyClient(routingKey string) *PagerDutyClient {
+	return &PagerDutyClient{
+		EventsURL:  "https://events.pagerduty.com/v2/enqueue",
+		RoutingKey: routingKey,
+		HTTPClient: &http.Client{Timeout: 5 * time.Second, Transport: instrument.Transport("pagerduty", egress.Transport(mtls.Transport))},
+	}
+}
+
+// Alert is a PagerDuty Events API v2 trigger/resolve event
+type Alert struct {
+	DedupKey  string
+	Summary   string
+	Source    string
+	Component string
+	Severity  string // critical, error, warning, info
+	Resolve   bool
+	Details   map[string]interface{}
+}
+
+// SendAlert enqueues an alert event. Alerts sharing a DedupKey are grouped
+// into one incident; Resolve closes it.
+func (c *PagerDutyClient) SendAlert(ctx context.Context, alert Alert) error {
+	action := "trigger"
+	if alert.Resolve {
+		action = "resolve"
+	}
+
+	body := map[string]interface{}{
+		"routing_key":  c.RoutingKey,
+		"event_action": action,
+		"dedup_key":    alert.DedupKey,
+	}
+	if !alert.Resolve {
+		body["payload"] = map[string]interface{}{
+			"summary":        alert.Summary,
+			"source":         alert.Source,
+			"component":      alert.Component,
+			"severity":       alert.Severity,
+			"custom_details": alert.Details,
+		}
+	}
+	jsonBody, _ := json.Marshal(body)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.EventsURL, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := send(c.HTTPClient, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		return newAPIError(resp, "PagerDuty API error")
+	}
+	return nil
+}
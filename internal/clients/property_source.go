@@ -0,0 +1,54 @@
+package clients
+
+import (
+	"context"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+// PropertyDataSource provides property details and the property groups used
+// for agent routing. AppFolio's shapes are the canonical models; other
+// property-management systems map their records into them.
+type PropertyDataSource interface {
+	// Name identifies the source in logs and metrics
+	Name() string
+	GetProperty(ctx context.Context, propertyID string) (*models.AppFolioProperty, error)
+	GetPropertyGroups(ctx context.Context, ids []string) ([]models.AppFolioGroup, error)
+}
+
+// AgentDirectory is implemented by sources that assign leasing agents to
+// properties directly rather than through property groups.
+type AgentDirectory interface {
+	GetMarketingAgents(ctx context.Context, propertyID string) ([]models.AgentInfo, error)
+}
+
+// PropertySearcher is implemented by sources that can list properties by
+// street address, for matching queries the search service couldn't.
+type PropertySearcher interface {
+	// SearchProperties returns the properties whose Address1 matches
+	// address under the source's own filter rules; callers should check
+	// the results themselves
+	SearchProperties(ctx context.Context, address string) ([]models.AppFolioProperty, error)
+}
+
+// UnitDirectory is implemented by sources that list a property's units, so
+// a showing can be booked for one unit of a multi-unit property.
+type UnitDirectory interface {
+	GetVacantUnits(ctx context.Context, propertyID string) ([]models.AppFolioUnit, error)
+}
+
+// CallerDirectory is implemented by sources that can identify a caller
+// (tenant or prospect) by phone number.
+type CallerDirectory interface {
+	LookupCaller(ctx context.Context, phone string) (*models.Caller, error)
+}
+
+var (
+	_ PropertyDataSource = (*AppFolioClient)(nil)
+	_ PropertyDataSource = (*BuildiumClient)(nil)
+	_ PropertyDataSource = (*YardiClient)(nil)
+	_ AgentDirectory     = (*YardiClient)(nil)
+	_ CallerDirectory    = (*AppFolioClient)(nil)
+	_ PropertySearcher   = (*AppFolioClient)(nil)
+	_ UnitDirectory      = (*AppFolioClient)(nil)
+)
@@ -4,23 +4,50 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/breaker"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/instrument"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
 )
 
+// SearchClient queries the search service. With several endpoints it fails
+// over to the next one on a transport error or 5xx, and hedges: if no
+// endpoint has answered within HedgeDelay, the next one is asked too, and
+// the first answer wins.
 type SearchClient struct {
-	SearchLambdaURL string
-	HTTPClient      *http.Client
+	Endpoints []SearchEndpoint
+	// HedgeDelay of zero disables hedging; endpoints are then only tried
+	// after a failure
+	HedgeDelay time.Duration
+	Signer     RequestSigner // nil sends unauthenticated requests
 }
 
-func NewSearchClient(url string) *SearchClient {
-	return &SearchClient{
-		SearchLambdaURL: url,
-		HTTPClient:      xray.Client(&http.Client{Timeout: 15 * time.Second}),
+// SearchEndpoint is one deployment of the search service. Each has its own
+// breaker: "search" for the first, "search2", "search3"... for the rest.
+type SearchEndpoint struct {
+	URL        string
+	HTTPClient *http.Client
+}
+
+func NewSearchClient(urls []string, hedgeDelay time.Duration, signer RequestSigner) *SearchClient {
+	c := &SearchClient{HedgeDelay: hedgeDelay, Signer: signer}
+	for i, url := range urls {
+		name := "search"
+		if i > 0 {
+			name = fmt.Sprintf("search%d", i+1)
+		}
+		c.Endpoints = append(c.Endpoints, SearchEndpoint{
+			URL:        url,
+			HTTPClient: xray.Client(&http.Client{Timeout: 15 * time.Second, Transport: breaker.Transport(name, nil)}),
+		})
 	}
+	return c
 }
 
 type SearchResponse struct {
@@ -40,26 +67,10 @@ func (c *SearchClient) FindPropertyID(ctx context.Context, query string) (string
 	}
 	jsonBody, _ := json.Marshal(body)
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.SearchLambdaURL, bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return "", err
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.HTTPClient.Do(req)
+	result, err := c.search(ctx, jsonBody)
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("search service error: %s", resp.Status)
-	}
-
-	var result SearchResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", err
-	}
 
 	if len(result.Results) == 0 {
 		return "", fmt.Errorf("no property found for query: %s", query)
@@ -88,3 +99,98 @@ func (c *SearchClient) FindPropertyID(ctx context.Context, query string) (string
 
 	return "", fmt.Errorf("property ID missing in search result")
 }
+
+// searchAttempt is the outcome of one endpoint's request
+type searchAttempt struct {
+	endpoint int
+	result   *SearchResponse
+	err      error
+}
+
+// search posts body to the endpoints in order until one answers. The next
+// endpoint is asked as soon as the last one asked fails over, or once
+// HedgeDelay passes without an answer. The first answer wins and the
+// requests still in flight are cancelled. An error that another endpoint
+// would repeat (4xx) is returned at once.
+func (c *SearchClient) search(ctx context.Context, body []byte) (*SearchResponse, error) {
+	if len(c.Endpoints) == 0 {
+		return nil, fmt.Errorf("search service: no endpoints configured")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	attempts := make(chan searchAttempt, len(c.Endpoints))
+	next, inFlight := 0, 0
+	launch := func() {
+		i := next
+		next++
+		inFlight++
+		go func() {
+			result, err := c.post(instrument.WithRetry(ctx, i), c.Endpoints[i], body)
+			attempts <- searchAttempt{endpoint: i, result: result, err: err}
+		}()
+	}
+	launch()
+
+	var errs []error
+	for {
+		var hedge <-chan time.Time
+		if next < len(c.Endpoints) && c.HedgeDelay > 0 {
+			hedge = time.After(c.HedgeDelay)
+		}
+		select {
+		case <-hedge:
+			slog.InfoContext(ctx, "search_hedged", "endpoint", next)
+			metrics.Incr(ctx, "SearchHedged")
+			launch()
+		case a := <-attempts:
+			inFlight--
+			if a.err == nil {
+				if a.endpoint > 0 {
+					metrics.Incr(ctx, "SearchServedByAlternate")
+				}
+				return a.result, nil
+			}
+			if !failoverable(a.err) {
+				return nil, a.err
+			}
+			slog.WarnContext(ctx, "search_endpoint_failed", "endpoint", a.endpoint, "error", a.err)
+			errs = append(errs, a.err)
+			if next < len(c.Endpoints) {
+				launch()
+			} else if inFlight == 0 {
+				return nil, errors.Join(errs...)
+			}
+		}
+	}
+}
+
+// post sends one search request to endpoint
+func (c *SearchClient) post(ctx context.Context, endpoint SearchEndpoint, body []byte) (*SearchResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.Signer != nil {
+		if err := c.Signer.Sign(req, body); err != nil {
+			return nil, fmt.Errorf("search request signing: %w", err)
+		}
+	}
+
+	resp, err := send(endpoint.HTTPClient, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp, "search service error")
+	}
+
+	var result SearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
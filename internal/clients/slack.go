@@ -0,0 +1,70 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-xray-sdk-go/xray"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/egress"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/instrument"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/mtls"
+)
+
+type SlackClient struct {
+	BaseURL    string
+	BotToken   string
+	HTTPClient *http.Client
+}
+
+func NewSlackClient(botToken string) *SlackClient {
+	return &SlackClient{
+		BaseURL:    "https://slack.com/api",
+		BotToken:   botToken,
+		HTTPClient: xray.Client(&http.Client{Timeout: 5 * time.Second, Transport: instrument.Transport("slack", egress.Transport(mtls.Transport))}),
+	}
+}
+
+// PostMessage posts a plain-text message to a channel via chat.postMessage
+func (c *SlackClient) PostMessage(ctx context.Context, channel, text string) error {
+	body := map[string]interface{}{
+		"channel":      channel,
+		"text":         text,
+		"unfurl_links": false,
+	}
+	jsonBody, _ := json.Marshal(body)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"/chat.postMessage", bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+c.BotToken)
+
+	resp, err := send(c.HTTPClient, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newAPIError(resp, "Slack API error")
+	}
+
+	// Slack reports most failures with a 200 and ok=false
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	if !result.OK {
+		return fmt.Errorf("Slack API error: %s", result.Error)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/breaker"
+)
+
+// SmartLockClient issues time-bound access codes through a lockbox/smart-lock
+// provider's REST API (CodeBox-style: codes are created per lock with a
+// validity window and revoked by ID).
+type SmartLockClient struct {
+	BaseURL    string
+	APIKey     string
+	HTTPClient *http.Client
+}
+
+func NewSmartLockClient(baseURL, apiKey string) *SmartLockClient {
+	return &SmartLockClient{
+		BaseURL:    baseURL,
+		APIKey:     apiKey,
+		HTTPClient: xray.Client(&http.Client{Timeout: 10 * time.Second, Transport: breaker.Transport("smart_lock", nil)}),
+	}
+}
+
+// AccessCode is an issued lock code
+type AccessCode struct {
+	ID       string    `json:"id"`
+	Code     string    `json:"code"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+// CreateAccessCode issues a code for lockID that only works between startsAt and endsAt
+func (c *SmartLockClient) CreateAccessCode(ctx context.Context, lockID, label string, startsAt, endsAt time.Time) (*AccessCode, error) {
+	body := map[string]interface{}{
+		"name":      label,
+		"starts_at": startsAt.UTC().Format(time.RFC3339),
+		"ends_at":   endsAt.UTC().Format(time.RFC3339),
+	}
+	jsonBody, _ := json.Marshal(body)
+
+	reqURL := fmt.Sprintf("%s/locks/%s/access_codes", c.BaseURL, url.PathEscape(lockID))
+	req, err := http.NewRequestWithContext(ctx, "POST", reqURL, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+
+	resp, err := send(c.HTTPClient, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, newAPIError(resp, "smart lock API error (Create)")
+	}
+
+	var code AccessCode
+	if err := json.NewDecoder(resp.Body).Decode(&code); err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+// RevokeAccessCode deletes an issued code. A missing code is not an error.
+func (c *SmartLockClient) RevokeAccessCode(ctx context.Context, lockID, codeID string) error {
+	reqURL := fmt.Sprintf("%s/locks/%s/access_codes/%s", c.BaseURL, url.PathEscape(lockID), url.PathEscape(codeID))
+	req, err := http.NewRequestWithContext(ctx, "DELETE", reqURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+
+	resp, err := send(c.HTTPClient, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return newAPIError(resp, "smart lock API error (Revoke)")
+	}
+	return nil
+}
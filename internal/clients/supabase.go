@@ -1,63 +1,668 @@
 package clients
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/breaker"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/cache"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/inflight"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/instrument"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
 )
 
+const (
+	// TokenCacheTTL bounds how long a warm container reuses an access token
+	// without re-reading Supabase (realtime updates invalidate sooner).
+	TokenCacheTTL = 2 * time.Minute
+	// MissingTokenCacheTTL bounds how long "no token found" is remembered,
+	// so a burst of calls for an unonboarded agent reads Supabase once
+	MissingTokenCacheTTL = 30 * time.Second
+	// AgentRosterCacheTTL bounds how long the agents table is reused
+	AgentRosterCacheTTL = 5 * time.Minute
+)
+
+// PropertySettingsCacheTTL bounds how long per-property settings are reused
+const PropertySettingsCacheTTL = 5 * time.Minute
+
+var (
+	tokenCache = cache.New[string, string](TokenCacheTTL)
+	// missingTokenCache remembers agents without a token row
+	missingTokenCache = cache.New[string, struct{}](MissingTokenCacheTTL)
+	rosterCache       = cache.New[string, []models.AgentInfo](AgentRosterCacheTTL)
+	settingsCache     = cache.New[string, models.PropertySettings](PropertySettingsCacheTTL)
+	// overrideCache holds nil for properties without an override
+	overrideCache = cache.New[string, *models.PropertyAgentOverride](PropertySettingsCacheTTL)
+	// tokenLookups shares one read between concurrent cache misses for an agent
+	tokenLookups = inflight.New[string](0)
+)
+
+// ErrTokenNotFound means the agent has no stored calendar token
+var ErrTokenNotFound = errors.New("no token found")
+
+// InvalidateAccessToken drops the cached token, or cached absence of one,
+// for an agent (e.g. after a refresh or re-authorization), in every project
+func InvalidateAccessToken(email string) {
+	suffix := "|" + strings.ToLower(email)
+	match := func(key string) bool { return strings.HasSuffix(key, suffix) }
+	tokenCache.DeleteMatching(match)
+	missingTokenCache.DeleteMatching(match)
+}
+
+// InvalidateAgentRoster drops the cached agents table
+func InvalidateAgentRoster() {
+	rosterCache.Purge()
+}
+
+// InvalidatePropertyAgentOverrides drops the cached property → agent overrides
+func InvalidatePropertyAgentOverrides() {
+	overrideCache.Purge()
+}
+
 type SupabaseClient struct {
 	BaseURL    string
 	APIKey     string
 	HTTPClient *http.Client
+
+	// Fallback serves reads when this project is unreachable or returns a
+	// 5xx (e.g. a read replica or backup project in another region).
+	// Writes never fail over.
+	Fallback *SupabaseClient
+	// OnFailover, if set, is called each time a read is retried on Fallback
+	OnFailover func(ctx context.Context, path string, err error)
 }
 
 func NewSupabaseClient(projectID, apiKey string) *SupabaseClient {
 	return &SupabaseClient{
 		BaseURL:    fmt.Sprintf("https://%s.supabase.co/rest/v1", projectID),
 		APIKey:     apiKey,
-		HTTPClient: xray.Client(&http.Client{Timeout: 10 * time.Second}),
+		HTTPClient: xray.Client(&http.Client{Timeout: 10 * time.Second, Transport: breaker.Transport("supabase", nil)}),
+	}
+}
+
+// NewSupabaseReplica returns a read-only client for a fallback project,
+// with its own circuit breaker so the primary's failures don't trip it
+func NewSupabaseReplica(projectID, apiKey string) *SupabaseClient {
+	return &SupabaseClient{
+		BaseURL:    fmt.Sprintf("https://%s.supabase.co/rest/v1", projectID),
+		APIKey:     apiKey,
+		HTTPClient: xray.Client(&http.Client{Timeout: 10 * time.Second, Transport: breaker.Transport("supabase_replica", nil)}),
+	}
+}
+
+// cacheKey scopes a warm-cache key to this client's project, since
+// tenants with data residency use their own projects
+func (c *SupabaseClient) cacheKey(key string) string {
+	return c.BaseURL + "|" + key
+}
+
+// failoverable reports whether a read error is worth retrying on another
+// project: transport failures (including an open breaker) and 5xx.
+func failoverable(err error) bool {
+	var status *APIError
+	if errors.As(err, &status) {
+		return status.Code >= 500
 	}
+	return !errors.Is(err, context.Canceled)
 }
 
 type OAuthToken struct {
-	AccessToken string `json:"access_token"`
-	Email       string `json:"email"`
+	AccessToken  string `json:"access_token"`
+	Email        string `json:"email"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	// Status is "invalid" once the token can no longer be refreshed
+	Status      string     `json:"status,omitempty"`
+	ValidatedAt *time.Time `json:"validated_at,omitempty"`
 }
 
+// OAuth token statuses
+const (
+	OAuthTokenValid   = "valid"
+	OAuthTokenInvalid = "invalid"
+)
+
+// GetAccessToken returns the agent's calendar access token, read through the
+// warm-container cache. A missing token is cached too, for a shorter time,
+// and reported as ErrTokenNotFound.
 func (c *SupabaseClient) GetAccessToken(ctx context.Context, email string) (string, error) {
-	url := fmt.Sprintf("%s/oauth_tokens?email=eq.%s&select=access_token", c.BaseURL, email)
+	key := c.cacheKey(strings.ToLower(email))
+	if token, ok := tokenCache.Get(key); ok {
+		return token, nil
+	}
+	if _, ok := missingTokenCache.Get(key); ok {
+		return "", fmt.Errorf("%w for email: %s", ErrTokenNotFound, email)
+	}
+
+	token, err, shared := tokenLookups.Do(key, func() (string, error) {
+		var tokens []OAuthToken
+		if err := c.do(context.WithoutCancel(ctx), "GET", fmt.Sprintf("/oauth_tokens?email=eq.%s&select=access_token", email), nil, "", &tokens); err != nil {
+			return "", err
+		}
+
+		if len(tokens) == 0 {
+			missingTokenCache.Set(key, struct{}{})
+			return "", fmt.Errorf("%w for email: %s", ErrTokenNotFound, email)
+		}
+
+		tokenCache.Set(key, tokens[0].AccessToken)
+		return tokens[0].AccessToken, nil
+	})
+	if shared {
+		metrics.Incr(ctx, "LookupCoalesced", "Lookup", "AccessToken")
+	}
+	return token, err
+}
+
+// ListOAuthTokens returns every stored agent token, including refresh tokens
+func (c *SupabaseClient) ListOAuthTokens(ctx context.Context) ([]OAuthToken, error) {
+	var tokens []OAuthToken
+	if err := c.do(ctx, "GET", "/oauth_tokens?select=email,access_token,refresh_token,status,validated_at", nil, "", &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// SaveOAuthToken creates or replaces an agent's token row (e.g. after they
+// authorize calendar access)
+func (c *SupabaseClient) SaveOAuthToken(ctx context.Context, token OAuthToken) error {
+	token.Email = strings.ToLower(token.Email)
+	if err := c.do(ctx, "POST", "/oauth_tokens?on_conflict=email", token, "resolution=merge-duplicates,return=minimal", nil); err != nil {
+		return err
+	}
+	InvalidateAccessToken(token.Email)
+	return nil
+}
+
+// MarkOAuthToken records a validation result on an agent's token row,
+// replacing the access token when accessToken is non-empty
+func (c *SupabaseClient) MarkOAuthToken(ctx context.Context, email, status, accessToken string, validatedAt time.Time) error {
+	patch := map[string]interface{}{"status": status, "validated_at": validatedAt}
+	if accessToken != "" {
+		patch["access_token"] = accessToken
+	}
+	path := fmt.Sprintf("/oauth_tokens?email=eq.%s", url.QueryEscape(email))
+	if err := c.do(ctx, "PATCH", path, patch, "return=minimal", nil); err != nil {
+		return err
+	}
+	InvalidateAccessToken(email)
+	return nil
+}
+
+// ListAgents returns the active leasing agents from the agents table
+func (c *SupabaseClient) ListAgents(ctx context.Context) ([]models.AgentInfo, error) {
+	if agents, ok := rosterCache.Get(c.cacheKey("agents")); ok {
+		return agents, nil
+	}
+
+	var agents []models.AgentInfo
+	if err := c.do(ctx, "GET", "/agents?active=eq.true&select=id,name,email,zone,vacationUntil:vacation_until,backupEmail:backup_email", nil, "", &agents); err != nil {
+		return nil, err
+	}
+
+	rosterCache.Set(c.cacheKey("agents"), agents)
+	return agents, nil
+}
+
+// GetPropertySettings returns the scheduling settings for a property. A
+// property without a row gets zero-value (default) settings.
+func (c *SupabaseClient) GetPropertySettings(ctx context.Context, propertyID string) (models.PropertySettings, error) {
+	if settings, ok := settingsCache.Get(c.cacheKey(propertyID)); ok {
+		return settings, nil
+	}
+
+	path := fmt.Sprintf("/property_settings?property_id=eq.%s&select=*", url.QueryEscape(propertyID))
+	var rows []models.PropertySettings
+	if err := c.do(ctx, "GET", path, nil, "", &rows); err != nil {
+		return models.PropertySettings{}, err
+	}
+
+	settings := models.PropertySettings{PropertyID: propertyID}
+	if len(rows) > 0 {
+		settings = rows[0]
+	}
+	settingsCache.Set(c.cacheKey(propertyID), settings)
+	return settings, nil
+}
+
+// GetPropertyAgentOverride returns the property's explicit agent
+// assignment, or nil if it has none
+func (c *SupabaseClient) GetPropertyAgentOverride(ctx context.Context, propertyID string) (*models.PropertyAgentOverride, error) {
+	if override, ok := overrideCache.Get(c.cacheKey(propertyID)); ok {
+		return override, nil
+	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	path := fmt.Sprintf("/property_agent_overrides?property_id=eq.%s&select=*", url.QueryEscape(propertyID))
+	var rows []models.PropertyAgentOverride
+	if err := c.do(ctx, "GET", path, nil, "", &rows); err != nil {
+		return nil, err
+	}
+
+	var override *models.PropertyAgentOverride
+	if len(rows) > 0 {
+		override = &rows[0]
+	}
+	overrideCache.Set(c.cacheKey(propertyID), override)
+	return override, nil
+}
+
+// ListPropertyAgentOverrides returns every property → agent override
+func (c *SupabaseClient) ListPropertyAgentOverrides(ctx context.Context) ([]models.PropertyAgentOverride, error) {
+	var rows []models.PropertyAgentOverride
+	if err := c.do(ctx, "GET", "/property_agent_overrides?select=*&order=property_id", nil, "", &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// SavePropertyAgentOverride creates or replaces the override for override.PropertyID
+func (c *SupabaseClient) SavePropertyAgentOverride(ctx context.Context, override models.PropertyAgentOverride) error {
+	override.UpdatedAt = time.Now().UTC()
+	if err := c.do(ctx, "POST", "/property_agent_overrides?on_conflict=property_id", override, "resolution=merge-duplicates,return=minimal", nil); err != nil {
+		return err
+	}
+	overrideCache.Delete(c.cacheKey(override.PropertyID))
+	return nil
+}
+
+// DeletePropertyAgentOverride returns a property to zone-based assignment
+func (c *SupabaseClient) DeletePropertyAgentOverride(ctx context.Context, propertyID string) error {
+	path := fmt.Sprintf("/property_agent_overrides?property_id=eq.%s", url.QueryEscape(propertyID))
+	if err := c.do(ctx, "DELETE", path, nil, "return=minimal", nil); err != nil {
+		return err
+	}
+	overrideCache.Delete(c.cacheKey(propertyID))
+	return nil
+}
+
+// OnDoNotContact reports whether phone is on tenantID's do-not-contact list
+// (or the list shared by all tenants). With no tenantID, an entry for any
+// tenant counts.
+func (c *SupabaseClient) OnDoNotContact(ctx context.Context, tenantID, phone string) (bool, error) {
+	path := fmt.Sprintf("/do_not_contact?phone=eq.%s&select=tenant_id", url.QueryEscape(phone))
+	var rows []models.DoNotContact
+	if err := c.do(ctx, "GET", path, nil, "", &rows); err != nil {
+		return false, err
+	}
+	for _, row := range rows {
+		if tenantID == "" || row.TenantID == "" || row.TenantID == tenantID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ListDoNotContact returns the do-not-contact entries, limited to tenantID's
+// when it is set
+func (c *SupabaseClient) ListDoNotContact(ctx context.Context, tenantID string) ([]models.DoNotContact, error) {
+	path := "/do_not_contact?select=*&order=created_at.desc"
+	if tenantID != "" {
+		path += "&tenant_id=eq." + url.QueryEscape(tenantID)
+	}
+	var rows []models.DoNotContact
+	if err := c.do(ctx, "GET", path, nil, "", &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// AddDoNotContact adds entry.Phone to entry.TenantID's do-not-contact list
+func (c *SupabaseClient) AddDoNotContact(ctx context.Context, entry models.DoNotContact) error {
+	entry.CreatedAt = time.Now().UTC()
+	return c.do(ctx, "POST", "/do_not_contact?on_conflict=tenant_id,phone", entry, "resolution=ignore-duplicates,return=minimal", nil)
+}
+
+// RemoveDoNotContact takes phone off tenantID's do-not-contact list
+func (c *SupabaseClient) RemoveDoNotContact(ctx context.Context, tenantID, phone string) error {
+	path := fmt.Sprintf("/do_not_contact?tenant_id=eq.%s&phone=eq.%s", url.QueryEscape(tenantID), url.QueryEscape(phone))
+	return c.do(ctx, "DELETE", path, nil, "return=minimal", nil)
+}
+
+// GetFeedListing returns the ingested listings-feed row for a property, or nil if none exists
+func (c *SupabaseClient) GetFeedListing(ctx context.Context, propertyID string) (*models.FeedListing, error) {
+	path := fmt.Sprintf("/listing_feed?property_id=eq.%s&select=*&order=ingested_at.desc&limit=1", url.QueryEscape(propertyID))
+
+	var listings []models.FeedListing
+	if err := c.do(ctx, "GET", path, nil, "", &listings); err != nil {
+		return nil, err
+	}
+	if len(listings) == 0 {
+		return nil, nil
+	}
+	return &listings[0], nil
+}
+
+// UpsertFeedListings writes ingested listings keyed by (property_id, source)
+func (c *SupabaseClient) UpsertFeedListings(ctx context.Context, listings []models.FeedListing) error {
+	if len(listings) == 0 {
+		return nil
+	}
+	return c.do(ctx, "POST", "/listing_feed?on_conflict=property_id,source", listings, "resolution=merge-duplicates,return=minimal", nil)
+}
+
+// ListPropertySnapshots returns every stored property snapshot
+func (c *SupabaseClient) ListPropertySnapshots(ctx context.Context) ([]models.PropertySnapshot, error) {
+	var snapshots []models.PropertySnapshot
+	if err := c.do(ctx, "GET", "/property_snapshots?select=*", nil, "", &snapshots); err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+// UpsertPropertySnapshots writes snapshots keyed by property_id
+func (c *SupabaseClient) UpsertPropertySnapshots(ctx context.Context, snapshots []models.PropertySnapshot) error {
+	if len(snapshots) == 0 {
+		return nil
+	}
+	return c.do(ctx, "POST", "/property_snapshots?on_conflict=property_id", snapshots, "resolution=merge-duplicates,return=minimal", nil)
+}
+
+// DeletePropertySnapshots removes the snapshots of properties the property
+// system no longer has
+func (c *SupabaseClient) DeletePropertySnapshots(ctx context.Context, propertyIDs []string) error {
+	if len(propertyIDs) == 0 {
+		return nil
+	}
+	path := fmt.Sprintf("/property_snapshots?property_id=in.(%s)", url.QueryEscape(strings.Join(propertyIDs, ",")))
+	return c.do(ctx, "DELETE", path, nil, "return=minimal", nil)
+}
+
+// SaveDriftReport stores a property sync's reconciliation report
+func (c *SupabaseClient) SaveDriftReport(ctx context.Context, report models.DriftReport) error {
+	return c.do(ctx, "POST", "/property_drift_reports", report, "return=minimal", nil)
+}
+
+// GetLead returns the lead record for a phone number, or nil if none exists
+func (c *SupabaseClient) GetLead(ctx context.Context, phone string) (*models.Lead, error) {
+	path := fmt.Sprintf("/leads?phone=eq.%s&select=*", url.QueryEscape(phone))
+
+	var leads []models.Lead
+	if err := c.do(ctx, "GET", path, nil, "", &leads); err != nil {
+		return nil, err
+	}
+	if len(leads) == 0 {
+		return nil, nil
+	}
+	return &leads[0], nil
+}
+
+// ListWaitlistedLeads returns the leads on a property's waitlist, including
+// those already notified (see models.Lead.AwaitingVacancy)
+func (c *SupabaseClient) ListWaitlistedLeads(ctx context.Context) ([]models.Lead, error) {
+	var leads []models.Lead
+	if err := c.do(ctx, "GET", "/leads?waitlist_property_id=not.is.null&select=*", nil, "", &leads); err != nil {
+		return nil, err
+	}
+	return leads, nil
+}
+
+// SaveLead creates the lead for lead.Phone or updates the fields that are set
+func (c *SupabaseClient) SaveLead(ctx context.Context, lead models.Lead) error {
+	lead.UpdatedAt = time.Now().UTC()
+	return c.do(ctx, "POST", "/leads?on_conflict=phone", lead, "resolution=merge-duplicates,return=minimal", nil)
+}
+
+// GetSMSSession returns the active SMS conversation for a phone number, or nil if none exists
+func (c *SupabaseClient) GetSMSSession(ctx context.Context, phone string) (*models.SMSSession, error) {
+	path := fmt.Sprintf("/sms_sessions?phone=eq.%s&select=*", url.QueryEscape(phone))
+
+	var sessions []models.SMSSession
+	if err := c.do(ctx, "GET", path, nil, "", &sessions); err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, nil
+	}
+	return &sessions[0], nil
+}
+
+// SaveSMSSession creates or replaces the SMS conversation for session.Phone
+func (c *SupabaseClient) SaveSMSSession(ctx context.Context, session models.SMSSession) error {
+	session.UpdatedAt = time.Now().UTC()
+	return c.do(ctx, "POST", "/sms_sessions?on_conflict=phone", session, "resolution=merge-duplicates,return=minimal", nil)
+}
+
+// GetCallState returns a voice call's conversation state, or nil if none
+// was saved
+func (c *SupabaseClient) GetCallState(ctx context.Context, callID string) (*models.CallState, error) {
+	path := fmt.Sprintf("/call_states?call_id=eq.%s&select=*", url.QueryEscape(callID))
+
+	var states []models.CallState
+	if err := c.do(ctx, "GET", path, nil, "", &states); err != nil {
+		return nil, err
+	}
+	if len(states) == 0 {
+		return nil, nil
+	}
+	return &states[0], nil
+}
+
+// SaveCallState creates or replaces the conversation state for state.CallID
+func (c *SupabaseClient) SaveCallState(ctx context.Context, state models.CallState) error {
+	state.UpdatedAt = time.Now().UTC()
+	return c.do(ctx, "POST", "/call_states?on_conflict=call_id", state, "resolution=merge-duplicates,return=minimal", nil)
+}
+
+// ListShowingsAwaitingApplication returns booked SMS sessions whose showing
+// ended within (since, until] and haven't been sent an application link yet
+func (c *SupabaseClient) ListShowingsAwaitingApplication(ctx context.Context, since, until time.Time) ([]models.SMSSession, error) {
+	path := fmt.Sprintf("/sms_sessions?booked_end=gt.%s&booked_end=lte.%s&application_sent_at=is.null&select=*",
+		url.QueryEscape(since.UTC().Format(time.RFC3339)), url.QueryEscape(until.UTC().Format(time.RFC3339)))
+
+	var sessions []models.SMSSession
+	if err := c.do(ctx, "GET", path, nil, "", &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// ListExpiredHolds returns tentative SMS bookings whose confirmation
+// window closed before now
+func (c *SupabaseClient) ListExpiredHolds(ctx context.Context, now time.Time) ([]models.SMSSession, error) {
+	path := fmt.Sprintf("/sms_sessions?confirm_by=lt.%s&select=*", url.QueryEscape(now.UTC().Format(time.RFC3339)))
+
+	var sessions []models.SMSSession
+	if err := c.do(ctx, "GET", path, nil, "", &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// ConfirmSMSBooking clears the confirmation deadline on the tentative
+// booking of eventID. It returns an ErrConflict error if the hold is no
+// longer pending (released, rebooked or already confirmed).
+func (c *SupabaseClient) ConfirmSMSBooking(ctx context.Context, phone, eventID string) error {
+	path := fmt.Sprintf("/sms_sessions?phone=eq.%s&event_id=eq.%s&confirm_by=not.is.null", url.QueryEscape(phone), url.QueryEscape(eventID))
+	patch := map[string]interface{}{"confirm_by": nil, "updated_at": time.Now().UTC()}
+	var updated []models.SMSSession
+	if err := c.do(ctx, "PATCH", path, patch, "return=representation", &updated); err != nil {
+		return err
+	}
+	if len(updated) == 0 {
+		return fmt.Errorf("hold for %s: %w", eventID, ErrConflict)
+	}
+	return nil
+}
+
+// ReleaseHold deletes the session holding eventID tentatively. It returns an
+// ErrConflict error if the hold was confirmed or replaced meanwhile, so the
+// caller must leave the booking alone.
+func (c *SupabaseClient) ReleaseHold(ctx context.Context, phone, eventID string) error {
+	path := fmt.Sprintf("/sms_sessions?phone=eq.%s&event_id=eq.%s&confirm_by=not.is.null", url.QueryEscape(phone), url.QueryEscape(eventID))
+	var deleted []models.SMSSession
+	if err := c.do(ctx, "DELETE", path, nil, "return=representation", &deleted); err != nil {
+		return err
+	}
+	if len(deleted) == 0 {
+		return fmt.Errorf("hold for %s: %w", eventID, ErrConflict)
+	}
+	return nil
+}
+
+// RecordAgentResponse stores the agent's accept/decline on a booked session
+func (c *SupabaseClient) RecordAgentResponse(ctx context.Context, phone, response string) error {
+	path := fmt.Sprintf("/sms_sessions?phone=eq.%s", url.QueryEscape(phone))
+	patch := map[string]interface{}{"agent_response": response, "updated_at": time.Now().UTC()}
+	return c.do(ctx, "PATCH", path, patch, "return=minimal", nil)
+}
+
+// DeleteSMSSession removes the SMS conversation for a phone number
+func (c *SupabaseClient) DeleteSMSSession(ctx context.Context, phone string) error {
+	path := fmt.Sprintf("/sms_sessions?phone=eq.%s", url.QueryEscape(phone))
+	return c.do(ctx, "DELETE", path, nil, "return=minimal", nil)
+}
+
+// CreateBooking inserts a new booking record
+func (c *SupabaseClient) CreateBooking(ctx context.Context, booking models.Booking) error {
+	return c.do(ctx, "POST", "/bookings", booking, "return=minimal", nil)
+}
+
+// GetBooking returns the booking with id, or nil if there is none
+func (c *SupabaseClient) GetBooking(ctx context.Context, id string) (*models.Booking, error) {
+	var bookings []models.Booking
+	if err := c.do(ctx, "GET", fmt.Sprintf("/bookings?id=eq.%s&select=*", url.QueryEscape(id)), nil, "", &bookings); err != nil {
+		return nil, err
+	}
+	if len(bookings) == 0 {
+		return nil, nil
+	}
+	return &bookings[0], nil
+}
+
+// GetBookingByReference returns the booking with the confirmation number
+// reference, or nil if there is none
+func (c *SupabaseClient) GetBookingByReference(ctx context.Context, reference string) (*models.Booking, error) {
+	var bookings []models.Booking
+	if err := c.do(ctx, "GET", fmt.Sprintf("/bookings?reference=eq.%s&select=*", url.QueryEscape(reference)), nil, "", &bookings); err != nil {
+		return nil, err
+	}
+	if len(bookings) == 0 {
+		return nil, nil
+	}
+	return &bookings[0], nil
+}
+
+// UpdateBooking writes booking as the next version of the record it was read
+// from (booking.Version). It returns an ErrConflict error if the record has
+// moved on since, or ErrNotFound if there is none.
+func (c *SupabaseClient) UpdateBooking(ctx context.Context, booking models.Booking) error {
+	read := booking.Version
+	booking.Version++
+	var updated []models.Booking
+	path := fmt.Sprintf("/bookings?id=eq.%s&version=eq.%d", url.QueryEscape(booking.ID), read)
+	if err := c.do(ctx, "PATCH", path, booking, "return=representation", &updated); err != nil {
+		return err
+	}
+	if len(updated) > 0 {
+		return nil
+	}
+	current, err := c.GetBooking(ctx, booking.ID)
 	if err != nil {
-		return "", err
+		return err
+	}
+	if current == nil {
+		return fmt.Errorf("booking %s: %w", booking.ID, ErrNotFound)
 	}
+	return fmt.Errorf("booking %s at version %d, not %d: %w", booking.ID, current.Version, read, ErrConflict)
+}
 
-	req.Header.Set("apikey", c.APIKey)
-	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+// ListBookingsByPhone returns the prospect's bookings, soonest first
+func (c *SupabaseClient) ListBookingsByPhone(ctx context.Context, phone string) ([]models.Booking, error) {
+	var bookings []models.Booking
+	path := fmt.Sprintf("/bookings?phone=eq.%s&select=*&order=start.asc", url.QueryEscape(phone))
+	if err := c.do(ctx, "GET", path, nil, "", &bookings); err != nil {
+		return nil, err
+	}
+	return bookings, nil
+}
+
+// ListBookingsByAgent returns the agent's bookings starting in [from, to),
+// soonest first
+func (c *SupabaseClient) ListBookingsByAgent(ctx context.Context, agentEmail string, from, to time.Time) ([]models.Booking, error) {
+	var bookings []models.Booking
+	path := fmt.Sprintf("/bookings?agent_email=eq.%s&start=gte.%s&start=lt.%s&select=*&order=start.asc", url.QueryEscape(strings.ToLower(agentEmail)),
+		url.QueryEscape(from.UTC().Format(time.RFC3339)), url.QueryEscape(to.UTC().Format(time.RFC3339)))
+	if err := c.do(ctx, "GET", path, nil, "", &bookings); err != nil {
+		return nil, err
+	}
+	return bookings, nil
+}
+
+// ListBookingsByProperty returns the property's bookings starting in
+// [from, to), soonest first
+func (c *SupabaseClient) ListBookingsByProperty(ctx context.Context, propertyID string, from, to time.Time) ([]models.Booking, error) {
+	var bookings []models.Booking
+	path := fmt.Sprintf("/bookings?property_id=eq.%s&start=gte.%s&start=lt.%s&select=*&order=start.asc", url.QueryEscape(propertyID),
+		url.QueryEscape(from.UTC().Format(time.RFC3339)), url.QueryEscape(to.UTC().Format(time.RFC3339)))
+	if err := c.do(ctx, "GET", path, nil, "", &bookings); err != nil {
+		return nil, err
+	}
+	return bookings, nil
+}
 
-	resp, err := c.HTTPClient.Do(req)
+// do issues a PostgREST request against path, encoding body (if any) as JSON
+// and decoding the response into out (if non-nil). Failed reads are retried
+// on Fallback when one is configured.
+func (c *SupabaseClient) do(ctx context.Context, method, path string, body interface{}, prefer string, out interface{}) error {
+	err := c.doOnce(ctx, method, path, body, prefer, out)
+	if err == nil || method != "GET" || c.Fallback == nil || !failoverable(err) {
+		return err
+	}
+	if c.OnFailover != nil {
+		c.OnFailover(ctx, path, err)
+	}
+	if ferr := c.Fallback.doOnce(instrument.WithRetry(ctx, 1), method, path, body, prefer, out); ferr != nil {
+		return fmt.Errorf("%w (fallback: %v)", err, ferr)
+	}
+	return nil
+}
+
+func (c *SupabaseClient) doOnce(ctx context.Context, method, path string, body interface{}, prefer string, out interface{}) error {
+	var reqBody *bytes.Buffer
+	if body != nil {
+		jsonBody, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewBuffer(jsonBody)
+	} else {
+		reqBody = &bytes.Buffer{}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reqBody)
 	if err != nil {
-		return "", err
+		return err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("Supabase API error: %s", resp.Status)
+	req.Header.Set("apikey", c.APIKey)
+	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if prefer != "" {
+		req.Header.Set("Prefer", prefer)
 	}
 
-	var tokens []OAuthToken
-	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
-		return "", err
+	resp, err := send(c.HTTPClient, req)
+	if err != nil {
+		return err
 	}
+	defer resp.Body.Close()
 
-	if len(tokens) == 0 {
-		return "", fmt.Errorf("no token found for email: %s", email)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newAPIError(resp, "Supabase API error")
 	}
 
-	return tokens[0].AccessToken, nil
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
 }
@@ -0,0 +1,130 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/egress"
+)
+
+// RealtimeHeartbeatInterval keeps the Phoenix socket alive (server timeout is 60s)
+const RealtimeHeartbeatInterval = 25 * time.Second
+
+// RealtimeChange is a Postgres change delivered by Supabase Realtime
+type RealtimeChange struct {
+	Table     string                 `json:"table"`
+	Type      string                 `json:"type"` // INSERT, UPDATE, DELETE
+	Record    map[string]interface{} `json:"record"`
+	OldRecord map[string]interface{} `json:"old_record"`
+}
+
+// SupabaseRealtime subscribes to Postgres changes over the Realtime websocket
+type SupabaseRealtime struct {
+	SocketURL string
+	APIKey    string
+}
+
+func NewSupabaseRealtime(projectID, apiKey string) *SupabaseRealtime {
+	return &SupabaseRealtime{
+		SocketURL: fmt.Sprintf("wss://%s.supabase.co/realtime/v1/websocket", projectID),
+		APIKey:    apiKey,
+	}
+}
+
+type phoenixMessage struct {
+	Topic   string          `json:"topic"`
+	Event   string          `json:"event"`
+	Payload json.RawMessage `json:"payload"`
+	Ref     string          `json:"ref,omitempty"`
+}
+
+// Listen joins one channel per table and calls onChange for every change
+// until ctx is cancelled or the connection drops. Callers are expected to
+// reconnect on error.
+func (r *SupabaseRealtime) Listen(ctx context.Context, tables []string, onChange func(RealtimeChange)) error {
+	socketURL := fmt.Sprintf("%s?apikey=%s&vsn=1.0.0", r.SocketURL, url.QueryEscape(r.APIKey))
+	cfg, err := websocket.NewConfig(socketURL, "https://supabase.co")
+	if err != nil {
+		return err
+	}
+	// The socket doesn't go through an HTTP transport, so the allowlist is
+	// checked here
+	if err := egress.Check(cfg.Location.Hostname()); err != nil {
+		return err
+	}
+	conn, err := cfg.DialContext(ctx)
+	if err != nil {
+		return fmt.Errorf("realtime dial failed: %w", err)
+	}
+	defer conn.Close()
+
+	ref := 0
+	send := func(topic, event string, payload interface{}) error {
+		ref++
+		raw, _ := json.Marshal(payload)
+		return websocket.JSON.Send(conn, phoenixMessage{Topic: topic, Event: event, Payload: raw, Ref: strconv.Itoa(ref)})
+	}
+
+	for _, table := range tables {
+		join := map[string]interface{}{
+			"config": map[string]interface{}{
+				"postgres_changes": []map[string]string{
+					{"event": "*", "schema": "public", "table": table},
+				},
+			},
+			"access_token": r.APIKey,
+		}
+		if err := send("realtime:public:"+table, "phx_join", join); err != nil {
+			return fmt.Errorf("realtime join %s failed: %w", table, err)
+		}
+	}
+
+	// Heartbeats and shutdown run alongside the blocking read loop
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(RealtimeHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				conn.Close()
+				return
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := send("phoenix", "heartbeat", map[string]string{}); err != nil {
+					conn.Close()
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		var msg phoenixMessage
+		if err := websocket.JSON.Receive(conn, &msg); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("realtime receive failed: %w", err)
+		}
+		if msg.Event != "postgres_changes" {
+			continue
+		}
+
+		var payload struct {
+			Data RealtimeChange `json:"data"`
+		}
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			continue
+		}
+		onChange(payload.Data)
+	}
+}
@@ -0,0 +1,124 @@
+package clients
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/breaker"
+)
+
+// TwilioClient sends outbound text messages through Twilio's Messages API
+type TwilioClient struct {
+	BaseURL    string
+	AccountSID string
+	AuthToken  string
+	From       string
+	HTTPClient *http.Client
+}
+
+func NewTwilioClient(accountSID, authToken, from string) *TwilioClient {
+	return &TwilioClient{
+		BaseURL:    "https://api.twilio.com/2010-04-01",
+		AccountSID: accountSID,
+		AuthToken:  authToken,
+		From:       from,
+		HTTPClient: xray.Client(&http.Client{Timeout: 10 * time.Second, Transport: breaker.Transport("twilio", nil)}),
+	}
+}
+
+// SendSMS texts body to the given phone number
+func (c *TwilioClient) SendSMS(ctx context.Context, to, body string) error {
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("From", c.From)
+	form.Set("Body", body)
+
+	reqURL := fmt.Sprintf("%s/Accounts/%s/Messages.json", c.BaseURL, c.AccountSID)
+	req, err := http.NewRequestWithContext(ctx, "POST", reqURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(c.AccountSID, c.AuthToken)
+
+	resp, err := send(c.HTTPClient, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return newAPIError(resp, "Twilio API error (SendSMS)")
+	}
+	return nil
+}
+
+// InboundSMS is a message delivered by Twilio's incoming-message webhook
+type InboundSMS struct {
+	MessageSID string
+	From       string
+	To         string
+	Body       string
+}
+
+// ParseInboundSMS extracts an inbound message from a Twilio webhook form.
+// Returns false if the form does not look like a Twilio message callback.
+func ParseInboundSMS(form url.Values) (InboundSMS, bool) {
+	sms := InboundSMS{
+		MessageSID: form.Get("MessageSid"),
+		From:       form.Get("From"),
+		To:         form.Get("To"),
+		Body:       strings.TrimSpace(form.Get("Body")),
+	}
+	if sms.MessageSID == "" || sms.From == "" {
+		return InboundSMS{}, false
+	}
+	return sms, true
+}
+
+// ValidateTwilioSignature checks the X-Twilio-Signature header against the
+// webhook URL and posted form parameters, per Twilio's request validation scheme.
+func ValidateTwilioSignature(authToken, webhookURL, signature string, form url.Values) bool {
+	keys := make([]string, 0, len(form))
+	for k := range form {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString(webhookURL)
+	for _, k := range keys {
+		for _, v := range form[k] {
+			sb.WriteString(k)
+			sb.WriteString(v)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(sb.String()))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// TwiMLEmpty acknowledges an inbound message without replying
+func TwiMLEmpty() string {
+	return xml.Header + "<Response></Response>"
+}
+
+// TwiMLMessage renders a TwiML document replying to the sender with msg
+func TwiMLMessage(msg string) string {
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(msg))
+	return xml.Header + "<Response><Message>" + escaped.String() + "</Message></Response>"
+}
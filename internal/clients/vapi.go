@@ -0,0 +1,81 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/breaker"
+)
+
+// VAPIClient places outbound calls through VAPI's call API
+type VAPIClient struct {
+	BaseURL    string
+	APIKey     string
+	HTTPClient *http.Client
+}
+
+func NewVAPIClient(apiKey string) *VAPIClient {
+	return &VAPIClient{
+		BaseURL:    "https://api.vapi.ai",
+		APIKey:     apiKey,
+		HTTPClient: xray.Client(&http.Client{Timeout: 10 * time.Second, Transport: breaker.Transport("vapi", nil)}),
+	}
+}
+
+// OutboundCall is a call from one of our VAPI numbers to a customer
+type OutboundCall struct {
+	AssistantID    string
+	PhoneNumberID  string
+	CustomerNumber string
+	// At schedules the call; VAPI places it at or shortly after this time
+	At time.Time
+	// Metadata is echoed back on the call's webhooks
+	Metadata map[string]string
+}
+
+// ScheduledCall is VAPI's reference to a created call
+type ScheduledCall struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+// ScheduleCall creates an outbound call scheduled for call.At
+func (c *VAPIClient) ScheduleCall(ctx context.Context, call OutboundCall) (*ScheduledCall, error) {
+	body := map[string]interface{}{
+		"assistantId":   call.AssistantID,
+		"phoneNumberId": call.PhoneNumberID,
+		"customer":      map[string]string{"number": call.CustomerNumber},
+		"schedulePlan":  map[string]string{"earliestAt": call.At.UTC().Format(time.RFC3339)},
+	}
+	if len(call.Metadata) > 0 {
+		body["metadata"] = call.Metadata
+	}
+	jsonBody, _ := json.Marshal(body)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.BaseURL+"/call", bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := send(c.HTTPClient, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp, "VAPI API error (ScheduleCall)")
+	}
+
+	var scheduled ScheduledCall
+	if err := json.NewDecoder(resp.Body).Decode(&scheduled); err != nil {
+		return nil, err
+	}
+	return &scheduled, nil
+}
@@ -0,0 +1,63 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/breaker"
+)
+
+// WebhookClient posts JSON notifications to customer-configured URLs. When
+// Secret is set each post carries X-Signature: the hex HMAC-SHA256 of
+// "<X-Timestamp>.<body>", so receivers can verify it came from us.
+type WebhookClient struct {
+	Secret     string
+	HTTPClient *http.Client
+}
+
+func NewWebhookClient(secret string) *WebhookClient {
+	return &WebhookClient{
+		Secret:     secret,
+		HTTPClient: xray.Client(&http.Client{Timeout: 10 * time.Second, Transport: breaker.Transport("webhook", nil)}),
+	}
+}
+
+// Post sends payload to url; any 2xx response is success
+func (c *WebhookClient) Post(ctx context.Context, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.Secret != "" {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(c.Secret))
+		mac.Write([]byte(ts + "."))
+		mac.Write(body)
+		req.Header.Set("X-Timestamp", ts)
+		req.Header.Set("X-Signature", hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := send(c.HTTPClient, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newAPIError(resp, "webhook error")
+	}
+	return nil
+}
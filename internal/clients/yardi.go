@@ -0,0 +1,146 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-xray-sdk-go/xray"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/breaker"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+const yardiNamespace = "http://tempuri.org/YSI.Interfaces.WebServices/ItfILSGuestCard"
+
+// YardiCredentials are the interface-license parameters every Voyager SOAP call carries
+type YardiCredentials struct {
+	UserName        string
+	Password        string
+	ServerName      string
+	Database        string
+	Platform        string
+	InterfaceEntity string
+	License         string
+}
+
+// YardiClient reads properties and marketing agents from Yardi Voyager's
+// ILS/Guest Card SOAP interface
+type YardiClient struct {
+	ServiceURL  string // .../webservices/itfilsguestcard.asmx
+	Credentials YardiCredentials
+	HTTPClient  *http.Client
+}
+
+func NewYardiClient(serviceURL string, creds YardiCredentials) *YardiClient {
+	return &YardiClient{
+		ServiceURL:  serviceURL,
+		Credentials: creds,
+		HTTPClient:  xray.Client(&http.Client{Timeout: 15 * time.Second, Transport: breaker.Transport("yardi", nil)}),
+	}
+}
+
+func (c *YardiClient) Name() string { return "yardi" }
+
+// GetProperty finds a property by its Voyager property code. Voyager has no
+// property groups; agents come from GetMarketingAgents instead.
+func (c *YardiClient) GetProperty(ctx context.Context, propertyID string) (*models.AppFolioProperty, error) {
+	var result struct {
+		Properties []models.YardiProperty `xml:"Body>GetPropertyConfigurationsResponse>GetPropertyConfigurationsResult>Properties>Property"`
+	}
+	if err := c.call(ctx, "GetPropertyConfigurations", nil, &result); err != nil {
+		return nil, err
+	}
+
+	for _, p := range result.Properties {
+		if strings.EqualFold(p.Code, propertyID) {
+			return &models.AppFolioProperty{
+				ID:       p.Code,
+				Name:     p.MarketingName,
+				Address1: p.AddressLine1,
+				City:     p.City,
+				State:    p.State,
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("property not found: %s", propertyID)
+}
+
+// GetPropertyGroups always returns no groups; see GetMarketingAgents
+func (c *YardiClient) GetPropertyGroups(ctx context.Context, ids []string) ([]models.AppFolioGroup, error) {
+	return nil, nil
+}
+
+// GetMarketingAgents returns the leasing agents configured for a property
+func (c *YardiClient) GetMarketingAgents(ctx context.Context, propertyID string) ([]models.AgentInfo, error) {
+	var result struct {
+		Agents []models.YardiAgent `xml:"Body>GetYardiAgentsSourcesResultsResponse>GetYardiAgentsSourcesResultsResult>YardiAgentsSourcesResults>Property>Agents>Agent"`
+	}
+	params := map[string]string{"YardiPropertyId": propertyID}
+	if err := c.call(ctx, "GetYardiAgentsSourcesResults", params, &result); err != nil {
+		return nil, err
+	}
+
+	agents := make([]models.AgentInfo, 0, len(result.Agents))
+	for _, a := range result.Agents {
+		name := strings.TrimSpace(a.FirstName + " " + a.LastName)
+		if name == "" {
+			continue
+		}
+		agents = append(agents, models.AgentInfo{Name: name, Email: a.Email})
+	}
+	return agents, nil
+}
+
+// call issues a SOAP 1.1 request for method, adding the credential
+// parameters, and decodes the envelope into out.
+func (c *YardiClient) call(ctx context.Context, method string, params map[string]string, out interface{}) error {
+	var body bytes.Buffer
+	body.WriteString(xml.Header)
+	body.WriteString(`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body>`)
+	fmt.Fprintf(&body, `<%s xmlns="%s">`, method, yardiNamespace)
+
+	creds := [][2]string{
+		{"UserName", c.Credentials.UserName},
+		{"Password", c.Credentials.Password},
+		{"ServerName", c.Credentials.ServerName},
+		{"Database", c.Credentials.Database},
+		{"Platform", c.Credentials.Platform},
+		{"InterfaceEntity", c.Credentials.InterfaceEntity},
+		{"InterfaceLicense", c.Credentials.License},
+	}
+	for _, kv := range creds {
+		writeXMLElement(&body, kv[0], kv[1])
+	}
+	for k, v := range params {
+		writeXMLElement(&body, k, v)
+	}
+	fmt.Fprintf(&body, `</%s></soap:Body></soap:Envelope>`, method)
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.ServiceURL, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/xml; charset=utf-8")
+	req.Header.Set("SOAPAction", fmt.Sprintf(`"%s/%s"`, yardiNamespace, method))
+
+	resp, err := send(c.HTTPClient, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newAPIError(resp, fmt.Sprintf("Yardi API error (%s)", method))
+	}
+	return xml.NewDecoder(resp.Body).Decode(out)
+}
+
+func writeXMLElement(buf *bytes.Buffer, name, value string) {
+	buf.WriteString("<" + name + ">")
+	xml.EscapeText(buf, []byte(value))
+	buf.WriteString("</" + name + ">")
+}
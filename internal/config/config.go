@@ -0,0 +1,632 @@
+package config
+
+import (
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+// DefaultSlotNoticeTemplate sets expectations when the earliest showing is
+// far out and points the caller at the agent for anything sooner
+const DefaultSlotNoticeTemplate = "Heads up: the earliest showing for {property} is {earliest}. If you need to see it sooner, reach out to {agent} at {email} and they'll do their best to fit you in."
+
+// Config holds the environment-driven settings shared by every entry point
+type Config struct {
+	SupabaseProjectID   string
+	SupabaseKey         string
+	AppFolioAuthHeader  string
+	AppFolioDeveloperID string
+	SearchServiceURL    string
+	OpenAIAPIKey        string
+
+	// SearchServiceURLs are the search service endpoints in failover order
+	// (SEARCH_SERVICE_URL, comma-separated); SearchServiceURL is the first.
+	// SearchHedgeDelay is how long an endpoint may take before the next is
+	// asked as well; zero disables hedging.
+	SearchServiceURLs []string
+	SearchHedgeDelay  time.Duration
+
+	// Optional read replica / backup Supabase project in another region,
+	// used for reads (tokens, roster, settings) when the primary fails
+	SupabaseFallbackProjectID string
+	SupabaseFallbackKey       string
+
+	// SearchAuth signs calls to the search service: "" (none), "sigv4"
+	// (IAM-authenticated Function URL in AWSRegion) or "hmac" (shared secret)
+	SearchAuth       string
+	SearchHMACSecret string
+	AWSRegion        string
+
+	// EgressAllowlist restricts outbound HTTP to these hosts ("*.example.com"
+	// for subdomains); empty allows all
+	EgressAllowlist []string
+
+	// MatchConfirmThreshold is the match confidence below which a voice
+	// caller is asked to confirm the address before times are offered
+	MatchConfirmThreshold float64
+
+	// LLMScrub lists the scrub categories masked in text sent to the LLM
+	// (see internal/scrub); empty masks all of them, "none" disables the
+	// scrubber. LLMScrubWords extends its profanity list.
+	LLMScrub      []string
+	LLMScrubWords []string
+	// LLMLogSampleRate is the fraction of LLM calls whose prompt and
+	// completion are logged when the log level is debug (default none)
+	LLMLogSampleRate float64
+	// LLMMaxWait is how long address matching may wait on the LLM rate
+	// limit before falling back to search (LLM_MAX_WAIT_MS); zero waits
+	// for as long as the request allows
+	LLMMaxWait time.Duration
+
+	// LLMProvider runs the LLM features: "openai" (default), "bedrock"
+	// (Anthropic on AWS Bedrock, in BedrockRegion or AWSRegion) or
+	// "openai_compatible" (a self-hosted vLLM/Ollama server at LLMBaseURL
+	// running LLMModel, sent LLMHeaders and OpenAIAPIKey if set).
+	// TenantLLMProviders overrides it per tenant ID.
+	LLMProvider        string
+	TenantLLMProviders map[string]string
+	BedrockModelID     string
+	BedrockRegion      string
+	LLMBaseURL         string
+	LLMModel           string
+	LLMHeaders         map[string]string
+	// LLMFixtures maps queries to candidate indexes for the "fixture"
+	// provider, which answers deterministically without a model (tests
+	// and CI only; refused in production)
+	LLMFixtures map[string]int
+
+	// MTLSSecrets maps a dependency host to the Secrets Manager secret
+	// holding its client certificate ({"cert", "key", "ca"} PEM JSON)
+	MTLSSecrets map[string]string
+
+	// PropertySource selects the default property data source: "appfolio"
+	// (default), "buildium" or "yardi". TenantPropertySources overrides it
+	// per tenant ID.
+	PropertySource        string
+	TenantPropertySources map[string]string
+	BuildiumClientID      string
+	BuildiumClientSecret  string
+
+	// Yardi Voyager ILS/Guest Card interface
+	YardiServiceURL      string
+	YardiUserName        string
+	YardiPassword        string
+	YardiServerName      string
+	YardiDatabase        string
+	YardiPlatform        string
+	YardiInterfaceEntity string
+	YardiLicense         string
+
+	// Twilio inbound SMS webhook, whose texts are refused unless both are set
+	// to check their signature; outbound texts also need the account SID and
+	// a sending number
+	TwilioAuthToken  string
+	TwilioWebhookURL string
+	TwilioAccountSID string
+	TwilioFromNumber string
+
+	// Post-showing application links. ApplicationURLTemplate is the online
+	// application URL with a {property_id} placeholder; links are sent as
+	// tracked redirects through PublicBaseURL, signed with LinkSigningSecret.
+	ApplicationURLTemplate string
+	PublicBaseURL          string
+	LinkSigningSecret      string
+
+	// Slack team notifications. SlackZoneChannels maps an agent zone
+	// (e.g. "PD1") to a channel; unmapped zones use SlackDefaultChannel.
+	SlackBotToken       string
+	SlackZoneChannels   map[string]string
+	SlackDefaultChannel string
+
+	// AuditURLTemplate builds a link to an invocation's audit record;
+	// "{request_id}" is replaced with the Lambda request ID.
+	AuditURLTemplate string
+
+	// SlotNoticeDays, when positive, prefixes FormattedMsg with
+	// SlotNoticeTemplate whenever the earliest open slot is more than that
+	// many days out. The template's {property}, {earliest}, {agent} and
+	// {email} placeholders are filled in, as are the Branding ones.
+	SlotNoticeDays     int
+	SlotNoticeTemplate string
+
+	// BookingConfirmWindow, when set, books SMS showings as tentative until
+	// the prospect replies YES within it; unconfirmed holds are released
+	BookingConfirmWindow time.Duration
+
+	// AgentBookingApproval sends agents accept/decline links (via the zone's
+	// Slack channel) for each SMS booking; a decline frees the slot and
+	// re-offers the prospect other times
+	AgentBookingApproval bool
+
+	// VAPI outbound call-backs: the assistant that places them and the VAPI
+	// phone number they're placed from
+	VAPIAPIKey        string
+	VAPIAssistantID   string
+	VAPIPhoneNumberID string
+	// VAPIStrictSchema rejects VAPI server messages that don't match the
+	// shape this service parses (422 listing the violations) instead of
+	// carrying on with whatever fields could be read
+	VAPIStrictSchema bool
+
+	// Bearer token for the /admin API; the API is disabled when unset
+	AdminAPIToken string
+
+	// Google OAuth client the agents authorized calendar access through;
+	// needed to refresh their tokens
+	GoogleClientID     string
+	GoogleClientSecret string
+
+	// PagerDuty Events API v2 routing key for dependency outage alerts
+	PagerDutyRoutingKey string
+	// FunctionName identifies this deployment as the alert source
+	FunctionName string
+
+	// Environment is the deployment stage (APP_ENV); anything other than
+	// "prod"/"production" counts as non-production. Defaults to "prod".
+	Environment string
+	// FaultInject is a faultinject spec applied outside production
+	FaultInject string
+
+	// ListingFeedURLs maps a syndication source (zillow, zumper) to its
+	// HotPads-format feed URL for the nightly ingest job
+	ListingFeedURLs map[string]string
+
+	// Geocoding fallback for properties without a PD group: the address is
+	// resolved with GoogleMapsAPIKey and matched against ZonePolygons
+	// (zone → ring of [lat, lng] vertices).
+	GoogleMapsAPIKey string
+	ZonePolygons     map[string][][2]float64
+
+	// ZoneHierarchy escalates properties no zone agent can take to a
+	// regional, then company, contact instead of failing
+	ZoneHierarchy *models.ZoneHierarchy
+
+	// Slot ranking: RankingClusterWeight is the preference (in days of
+	// waiting) for slots back-to-back with a showing within ClusterRadiusKm.
+	RankingClusterWeight float64
+	ClusterRadiusKm      float64
+
+	// Smart-lock provider used to issue access codes for self-guided tours
+	SmartLockAPIURL string
+	SmartLockAPIKey string
+
+	// Stripe Identity: when StripeSecretKey is set, leads must verify their
+	// ID before a self-guided access code is issued
+	StripeSecretKey     string
+	StripeWebhookSecret string
+
+	// AgentWorkingHours uses each agent's Google Calendar working hours
+	// (from working-location entries) as the showing window on days they
+	// set them, instead of the rules' weekday hours
+	AgentWorkingHours bool
+
+	// Showing-hours rules documents (business hours + exclusion windows):
+	// ScheduleRules applies to every tenant without its own entry in
+	// TenantScheduleRules. Unset means the built-in hours.
+	ScheduleRules       *models.ScheduleRules
+	TenantScheduleRules map[string]models.ScheduleRules
+
+	// Immutable domain event log: NDJSON objects under EventLogPrefix in
+	// EventLogBucket, partitioned by date and tenant
+	EventLogBucket string
+	EventLogPrefix string
+
+	// Domain events are also published to this EventBridge bus when set
+	EventBusName   string
+	EventBusSource string
+
+	// Browser origins allowed to call the service (e.g. the web widget on a
+	// Function URL). CORSAllowedOrigins applies to every tenant;
+	// TenantCORSOrigins adds origins for one tenant. "*" allows any origin.
+	CORSAllowedOrigins []string
+	TenantCORSOrigins  map[string][]string
+
+	// Branding fills the {company}, {company_phone}, {website} and
+	// {sign_off} placeholders in messages and signs outbound texts.
+	// A tenant with a TenantBranding entry uses only that entry, so no
+	// field falls back to another tenant's identity.
+	Branding       models.Branding
+	TenantBranding map[string]models.Branding
+
+	// Per-tenant monthly usage is counted in the UsageTable DynamoDB table
+	// (partition key "pk"). UsageQuotas, keyed by metric (invocations,
+	// openai_calls, sms_sends, bookings), apply to every tenant without its
+	// own entry in TenantUsageQuotas.
+	UsageTable        string
+	UsageQuotas       map[string]models.UsageQuota
+	TenantUsageQuotas map[string]map[string]models.UsageQuota
+
+	// TenantDataResidency selects the Supabase project and event-log bucket
+	// holding a tenant's data, for customers with residency requirements
+	TenantDataResidency map[string]models.DataResidency
+
+	// BookingStore selects where booking records live: "supabase" (the
+	// bookings table; the default) or "dynamodb" (BookingsTable)
+	BookingStore  string
+	BookingsTable string
+
+	// JobCheckpointTable (DynamoDB) enables checkpointing of long jobs: a
+	// run nearing the Lambda timeout saves its progress there and
+	// re-invokes the function to resume (see internal/checkpoint)
+	JobCheckpointTable string
+
+	// SlotHoldTable (DynamoDB) enables slot holds: the first SlotHoldCount
+	// slots offered to a caller are held for SlotHoldTTL, hidden from
+	// concurrent callers until booked or expired (see internal/holds)
+	SlotHoldTable string
+	SlotHoldCount int
+	SlotHoldTTL   time.Duration
+
+	// SuppressPropertyOverlaps stops offering times that overlap a showing
+	// already booked at the same property, even with a different agent or
+	// one whose calendar could fit both
+	SuppressPropertyOverlaps bool
+
+	// Response bodies over ResponseOffloadBytes are written to
+	// ResponseOffloadBucket and answered with a presigned link instead,
+	// keeping bulk results under the Lambda payload limit
+	ResponseOffloadBucket string
+	ResponseOffloadBytes  int
+
+	// Notifications (see internal/notify). Notify routes every tenant
+	// without a TenantNotify entry. Email is sent through SES from
+	// NotifyEmailFrom; webhook posts are signed with NotifyWebhookSecret and
+	// their hosts must be on the egress allowlist. A failed delivery is
+	// retried up to NotifyRetryAttempts times in total.
+	Notify              models.NotifySettings
+	TenantNotify        map[string]models.NotifySettings
+	NotifyEmailFrom     string
+	NotifyWebhookSecret string
+	NotifyRetryAttempts int
+
+	// HTTPListenAddr enables container mode: serve HTTP on this address
+	// instead of starting the Lambda runtime.
+	HTTPListenAddr string
+
+	// RateLimits are the request rates allowed per dependency (e.g.
+	// "openai"): RATE_LIMITS, a JSON object of {"perMinute", "burst"},
+	// over the defaults. Invalid entries are ignored. The "appfolio" limit
+	// paces background jobs only, leaving headroom for live calls.
+	RateLimits map[string]RateLimit
+}
+
+// RateLimit is the request rate allowed to a dependency
+type RateLimit struct {
+	PerMinute float64 `json:"perMinute"`
+	Burst     int     `json:"burst"`
+}
+
+// defaultRateLimits apply to dependencies RATE_LIMITS leaves out
+var defaultRateLimits = map[string]RateLimit{
+	"openai":   {PerMinute: 10, Burst: 3},
+	"appfolio": {PerMinute: 120, Burst: 1},
+}
+
+// Load reads the configuration from environment variables
+func Load() Config {
+	cfg := Config{
+		SupabaseProjectID:      os.Getenv("SUPABASE_PROJECT_ID"),
+		SupabaseKey:            os.Getenv("SUPABASE_KEY"),
+		AppFolioAuthHeader:     os.Getenv("APPFOLIO_AUTH_HEADER"),
+		AppFolioDeveloperID:    os.Getenv("APPFOLIO_DEVELOPER_ID"),
+		OpenAIAPIKey:           os.Getenv("OPENAI_API_KEY"),
+		SearchAuth:             os.Getenv("SEARCH_AUTH"),
+		SearchHMACSecret:       os.Getenv("SEARCH_HMAC_SECRET"),
+		AWSRegion:              os.Getenv("AWS_REGION"),
+		EgressAllowlist:        envList("EGRESS_ALLOWLIST"),
+		MTLSSecrets:            jsonStringMap("MTLS_CLIENT_CERT_SECRETS"),
+		PropertySource:         envOr("PROPERTY_DATA_SOURCE", "appfolio"),
+		TenantPropertySources:  jsonStringMap("TENANT_PROPERTY_SOURCES"),
+		BuildiumClientID:       os.Getenv("BUILDIUM_CLIENT_ID"),
+		BuildiumClientSecret:   os.Getenv("BUILDIUM_CLIENT_SECRET"),
+		YardiServiceURL:        os.Getenv("YARDI_SERVICE_URL"),
+		YardiUserName:          os.Getenv("YARDI_USERNAME"),
+		YardiPassword:          os.Getenv("YARDI_PASSWORD"),
+		YardiServerName:        os.Getenv("YARDI_SERVER_NAME"),
+		YardiDatabase:          os.Getenv("YARDI_DATABASE"),
+		YardiPlatform:          envOr("YARDI_PLATFORM", "SQL Server"),
+		YardiInterfaceEntity:   os.Getenv("YARDI_INTERFACE_ENTITY"),
+		YardiLicense:           os.Getenv("YARDI_LICENSE"),
+		TwilioAuthToken:        os.Getenv("TWILIO_AUTH_TOKEN"),
+		TwilioWebhookURL:       os.Getenv("TWILIO_WEBHOOK_URL"),
+		TwilioAccountSID:       os.Getenv("TWILIO_ACCOUNT_SID"),
+		TwilioFromNumber:       os.Getenv("TWILIO_FROM_NUMBER"),
+		ApplicationURLTemplate: os.Getenv("APPLICATION_URL_TEMPLATE"),
+		PublicBaseURL:          os.Getenv("PUBLIC_BASE_URL"),
+		LinkSigningSecret:      os.Getenv("LINK_SIGNING_SECRET"),
+		SlackBotToken:          os.Getenv("SLACK_BOT_TOKEN"),
+		SlackZoneChannels:      jsonStringMap("SLACK_ZONE_CHANNELS"),
+		SlackDefaultChannel:    os.Getenv("SLACK_DEFAULT_CHANNEL"),
+		AuditURLTemplate:       os.Getenv("AUDIT_URL_TEMPLATE"),
+		PagerDutyRoutingKey:    os.Getenv("PAGERDUTY_ROUTING_KEY"),
+		FunctionName:           os.Getenv("AWS_LAMBDA_FUNCTION_NAME"),
+		Environment:            envOr("APP_ENV", "prod"),
+		FaultInject:            os.Getenv("FAULT_INJECT"),
+		ListingFeedURLs:        jsonStringMap("LISTING_FEED_URLS"),
+		GoogleMapsAPIKey:       os.Getenv("GOOGLE_MAPS_API_KEY"),
+		SmartLockAPIURL:        os.Getenv("SMART_LOCK_API_URL"),
+		SmartLockAPIKey:        os.Getenv("SMART_LOCK_API_KEY"),
+		StripeSecretKey:        os.Getenv("STRIPE_SECRET_KEY"),
+		StripeWebhookSecret:    os.Getenv("STRIPE_WEBHOOK_SECRET"),
+		EventLogBucket:         os.Getenv("EVENT_LOG_BUCKET"),
+		EventLogPrefix:         envOr("EVENT_LOG_PREFIX", "events/"),
+		EventBusName:           os.Getenv("EVENT_BUS_NAME"),
+		EventBusSource:         envOr("EVENT_BUS_SOURCE", "go-scheduling-service"),
+		CORSAllowedOrigins:     envList("CORS_ALLOWED_ORIGINS"),
+		AgentWorkingHours:      os.Getenv("AGENT_WORKING_HOURS") == "true",
+		HTTPListenAddr:         os.Getenv("HTTP_LISTEN_ADDR"),
+	}
+	cfg.SlotNoticeDays = envInt("SLOT_NOTICE_DAYS", 0)
+	cfg.SlotNoticeTemplate = os.Getenv("SLOT_NOTICE_TEMPLATE")
+	if cfg.SlotNoticeTemplate == "" {
+		cfg.SlotNoticeTemplate = DefaultSlotNoticeTemplate
+	}
+	cfg.BookingConfirmWindow = time.Duration(envInt("BOOKING_CONFIRM_MINUTES", 0)) * time.Minute
+	cfg.AgentBookingApproval = os.Getenv("AGENT_BOOKING_APPROVAL") == "true"
+	cfg.VAPIAPIKey = os.Getenv("VAPI_API_KEY")
+	cfg.VAPIAssistantID = os.Getenv("VAPI_CALLBACK_ASSISTANT_ID")
+	cfg.VAPIPhoneNumberID = os.Getenv("VAPI_PHONE_NUMBER_ID")
+	cfg.VAPIStrictSchema = os.Getenv("VAPI_STRICT_SCHEMA") == "true"
+	cfg.AdminAPIToken = os.Getenv("ADMIN_API_TOKEN")
+	cfg.GoogleClientID = os.Getenv("GOOGLE_OAUTH_CLIENT_ID")
+	cfg.GoogleClientSecret = os.Getenv("GOOGLE_OAUTH_CLIENT_SECRET")
+	cfg.SupabaseFallbackProjectID = os.Getenv("SUPABASE_FALLBACK_PROJECT_ID")
+	cfg.SupabaseFallbackKey = os.Getenv("SUPABASE_FALLBACK_KEY")
+	cfg.RankingClusterWeight = envFloat("RANKING_CLUSTER_WEIGHT", 1.5)
+	cfg.ClusterRadiusKm = envFloat("CLUSTER_RADIUS_KM", 3)
+	jsonEnv("ZONE_POLYGONS", &cfg.ZonePolygons)
+	jsonEnv("ZONE_HIERARCHY", &cfg.ZoneHierarchy)
+	jsonEnv("SCHEDULE_RULES", &cfg.ScheduleRules)
+	jsonEnv("TENANT_SCHEDULE_RULES", &cfg.TenantScheduleRules)
+	jsonEnv("TENANT_CORS_ORIGINS", &cfg.TenantCORSOrigins)
+	jsonEnv("BRANDING", &cfg.Branding)
+	jsonEnv("TENANT_BRANDING", &cfg.TenantBranding)
+	cfg.UsageTable = os.Getenv("USAGE_TABLE")
+	jsonEnv("USAGE_QUOTAS", &cfg.UsageQuotas)
+	jsonEnv("TENANT_USAGE_QUOTAS", &cfg.TenantUsageQuotas)
+	jsonEnv("TENANT_DATA_RESIDENCY", &cfg.TenantDataResidency)
+	cfg.BookingStore = strings.ToLower(os.Getenv("BOOKING_STORE"))
+	cfg.BookingsTable = os.Getenv("BOOKINGS_TABLE")
+	cfg.JobCheckpointTable = os.Getenv("JOB_CHECKPOINT_TABLE")
+	cfg.SlotHoldTable = os.Getenv("SLOT_HOLD_TABLE")
+	cfg.SlotHoldCount = envInt("SLOT_HOLD_COUNT", 3)
+	cfg.SlotHoldTTL = time.Duration(envInt("SLOT_HOLD_MINUTES", 10)) * time.Minute
+	cfg.SuppressPropertyOverlaps = os.Getenv("SUPPRESS_PROPERTY_OVERLAPS") == "true"
+	cfg.ResponseOffloadBucket = os.Getenv("RESPONSE_OFFLOAD_BUCKET")
+	cfg.ResponseOffloadBytes = envInt("RESPONSE_OFFLOAD_BYTES", 5_000_000)
+	jsonEnv("NOTIFY", &cfg.Notify)
+	jsonEnv("TENANT_NOTIFY", &cfg.TenantNotify)
+	cfg.NotifyEmailFrom = os.Getenv("NOTIFY_EMAIL_FROM")
+	cfg.NotifyWebhookSecret = os.Getenv("NOTIFY_WEBHOOK_SECRET")
+	cfg.NotifyRetryAttempts = envInt("NOTIFY_RETRY_ATTEMPTS", 3)
+	cfg.MatchConfirmThreshold = envFloat("MATCH_CONFIRM_THRESHOLD", 0.75)
+	cfg.LLMScrub = envList("LLM_SCRUB")
+	cfg.LLMScrubWords = envList("LLM_SCRUB_WORDS")
+	cfg.LLMLogSampleRate = envFloat("LLM_LOG_SAMPLE_RATE", 0)
+	cfg.LLMMaxWait = time.Duration(envInt("LLM_MAX_WAIT_MS", 2000)) * time.Millisecond
+	cfg.LLMProvider = envOr("LLM_PROVIDER", "openai")
+	cfg.TenantLLMProviders = jsonStringMap("TENANT_LLM_PROVIDERS")
+	cfg.BedrockModelID = os.Getenv("BEDROCK_MODEL_ID")
+	cfg.BedrockRegion = os.Getenv("BEDROCK_REGION")
+	cfg.LLMBaseURL = os.Getenv("LLM_BASE_URL")
+	cfg.LLMModel = os.Getenv("LLM_MODEL")
+	cfg.LLMHeaders = jsonStringMap("LLM_HEADERS")
+	jsonEnv("LLM_FIXTURES", &cfg.LLMFixtures)
+	cfg.SearchServiceURLs = envList("SEARCH_SERVICE_URL")
+	if len(cfg.SearchServiceURLs) > 0 {
+		cfg.SearchServiceURL = cfg.SearchServiceURLs[0]
+	}
+	cfg.SearchHedgeDelay = time.Duration(envInt("SEARCH_HEDGE_MS", 800)) * time.Millisecond
+	cfg.RateLimits = rateLimits()
+	return cfg
+}
+
+// rateLimits reads RATE_LIMITS over defaultRateLimits, dropping entries
+// that would stop or never refill a limiter
+func rateLimits() map[string]RateLimit {
+	limits := maps.Clone(defaultRateLimits)
+	var configured map[string]RateLimit
+	jsonEnv("RATE_LIMITS", &configured)
+	for dependency, limit := range configured {
+		if limit.PerMinute <= 0 || limit.Burst < 1 {
+			slog.Warn("config_invalid", "key", "RATE_LIMITS", "dependency", dependency,
+				"per_minute", limit.PerMinute, "burst", limit.Burst)
+			continue
+		}
+		limits[dependency] = limit
+	}
+	return limits
+}
+
+// Valid reports whether all required settings are present
+func (c Config) Valid() bool {
+	if c.SupabaseProjectID == "" || c.SupabaseKey == "" || c.SearchServiceURL == "" {
+		return false
+	}
+	return c.PropertySourceConfigured()
+}
+
+// PropertySourceConfigured reports whether the selected property source has credentials
+func (c Config) PropertySourceConfigured() bool {
+	switch c.PropertySource {
+	case "buildium":
+		return c.BuildiumClientID != "" && c.BuildiumClientSecret != ""
+	case "yardi":
+		return c.YardiServiceURL != "" && c.YardiUserName != "" && c.YardiPassword != "" && c.YardiLicense != ""
+	default:
+		return c.AppFolioAuthHeader != "" && c.AppFolioDeveloperID != ""
+	}
+}
+
+// jsonStringMap parses an environment variable holding a JSON object of strings
+func jsonStringMap(key string) map[string]string {
+	var m map[string]string
+	jsonEnv(key, &m)
+	return m
+}
+
+// jsonEnv decodes a JSON-valued environment variable into out, leaving it
+// untouched (and logging) when the variable is unset or malformed
+func jsonEnv(key string, out interface{}) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return
+	}
+	if err := json.Unmarshal([]byte(raw), out); err != nil {
+		slog.Warn("config_parse_failed", "key", key, "error", err)
+	}
+}
+
+// PropertySourceFor returns the property data source name for a tenant
+func (c Config) PropertySourceFor(tenantID string) string {
+	if src, ok := c.TenantPropertySources[tenantID]; ok && tenantID != "" {
+		return src
+	}
+	return c.PropertySource
+}
+
+// LLMProviderFor returns the LLM provider for tenantID
+func (c Config) LLMProviderFor(tenantID string) string {
+	if provider, ok := c.TenantLLMProviders[tenantID]; ok && tenantID != "" {
+		return provider
+	}
+	return c.LLMProvider
+}
+
+// Production reports whether this deployment serves live traffic
+func (c Config) Production() bool {
+	return c.Environment == "prod" || c.Environment == "production"
+}
+
+// BrandingFor returns the company identity messages to tenantID carry
+func (c Config) BrandingFor(tenantID string) models.Branding {
+	if branding, ok := c.TenantBranding[tenantID]; ok && tenantID != "" {
+		return branding
+	}
+	return c.Branding
+}
+
+// NotifyFor returns how tenantID's notifications are routed
+func (c Config) NotifyFor(tenantID string) models.NotifySettings {
+	if settings, ok := c.TenantNotify[tenantID]; ok && tenantID != "" {
+		return settings
+	}
+	return c.Notify
+}
+
+// ResidencyFor returns where tenantID's data lives, with defaults filled in
+func (c Config) ResidencyFor(tenantID string) models.DataResidency {
+	r := c.TenantDataResidency[tenantID]
+	if tenantID == "" {
+		r = models.DataResidency{}
+	}
+	if r.SupabaseProjectID == "" {
+		r.SupabaseProjectID, r.SupabaseKey = c.SupabaseProjectID, c.SupabaseKey
+		r.SupabaseFallbackProjectID, r.SupabaseFallbackKey = c.SupabaseFallbackProjectID, c.SupabaseFallbackKey
+	}
+	if r.EventLogBucket == "" {
+		r.EventLogBucket, r.Region = c.EventLogBucket, ""
+	}
+	return r
+}
+
+// UsageQuotaFor returns the tenant's monthly quota for a usage metric
+func (c Config) UsageQuotaFor(tenantID, metric string) models.UsageQuota {
+	if quotas, ok := c.TenantUsageQuotas[tenantID]; ok && tenantID != "" {
+		return quotas[metric]
+	}
+	return c.UsageQuotas[metric]
+}
+
+// ScheduleRulesFor returns the showing-hours rules for a tenant, or nil for the defaults
+func (c Config) ScheduleRulesFor(tenantID string) *models.ScheduleRules {
+	if rules, ok := c.TenantScheduleRules[tenantID]; ok && tenantID != "" {
+		return &rules
+	}
+	return c.ScheduleRules
+}
+
+// OriginAllowed reports whether a browser origin may read the service's
+// responses for a tenant. With no tenant (the default tenant, or a request
+// that failed before naming one) only CORSAllowedOrigins apply.
+func (c Config) OriginAllowed(tenantID, origin string) bool {
+	if originIn(c.CORSAllowedOrigins, origin) {
+		return true
+	}
+	return tenantID != "" && originIn(c.TenantCORSOrigins[tenantID], origin)
+}
+
+// OriginListed reports whether origin is allowed for any tenant. It only
+// suits a preflight, which carries no body to name the tenant and returns
+// no data; the response to the request itself is checked with
+// OriginAllowed.
+func (c Config) OriginListed(origin string) bool {
+	if originIn(c.CORSAllowedOrigins, origin) {
+		return true
+	}
+	for _, origins := range c.TenantCORSOrigins {
+		if originIn(origins, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func originIn(origins []string, origin string) bool {
+	for _, o := range origins {
+		if o == "*" || strings.EqualFold(strings.TrimRight(o, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func envInt(key string, fallback int) int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		slog.Warn("config_parse_failed", "key", key, "error", err)
+		return fallback
+	}
+	return v
+}
+
+func envFloat(key string, fallback float64) float64 {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		slog.Warn("config_parse_failed", "key", key, "error", err)
+		return fallback
+	}
+	return v
+}
+
+// envList splits a comma-separated environment variable, dropping empty items
+func envList(key string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
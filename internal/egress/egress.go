@@ -0,0 +1,77 @@
+// Package egress restricts which hosts the service may call. The allowlist
+// is enforced by Transport, which every HTTP client's transport is built
+// on, and checked before dialing websockets; AWS SDK calls are governed by
+// IAM/VPC endpoints instead.
+package egress
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ErrDenied is returned for requests to hosts outside the allowlist
+var ErrDenied = errors.New("egress denied")
+
+var (
+	mu        sync.RWMutex
+	allowlist []string
+)
+
+// SetAllowlist replaces the allowed hosts. Entries are exact host names or
+// suffix wildcards ("*.supabase.co"). An empty list allows every host.
+func SetAllowlist(hosts []string) {
+	var cleaned []string
+	for _, h := range hosts {
+		if h = strings.ToLower(strings.TrimSpace(h)); h != "" {
+			cleaned = append(cleaned, h)
+		}
+	}
+
+	mu.Lock()
+	allowlist = cleaned
+	mu.Unlock()
+}
+
+// Check returns an error wrapping ErrDenied if host may not be called
+func Check(host string) error {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if len(allowlist) == 0 {
+		return nil
+	}
+	host = strings.ToLower(host)
+	for _, entry := range allowlist {
+		if suffix, ok := strings.CutPrefix(entry, "*"); ok {
+			if strings.HasSuffix(host, suffix) {
+				return nil
+			}
+		} else if host == entry {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrDenied, host)
+}
+
+// Transport wraps base (http.DefaultTransport if nil) so requests to hosts
+// outside the allowlist fail with ErrDenied before they are sent
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return transport{base: base}
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+func (t transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := Check(req.URL.Hostname()); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
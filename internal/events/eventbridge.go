@@ -0,0 +1,61 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/eventbridge"
+	"github.com/aws/aws-sdk-go/service/eventbridge/eventbridgeiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+)
+
+// putEventsBatchSize is EventBridge's PutEvents entry limit
+const putEventsBatchSize = 10
+
+// EventBridgeBus publishes events to an EventBridge bus so other teams can
+// subscribe with rules on source and detail-type (see schema.go).
+type EventBridgeBus struct {
+	BusName string
+	Source  string
+	client  eventbridgeiface.EventBridgeAPI
+}
+
+func NewEventBridgeBus(sess *session.Session, busName, source string) *EventBridgeBus {
+	client := eventbridge.New(sess)
+	xray.AWS(client.Client)
+	return &EventBridgeBus{BusName: busName, Source: source, client: client}
+}
+
+// Write publishes events in PutEvents-sized batches
+func (b *EventBridgeBus) Write(ctx context.Context, events []Event) error {
+	for i := 0; i < len(events); i += putEventsBatchSize {
+		batch := events[i:min(i+putEventsBatchSize, len(events))]
+
+		entries := make([]*eventbridge.PutEventsRequestEntry, 0, len(batch))
+		for _, e := range batch {
+			detail, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, &eventbridge.PutEventsRequestEntry{
+				EventBusName: aws.String(b.BusName),
+				Source:       aws.String(b.Source),
+				DetailType:   aws.String(e.DetailType()),
+				Detail:       aws.String(string(detail)),
+				Time:         aws.Time(e.Time),
+			})
+		}
+
+		out, err := b.client.PutEventsWithContext(ctx, &eventbridge.PutEventsInput{Entries: entries})
+		if err != nil {
+			return fmt.Errorf("event bus publish: %w", err)
+		}
+		if n := aws.Int64Value(out.FailedEntryCount); n > 0 {
+			return fmt.Errorf("event bus publish: %d of %d entries failed", n, len(entries))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+// Package events records domain events (inquiries, matches, offers,
+// bookings, cancellations) separately from operational logs. Events are
+// collected per invocation and flushed to the configured sinks at the end.
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Domain event types
+const (
+	TypeInquiry      = "inquiry"
+	TypeMatch        = "match"
+	TypeMatchFailed  = "match_failed"
+	TypeOffer        = "offer"
+	TypeBooking      = "booking"
+	TypeCancellation = "cancellation"
+	TypeReschedule   = "reschedule"
+)
+
+// Event is one domain event
+type Event struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	Time       time.Time   `json:"time"`
+	RequestID  string      `json:"requestId"`
+	TenantID   string      `json:"tenantId,omitempty"`
+	PropertyID string      `json:"propertyId,omitempty"`
+	Phone      string      `json:"phone,omitempty"`
+	Data       interface{} `json:"data,omitempty"` // one of the payloads in schema.go
+}
+
+// Sink persists a batch of events
+type Sink interface {
+	Write(ctx context.Context, events []Event) error
+}
+
+// Recorder buffers the events of a single invocation
+type Recorder struct {
+	requestID string
+
+	mu     sync.Mutex
+	events []Event
+}
+
+type recorderKey struct{}
+
+// WithRecorder returns a context that collects events emitted during requestID
+func WithRecorder(ctx context.Context, requestID string) (context.Context, *Recorder) {
+	r := &Recorder{requestID: requestID}
+	return context.WithValue(ctx, recorderKey{}, r), r
+}
+
+// Emit records e on the context's recorder, filling in ID, time and request
+// ID. It is a no-op when the context has no recorder.
+func Emit(ctx context.Context, e Event) {
+	r, ok := ctx.Value(recorderKey{}).(*Recorder)
+	if !ok {
+		return
+	}
+	if e.ID == "" {
+		e.ID = newID()
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	e.RequestID = r.requestID
+
+	r.mu.Lock()
+	r.events = append(r.events, e)
+	r.mu.Unlock()
+}
+
+// Flush writes the buffered events to every sink and clears the buffer
+func (r *Recorder) Flush(ctx context.Context, sinks ...Sink) error {
+	r.mu.Lock()
+	batch := r.events
+	r.events = nil
+	r.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+	var errs []error
+	for _, s := range sinks {
+		if err := s.Write(ctx, batch); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,113 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+)
+
+// defaultTenant is the partition for events without a tenant
+const defaultTenant = "default"
+
+// S3Log is the audit event log: each flush writes new newline-delimited
+// JSON objects under Prefix/dt=YYYY-MM-DD/tenant=ID/, one per partition.
+// Objects are never rewritten, so the bucket can enforce Object Lock and
+// Athena can query the prefix as a partitioned table.
+type S3Log struct {
+	Bucket string
+	Prefix string
+	client s3iface.S3API
+	// tenants overrides Bucket for tenants with data residency
+	tenants map[string]s3Target
+}
+
+type s3Target struct {
+	bucket string
+	client s3iface.S3API
+}
+
+// NewS3Log writes to bucket; with no bucket only RouteTenant's tenants are logged
+func NewS3Log(sess *session.Session, bucket, prefix string) *S3Log {
+	client := s3.New(sess)
+	xray.AWS(client.Client)
+	return &S3Log{Bucket: bucket, Prefix: prefix, client: client, tenants: make(map[string]s3Target)}
+}
+
+// RouteTenant writes tenantID's events to its own bucket, in region when set
+func (l *S3Log) RouteTenant(sess *session.Session, tenantID, bucket, region string) {
+	client := s3.New(sess)
+	if region != "" {
+		client = s3.New(sess, aws.NewConfig().WithRegion(region))
+	}
+	xray.AWS(client.Client)
+	l.tenants[tenantID] = s3Target{bucket: bucket, client: client}
+}
+
+// Write stores events grouped by date and tenant partition
+func (l *S3Log) Write(ctx context.Context, events []Event) error {
+	partitions := make(map[string]*bytes.Buffer)
+	targets := make(map[string]s3Target)
+	var order []string
+	for _, e := range events {
+		key := l.partition(e)
+		buf, ok := partitions[key]
+		if !ok {
+			buf = &bytes.Buffer{}
+			partitions[key] = buf
+			targets[key] = l.target(e.TenantID)
+			order = append(order, key)
+		}
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	first := events[0]
+	for _, prefix := range order {
+		target := targets[prefix]
+		if target.bucket == "" {
+			continue
+		}
+		key := fmt.Sprintf("%s%s-%s.ndjson", prefix, first.Time.Format("20060102T150405Z"), first.RequestID)
+		_, err := target.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+			Bucket:      aws.String(target.bucket),
+			Key:         aws.String(key),
+			Body:        bytes.NewReader(partitions[prefix].Bytes()),
+			ContentType: aws.String("application/x-ndjson"),
+		})
+		if err != nil {
+			return fmt.Errorf("event log write %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func (l *S3Log) target(tenantID string) s3Target {
+	if t, ok := l.tenants[tenantID]; ok && tenantID != "" {
+		return t
+	}
+	return s3Target{bucket: l.Bucket, client: l.client}
+}
+
+func (l *S3Log) partition(e Event) string {
+	tenant := e.TenantID
+	if tenant == "" {
+		tenant = defaultTenant
+	}
+	prefix := l.Prefix
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return fmt.Sprintf("%sdt=%s/tenant=%s/", prefix, e.Time.UTC().Format("2006-01-02"), tenant)
+}
@@ -0,0 +1,90 @@
+package events
+
+import "time"
+
+// Event payloads. Every event is published as the Event envelope
+//
+//	{"id", "type", "time", "requestId", "tenantId", "propertyId", "phone", "data"}
+//
+// with "data" holding one of the payloads below. On EventBridge the
+// envelope is the event "detail", the source is the configured event
+// source, and the detail-type is the payload name (e.g. "ShowingBooked").
+// Fields are only ever added to these payloads, never renamed or removed.
+
+// InquiryReceived (type "inquiry"): a caller or texter asked about a property
+type InquiryReceived struct {
+	Query  string `json:"query"`
+	Source string `json:"source,omitempty"` // marketing channel, see models.Request.Source
+}
+
+// PropertyMatched (type "match"): the inquiry resolved to a property and,
+// unless the property is self-guided, a leasing agent
+type PropertyMatched struct {
+	Agent      string `json:"agent,omitempty"`      // agent email
+	Zone       string `json:"zone,omitempty"`       // agent zone, e.g. "PD1"
+	AssignedBy string `json:"assignedBy,omitempty"` // group, directory, feed, geo
+	Provenance string `json:"provenance"`           // appfolio, buildium, yardi, feed
+	SelfGuided bool   `json:"selfGuided,omitempty"`
+}
+
+// MatchFailed (type "match_failed"): the inquiry could not be matched
+type MatchFailed struct {
+	Stage  string `json:"stage"` // search, property, agent
+	Reason string `json:"reason"`
+	Query  string `json:"query"`
+}
+
+// ShowingOffered (type "offer"): showing times were offered for a property
+type ShowingOffered struct {
+	SlotsAvailable int         `json:"slotsAvailable"`
+	Suggested      []time.Time `json:"suggested"` // start times of the ranked suggestions
+}
+
+// ShowingBooked (type "booking"): a showing was booked
+type ShowingBooked struct {
+	Channel         string    `json:"channel"` // sms or voice
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	Agent           string    `json:"agent,omitempty"` // agent email; empty for self-guided tours
+	CalendarEventID string    `json:"calendarEventId,omitempty"`
+	SelfGuided      bool      `json:"selfGuided,omitempty"`
+	AccessCodeID    string    `json:"accessCodeId,omitempty"`
+	Source          string    `json:"source,omitempty"` // marketing channel of the inquiry
+}
+
+// ShowingCancelled (type "cancellation"): a booked showing was cancelled
+type ShowingCancelled struct {
+	Channel         string     `json:"channel"`
+	Start           *time.Time `json:"start,omitempty"`
+	CalendarEventID string     `json:"calendarEventId,omitempty"`
+	AccessCodeID    string     `json:"accessCodeId,omitempty"`
+}
+
+// ShowingRescheduled (type "reschedule"): a booked showing was moved to
+// another time, keeping its calendar event
+type ShowingRescheduled struct {
+	Channel         string    `json:"channel"`
+	PreviousStart   time.Time `json:"previousStart"`
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	CalendarEventID string    `json:"calendarEventId,omitempty"`
+}
+
+// detailTypes maps event types to their published payload names
+var detailTypes = map[string]string{
+	TypeInquiry:      "InquiryReceived",
+	TypeMatch:        "PropertyMatched",
+	TypeMatchFailed:  "MatchFailed",
+	TypeOffer:        "ShowingOffered",
+	TypeBooking:      "ShowingBooked",
+	TypeCancellation: "ShowingCancelled",
+	TypeReschedule:   "ShowingRescheduled",
+}
+
+// DetailType returns the published name of the event's payload
+func (e Event) DetailType() string {
+	if name, ok := detailTypes[e.Type]; ok {
+		return name
+	}
+	return e.Type
+}
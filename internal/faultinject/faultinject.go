@@ -0,0 +1,158 @@
+// Package faultinject injects latency, errors and malformed responses into
+// dependency calls so degradation paths can be exercised outside production.
+//
+// Faults are described per dependency (the breaker name, e.g. "search",
+// "appfolio", "google_calendar") as a spec string:
+//
+//	search:error;appfolio:latency=3s,p=0.5;google_calendar:status=503;supabase:malformed
+//
+// Keys: latency=<duration> delays the call; error fails it with a transport
+// error; status=<code> replaces the response with an empty one of that
+// status; malformed replaces the response body with invalid JSON;
+// p=<0..1> is the probability the rule fires (default 1).
+package faultinject
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInjected is the transport error returned by "error" rules
+var ErrInjected = errors.New("fault injected")
+
+// Rule is the fault configuration for one dependency
+type Rule struct {
+	Latency     time.Duration
+	Error       bool
+	Status      int
+	Malformed   bool
+	Probability float64
+}
+
+var (
+	mu    sync.RWMutex
+	rules map[string]Rule
+)
+
+// Configure installs the rules in spec. It refuses to enable anything when
+// production is true, so a stray variable can't degrade live traffic.
+func Configure(spec string, production bool) error {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil
+	}
+	if production {
+		slog.Warn("fault_injection_refused", "reason", "production environment")
+		return nil
+	}
+
+	parsed, err := Parse(spec)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	rules = parsed
+	mu.Unlock()
+
+	slog.Warn("fault_injection_enabled", "spec", spec)
+	return nil
+}
+
+// Parse decodes a spec string into per-dependency rules
+func Parse(spec string) (map[string]Rule, error) {
+	parsed := make(map[string]Rule)
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		dep, opts, _ := strings.Cut(entry, ":")
+		rule := Rule{Probability: 1}
+		for _, opt := range strings.Split(opts, ",") {
+			key, val, _ := strings.Cut(strings.TrimSpace(opt), "=")
+			var err error
+			switch key {
+			case "":
+			case "latency":
+				rule.Latency, err = time.ParseDuration(val)
+			case "error":
+				rule.Error = true
+			case "status":
+				rule.Status, err = strconv.Atoi(val)
+			case "malformed":
+				rule.Malformed = true
+			case "p":
+				rule.Probability, err = strconv.ParseFloat(val, 64)
+			default:
+				err = errors.New("unknown key")
+			}
+			if err != nil {
+				return nil, fmt.Errorf("fault spec %q: option %q: %w", entry, opt, err)
+			}
+		}
+		parsed[strings.TrimSpace(dep)] = rule
+	}
+	return parsed, nil
+}
+
+// Wrap returns base with the named dependency's faults applied. When no
+// rule is configured for name at call time, requests pass straight through.
+func Wrap(name string, base http.RoundTripper) http.RoundTripper {
+	return &transport{name: name, base: base}
+}
+
+type transport struct {
+	name string
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	mu.RLock()
+	rule, ok := rules[t.name]
+	mu.RUnlock()
+
+	if !ok || rand.Float64() >= rule.Probability {
+		return t.base.RoundTrip(req)
+	}
+
+	if rule.Latency > 0 {
+		select {
+		case <-time.After(rule.Latency):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+	if rule.Error {
+		return nil, fmt.Errorf("%w: %s", ErrInjected, t.name)
+	}
+	if rule.Status != 0 {
+		return &http.Response{
+			StatusCode: rule.Status,
+			Status:     fmt.Sprintf("%d %s", rule.Status, http.StatusText(rule.Status)),
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     make(http.Header),
+			Body:       io.NopCloser(strings.NewReader("")),
+			Request:    req,
+		}, nil
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || !rule.Malformed {
+		return resp, err
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(strings.NewReader(`{"data": [{"Id": `))
+	resp.ContentLength = -1
+	resp.Header.Del("Content-Length")
+	return resp, nil
+}
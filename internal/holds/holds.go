@@ -0,0 +1,150 @@
+// Package holds keeps short-lived holds on offered slots, so a slot read
+// out to one caller isn't offered to (and booked by) a concurrent one
+// until the hold expires.
+package holds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+// maxSlotLength bounds how long before a range a held slot can start and
+// still overlap it
+const maxSlotLength = 2 * time.Hour
+
+// Hold is a slot on an agent's calendar reserved for Holder (a call or a
+// phone number) until ExpiresAt
+type Hold struct {
+	AgentEmail string    `json:"agent_email"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Holder     string    `json:"holder"`
+	PropertyID string    `json:"property_id,omitempty"`
+	ExpiresAt  int64     `json:"expires_at"` // Unix seconds
+}
+
+// DynamoStore keeps holds in a DynamoDB table with partition key
+// "agent_email", sort key "start" (RFC 3339 UTC) and TTL attribute
+// "expires_at". TTL deletion lags, so expiry is also checked on read.
+type DynamoStore struct {
+	Table  string
+	client dynamodbiface.DynamoDBAPI
+}
+
+func NewDynamoStore(sess *session.Session, table string) *DynamoStore {
+	client := dynamodb.New(sess)
+	xray.AWS(client.Client)
+	return &DynamoStore{Table: table, client: client}
+}
+
+// Hold reserves [start, end) on the agent's calendar for holder for ttl. It
+// reports false when another holder's unexpired hold has the slot; holder's
+// own hold is extended.
+func (s *DynamoStore) Hold(ctx context.Context, agentEmail, holder, propertyID string, start, end time.Time, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	item, err := dynamodbattribute.MarshalMap(Hold{
+		AgentEmail: strings.ToLower(agentEmail),
+		Start:      start.UTC(),
+		End:        end.UTC(),
+		Holder:     holder,
+		PropertyID: propertyID,
+		ExpiresAt:  now.Add(ttl).Unix(),
+	})
+	if err != nil {
+		return false, fmt.Errorf("slot hold: %w", err)
+	}
+	_, err = s.client.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(s.Table),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(agent_email) OR expires_at < :now OR holder = :holder"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":now":    {N: aws.String(strconv.FormatInt(now.Unix(), 10))},
+			":holder": {S: aws.String(holder)},
+		},
+	})
+	var aerr awserr.Error
+	if errors.As(err, &aerr) && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("slot hold: %w", err)
+	}
+	return true, nil
+}
+
+// HeldByOthers returns the agent's unexpired holds overlapping [from, to)
+// whose holder isn't holder, as busy periods
+func (s *DynamoStore) HeldByOthers(ctx context.Context, agentEmail, holder string, from, to time.Time) ([]models.TimeRange, error) {
+	input := &dynamodb.QueryInput{
+		TableName:                aws.String(s.Table),
+		KeyConditionExpression:   aws.String("agent_email = :k AND #s BETWEEN :from AND :to"),
+		FilterExpression:         aws.String("expires_at > :now AND holder <> :holder"),
+		ExpressionAttributeNames: map[string]*string{"#s": aws.String("start")},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":k":    {S: aws.String(strings.ToLower(agentEmail))},
+			":from": {S: aws.String(from.Add(-maxSlotLength).UTC().Format(time.RFC3339))},
+			// BETWEEN is inclusive; stop just short of to
+			":to":     {S: aws.String(to.UTC().Add(-time.Second).Format(time.RFC3339))},
+			":now":    {N: aws.String(strconv.FormatInt(time.Now().Unix(), 10))},
+			":holder": {S: aws.String(holder)},
+		},
+	}
+
+	var held []models.TimeRange
+	var decodeErr error
+	err := s.client.QueryPagesWithContext(ctx, input, func(page *dynamodb.QueryOutput, _ bool) bool {
+		var items []Hold
+		if decodeErr = dynamodbattribute.UnmarshalListOfMaps(page.Items, &items); decodeErr != nil {
+			return false
+		}
+		for _, h := range items {
+			if h.End.After(from) {
+				held = append(held, models.TimeRange{Start: h.Start, End: h.End})
+			}
+		}
+		return true
+	})
+	if err == nil {
+		err = decodeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("slot holds read: %w", err)
+	}
+	return held, nil
+}
+
+// Release drops holder's hold on the slot starting at start, e.g. once it
+// is booked. Another holder's hold is left alone.
+func (s *DynamoStore) Release(ctx context.Context, agentEmail, holder string, start time.Time) error {
+	_, err := s.client.DeleteItemWithContext(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.Table),
+		Key: map[string]*dynamodb.AttributeValue{
+			"agent_email": {S: aws.String(strings.ToLower(agentEmail))},
+			"start":       {S: aws.String(start.UTC().Format(time.RFC3339))},
+		},
+		ConditionExpression:       aws.String("holder = :holder"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":holder": {S: aws.String(holder)}},
+	})
+	var aerr awserr.Error
+	if errors.As(err, &aerr) && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("slot hold release: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+// Package inflight coalesces concurrent calls for the same key into one
+// execution whose result every caller shares.
+package inflight
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// errPanicked is what waiters see when the shared call panicked
+var errPanicked = errors.New("inflight: call panicked")
+
+// Group runs at most one call per key at a time. A successful result is
+// also kept for the group's window after the call returns, so duplicates
+// arriving just behind it share it too.
+type Group[T any] struct {
+	mu     sync.Mutex
+	window time.Duration
+	calls  map[string]*call[T]
+}
+
+type call[T any] struct {
+	done chan struct{}
+	val  T
+	err  error
+}
+
+// New creates a group keeping results for window after their call returns;
+// zero coalesces only calls that overlap
+func New[T any](window time.Duration) *Group[T] {
+	return &Group[T]{window: window, calls: make(map[string]*call[T])}
+}
+
+// Do runs fn, or waits for the call already running or just finished for
+// key and returns its result. shared reports whether the result came from
+// another caller's call.
+func (g *Group[T]) Do(key string, fn func() (T, error)) (val T, err error, shared bool) {
+	g.mu.Lock()
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		<-c.done
+		return c.val, c.err, true
+	}
+	c := &call[T]{done: make(chan struct{}), err: errPanicked}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		close(c.done)
+		if c.err != nil || g.window <= 0 {
+			g.forget(key, c)
+			return
+		}
+		time.AfterFunc(g.window, func() { g.forget(key, c) })
+	}()
+	c.val, c.err = fn()
+	return c.val, c.err, false
+}
+
+// forget removes c unless a newer call for key has replaced it
+func (g *Group[T]) forget(key string, c *call[T]) {
+	g.mu.Lock()
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+	g.mu.Unlock()
+}
@@ -0,0 +1,118 @@
+// Package instrument logs and meters every dependency call made through the
+// shared dependency transport (breaker.Transport), so a failing call shows
+// up with its dependency, status and latency rather than only as an error
+// string in whichever handler happened to make it.
+package instrument
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+)
+
+type contextKey string
+
+const retryKey contextKey = "dependency_retry"
+
+// WithRetry marks calls made with ctx as retry number n of the same
+// operation (e.g. a Supabase read retried on the replica)
+func WithRetry(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, retryKey, n)
+}
+
+func retryCount(ctx context.Context) int {
+	n, _ := ctx.Value(retryKey).(int)
+	return n
+}
+
+// Transport wraps base so each round trip to the named dependency is logged
+// as "dependency_call" and recorded as DependencyCall and DependencyLatency
+// metrics. Transport errors and 4xx/5xx responses log at warn.
+func Transport(name string, base http.RoundTripper) http.RoundTripper {
+	return &transport{name: name, base: base}
+}
+
+type transport struct {
+	name string
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	latency := time.Since(start)
+
+	outcome := "error"
+	args := []any{
+		"dependency", t.name,
+		"method", req.Method,
+		"url", SanitizeURL(req.URL),
+		"latency_ms", latency.Milliseconds(),
+		"retry", retryCount(ctx),
+	}
+	level := slog.LevelInfo
+	if err != nil {
+		level = slog.LevelWarn
+		args = append(args, "error", err)
+	} else {
+		outcome = strconv.Itoa(resp.StatusCode/100) + "xx"
+		args = append(args, "status", resp.StatusCode)
+		if resp.StatusCode >= 400 {
+			level = slog.LevelWarn
+		}
+	}
+	slog.Log(ctx, level, "dependency_call", args...)
+
+	metrics.Incr(ctx, "DependencyCall", "Dependency", t.name, "Outcome", outcome)
+	metrics.Record(ctx, "DependencyLatency", float64(latency.Milliseconds()), metrics.Milliseconds, "Dependency", t.name)
+	return resp, err
+}
+
+// SanitizeURL renders u for logs without credentials or query values, and
+// with path segments that look like emails or phone numbers masked
+func SanitizeURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	segments := strings.Split(u.EscapedPath(), "/")
+	for i, seg := range segments {
+		if personal(seg) {
+			segments[i] = "redacted"
+		}
+	}
+	out := u.Scheme + "://" + u.Host + strings.Join(segments, "/")
+
+	if u.RawQuery == "" {
+		return out
+	}
+	query := u.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k+"=redacted")
+	}
+	sort.Strings(keys)
+	return out + "?" + strings.Join(keys, "&")
+}
+
+// personal reports whether a path segment looks like an email address or
+// phone number
+func personal(seg string) bool {
+	if strings.Contains(seg, "@") || strings.Contains(seg, "%40") {
+		return true
+	}
+	digits := 0
+	for _, r := range seg {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+	return digits >= 10 && digits*2 >= len(seg)
+}
@@ -0,0 +1,180 @@
+// Package lifecycle enforces the booking state machine:
+//
+//	offered → held → booked → confirmed → completed
+//	                                    ↘ cancelled / no_show
+//
+// A step may be skipped going forward (an SMS hold is confirmed straight
+// from held, a booking without a hold starts at booked), and any booking
+// still occupying its slot may be cancelled. Completed, cancelled and
+// no-show are final. Every write re-reads the booking first and is
+// conditional on the version read, so a flow acting on a stale copy (e.g. a
+// YES racing the hold-release job) gets ErrInvalidTransition instead of
+// overwriting the newer state.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/store"
+)
+
+// ErrInvalidTransition is returned when a booking can't move to the
+// requested status from its current one
+var ErrInvalidTransition = errors.New("invalid booking transition")
+
+// conflictAttempts bounds how often Transition re-reads a booking that
+// another invocation wrote between its read and its write
+const conflictAttempts = 3
+
+// transitions lists the statuses each status may move to
+var transitions = map[string][]string{
+	models.BookingOffered:   {models.BookingHeld, models.BookingBooked, models.BookingCancelled},
+	models.BookingHeld:      {models.BookingBooked, models.BookingConfirmed, models.BookingCancelled},
+	models.BookingBooked:    {models.BookingConfirmed, models.BookingCompleted, models.BookingCancelled, models.BookingNoShow},
+	models.BookingConfirmed: {models.BookingCompleted, models.BookingCancelled, models.BookingNoShow},
+}
+
+// Allowed reports whether a booking may move from one status to another
+func Allowed(from, to string) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Hook runs after a booking has moved out of status from. Hooks must not
+// fail the transition: they log their own errors.
+type Hook func(ctx context.Context, from string, booking models.Booking)
+
+// AnyStatus registers a hook for every transition
+const AnyStatus = ""
+
+// Machine applies transitions to bookings in a store and fires hooks
+type Machine struct {
+	Store store.Bookings
+
+	mu    sync.RWMutex
+	hooks map[string][]Hook
+}
+
+func New(bookings store.Bookings) *Machine {
+	return &Machine{Store: bookings, hooks: make(map[string][]Hook)}
+}
+
+// On registers h to run after each transition into status (or every
+// transition, for AnyStatus)
+func (m *Machine) On(status string, h Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks[status] = append(m.hooks[status], h)
+}
+
+// Transition moves booking id to status, applying change (if non-nil) to
+// the record before it is written, and fires the hooks. Moving a booking to
+// the status it already has succeeds without a write or hooks, so retried
+// requests are harmless. A write that loses to a concurrent one is retried
+// from a fresh read; store.ErrConflict is returned if it keeps losing.
+func (m *Machine) Transition(ctx context.Context, id, status string, change func(*models.Booking)) (models.Booking, error) {
+	for attempt := 1; ; attempt++ {
+		booking, from, err := m.transition(ctx, id, status, change)
+		if errors.Is(err, store.ErrConflict) && attempt < conflictAttempts {
+			slog.InfoContext(ctx, "booking_write_conflict", "booking_id", id, "to", status, "attempt", attempt)
+			continue
+		}
+		if err != nil || from == "" {
+			return booking, err
+		}
+		slog.InfoContext(ctx, "booking_transitioned", "booking_id", id, "from", from, "to", status)
+
+		m.mu.RLock()
+		hooks := append(append([]Hook{}, m.hooks[status]...), m.hooks[AnyStatus]...)
+		m.mu.RUnlock()
+		for _, h := range hooks {
+			h(ctx, from, booking)
+		}
+		return booking, nil
+	}
+}
+
+// Reschedule moves booking id to [start, end), keeping its status. Like
+// Transition it writes against a fresh read, retrying lost writes; a
+// booking no longer occupying its slot gets ErrInvalidTransition.
+func (m *Machine) Reschedule(ctx context.Context, id string, start, end time.Time) (models.Booking, error) {
+	for attempt := 1; ; attempt++ {
+		current, err := m.Store.Get(ctx, id)
+		if err != nil {
+			return models.Booking{}, err
+		}
+		if current == nil {
+			return models.Booking{}, store.ErrNotFound
+		}
+		booking := *current
+		if !booking.Active() {
+			return booking, fmt.Errorf("%w: reschedule while %s", ErrInvalidTransition, booking.Status)
+		}
+		booking.Start, booking.End = start, end
+		err = m.Store.Update(ctx, booking)
+		if errors.Is(err, store.ErrConflict) && attempt < conflictAttempts {
+			slog.InfoContext(ctx, "booking_write_conflict", "booking_id", id, "to", "rescheduled", "attempt", attempt)
+			continue
+		}
+		if err != nil {
+			return booking, err
+		}
+		booking.Version++
+		return booking, nil
+	}
+}
+
+// transition makes one read-check-write pass, returning the status the
+// booking moved from, or "" if it already had status
+func (m *Machine) transition(ctx context.Context, id, status string, change func(*models.Booking)) (models.Booking, string, error) {
+	current, err := m.Store.Get(ctx, id)
+	if err != nil {
+		return models.Booking{}, "", err
+	}
+	if current == nil {
+		return models.Booking{}, "", store.ErrNotFound
+	}
+	booking := *current
+	if booking.Status == status {
+		return booking, "", nil
+	}
+	if !Allowed(booking.Status, status) {
+		return booking, "", fmt.Errorf("%w: %s to %s", ErrInvalidTransition, booking.Status, status)
+	}
+
+	from := booking.Status
+	apply(&booking, status, time.Now().UTC())
+	if change != nil {
+		change(&booking)
+	}
+	if err := m.Store.Update(ctx, booking); err != nil {
+		return booking, "", err
+	}
+	// Update wrote the next version
+	booking.Version++
+	return booking, from, nil
+}
+
+// apply sets status and the timestamps that go with it
+func apply(b *models.Booking, status string, now time.Time) {
+	b.Status = status
+	if status != models.BookingHeld {
+		b.ConfirmBy = nil
+	}
+	switch status {
+	case models.BookingConfirmed:
+		b.ConfirmedAt = &now
+	case models.BookingCancelled:
+		b.CancelledAt = &now
+	}
+}
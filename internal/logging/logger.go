@@ -4,27 +4,146 @@ import (
 	"context"
 	"log/slog"
 	"os"
+	"strings"
 )
 
 type contextKey string
 
 const (
 	RequestIDKey contextKey = "request_id"
+	TenantIDKey  contextKey = "tenant_id"
+	CallIDKey    contextKey = "call_id"
+
+	levelKey contextKey = "log_level"
 )
 
-// Init sets the global logger to JSON output for CloudWatch
+// contextFields are the request-scoped IDs added to every record logged
+// with a context that carries them
+var contextFields = []contextKey{RequestIDKey, TenantIDKey, CallIDKey}
+
+// Init sets the global logger to JSON output for CloudWatch. Records logged
+// with a context (slog.InfoContext etc.) carry its request, tenant and call
+// IDs, so callers don't pass them.
 func Init() {
 	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
+		// The handler filters by level itself; see contextHandler.Enabled
+		Level: slog.LevelDebug,
 	})
-	slog.SetDefault(slog.New(handler))
+	slog.SetDefault(slog.New(&contextHandler{base: handler, level: envLevel()}))
 }
 
-// WithRequestContext returns a logger enriched with request-scoped fields
-func WithRequestContext(ctx context.Context) *slog.Logger {
+func envLevel() slog.Level {
+	if level, ok := ParseLevel(os.Getenv("LOG_LEVEL")); ok {
+		return level
+	}
+	return slog.LevelInfo
+}
+
+// ParseLevel parses "debug", "info", "warn" or "error"
+func ParseLevel(s string) (slog.Level, bool) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil || s == "" {
+		return 0, false
+	}
+	return level, true
+}
+
+// WithRequestID returns ctx carrying the invocation's request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, RequestIDKey, id)
+}
+
+// WithTenantID returns ctx carrying the tenant the invocation is for
+func WithTenantID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, TenantIDKey, id)
+}
+
+// WithCallID returns ctx carrying the voice call the invocation belongs to
+func WithCallID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, CallIDKey, id)
+}
+
+// CallID returns the voice call ctx belongs to, or ""
+func CallID(ctx context.Context) string {
+	id, _ := ctx.Value(CallIDKey).(string)
+	return id
+}
+
+// WithLevel overrides the minimum log level for records logged with ctx,
+// e.g. to debug a single invocation
+func WithLevel(ctx context.Context, level slog.Level) context.Context {
+	return context.WithValue(ctx, levelKey, level)
+}
+
+// FromContext returns a logger pre-bound with ctx's request-scoped IDs and
+// level, for code that logs without passing a context
+func FromContext(ctx context.Context) *slog.Logger {
+	if h, ok := slog.Default().Handler().(*contextHandler); ok {
+		return slog.New(&contextHandler{base: h.base, level: h.level, ctx: ctx})
+	}
 	logger := slog.Default()
-	if reqID, ok := ctx.Value(RequestIDKey).(string); ok {
-		logger = logger.With("request_id", reqID)
+	for _, key := range contextFields {
+		if v, ok := ctx.Value(key).(string); ok {
+			logger = logger.With(string(key), v)
+		}
 	}
 	return logger
 }
+
+// WithRequestContext returns a logger enriched with request-scoped fields
+//
+// Deprecated: use FromContext.
+func WithRequestContext(ctx context.Context) *slog.Logger {
+	return FromContext(ctx)
+}
+
+// contextHandler adds request-scoped IDs from the record's context (or the
+// context bound by FromContext) and applies per-invocation levels
+type contextHandler struct {
+	base  slog.Handler
+	level slog.Level
+	// ctx is the fallback for records logged without a context
+	ctx context.Context
+}
+
+func (h *contextHandler) scope(ctx context.Context) context.Context {
+	if h.ctx != nil && (ctx == nil || ctx.Value(RequestIDKey) == nil) {
+		return h.ctx
+	}
+	return ctx
+}
+
+func (h *contextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	min := h.level
+	if ctx = h.scope(ctx); ctx != nil {
+		if override, ok := ctx.Value(levelKey).(slog.Level); ok {
+			min = override
+		}
+	}
+	return level >= min
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if scoped := h.scope(ctx); scoped != nil {
+		for _, key := range contextFields {
+			if v, ok := scoped.Value(key).(string); ok {
+				r.AddAttrs(slog.String(string(key), v))
+			}
+		}
+	}
+	return h.base.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{base: h.base.WithAttrs(attrs), level: h.level, ctx: h.ctx}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{base: h.base.WithGroup(name), level: h.level, ctx: h.ctx}
+}
@@ -0,0 +1,122 @@
+package logic
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+// StreetQuery is the street number and name picked out of a free-text
+// property query: "123" and "main" from "the house at 123 N. Main St".
+type StreetQuery struct {
+	Number string
+	Name   string
+}
+
+// directions are skipped when looking for the street name after a number
+var directions = map[string]bool{
+	"n": true, "s": true, "e": true, "w": true,
+	"ne": true, "nw": true, "se": true, "sw": true,
+	"north": true, "south": true, "east": true, "west": true,
+}
+
+// ParseStreet returns the first street number in query and the name word
+// that follows it. It reports false when the query has no number followed
+// by a name.
+func ParseStreet(query string) (StreetQuery, bool) {
+	words := addressWords(query)
+	for i, w := range words {
+		if !isDigits(w) {
+			continue
+		}
+		for _, name := range words[i+1:] {
+			if directions[name] {
+				continue
+			}
+			return StreetQuery{Number: w, Name: name}, true
+		}
+		return StreetQuery{}, false
+	}
+	return StreetQuery{}, false
+}
+
+// Matches reports whether address1 (e.g. "123 North Main Street") starts
+// with s's number and has s's name among its words
+func (s StreetQuery) Matches(address1 string) bool {
+	words := addressWords(address1)
+	if len(words) == 0 || words[0] != s.Number {
+		return false
+	}
+	for _, w := range words[1:] {
+		if w == s.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// String renders s for an address filter: "123 main"
+func (s StreetQuery) String() string {
+	return s.Number + " " + s.Name
+}
+
+// unitPattern finds a unit designator and its unit: "apt 4b", "unit #12"
+var unitPattern = regexp.MustCompile(`(?i)(?:\b(?:unit|apt|apartment|suite|ste)\b\.?|#)\s*#?\s*([a-z0-9-]+)`)
+
+// matchBaseConfidence is how often each match source is right when the
+// query's street number can't be checked against the result
+var matchBaseConfidence = map[string]float64{
+	models.MatchOverride: 1,
+	models.MatchOpenAI:   0.85,
+	models.MatchSearch:   0.8,
+	models.MatchFuzzy:    0.7,
+}
+
+// MatchConfidence estimates how likely a property found by source for
+// query is the one the caller meant, given its street address. A query
+// naming the same street number and name raises it; a different street
+// number lowers it.
+func MatchConfidence(source, query, address1 string) float64 {
+	confidence := matchBaseConfidence[source]
+	if source == models.MatchOverride || address1 == "" {
+		return confidence
+	}
+	street, ok := ParseStreet(query)
+	if !ok {
+		return confidence
+	}
+	if street.Matches(address1) {
+		return max(confidence, 0.95)
+	}
+	if words := addressWords(address1); len(words) > 0 && isDigits(words[0]) && words[0] != street.Number {
+		return min(confidence, 0.4)
+	}
+	return confidence
+}
+
+// UnitFromQuery returns the unit named in a query ("4B" from "123 Main St
+// apt 4B"), or "" if there is none
+func UnitFromQuery(query string) string {
+	if m := unitPattern.FindStringSubmatch(query); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// addressWords lowercases s and splits it into runs of letters and digits
+func addressWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,64 @@
+package logic
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+// DiffProperties compares the stored snapshots with the property system's
+// current state and returns the drift, ordered by property ID
+func DiffProperties(stored, current []models.PropertySnapshot) []models.PropertyDrift {
+	was := make(map[string]models.PropertySnapshot, len(stored))
+	for _, s := range stored {
+		was[s.PropertyID] = s
+	}
+
+	var drift []models.PropertyDrift
+	for _, now := range current {
+		old, ok := was[now.PropertyID]
+		delete(was, now.PropertyID)
+		if !ok {
+			drift = append(drift, models.PropertyDrift{PropertyID: now.PropertyID, Kind: models.DriftAdded, Now: snapshotAddress(now)})
+			continue
+		}
+		if !strings.EqualFold(snapshotAddress(old), snapshotAddress(now)) {
+			drift = append(drift, models.PropertyDrift{PropertyID: now.PropertyID, Kind: models.DriftAddress, Was: snapshotAddress(old), Now: snapshotAddress(now)})
+		}
+		if !slices.Equal(sortedZones(old.Zones), sortedZones(now.Zones)) {
+			drift = append(drift, models.PropertyDrift{PropertyID: now.PropertyID, Kind: models.DriftZones,
+				Was: strings.Join(sortedZones(old.Zones), ","), Now: strings.Join(sortedZones(now.Zones), ",")})
+		}
+		if old.Vacant != now.Vacant {
+			drift = append(drift, models.PropertyDrift{PropertyID: now.PropertyID, Kind: models.DriftVacancy,
+				Was: vacancy(old.Vacant), Now: vacancy(now.Vacant)})
+		}
+	}
+	for id, old := range was {
+		drift = append(drift, models.PropertyDrift{PropertyID: id, Kind: models.DriftRemoved, Was: snapshotAddress(old)})
+	}
+
+	slices.SortStableFunc(drift, func(a, b models.PropertyDrift) int { return strings.Compare(a.PropertyID, b.PropertyID) })
+	return drift
+}
+
+func snapshotAddress(s models.PropertySnapshot) string {
+	return strings.Join([]string{s.Address1, s.City, s.State}, ", ")
+}
+
+func sortedZones(zones []string) []string {
+	sorted := make([]string, 0, len(zones))
+	for _, z := range zones {
+		sorted = append(sorted, strings.ToUpper(strings.TrimSpace(z)))
+	}
+	slices.Sort(sorted)
+	return sorted
+}
+
+func vacancy(vacant bool) string {
+	if vacant {
+		return "vacant"
+	}
+	return "occupied"
+}
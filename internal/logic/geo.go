@@ -0,0 +1,34 @@
+package logic
+
+import "sort"
+
+// ZoneForPoint returns the zone whose polygon contains (lat, lng), or "" if
+// none does. Polygons are rings of [lat, lng] vertices; zones are checked in
+// name order so overlapping polygons resolve deterministically.
+func ZoneForPoint(lat, lng float64, polygons map[string][][2]float64) string {
+	zones := make([]string, 0, len(polygons))
+	for zone := range polygons {
+		zones = append(zones, zone)
+	}
+	sort.Strings(zones)
+
+	for _, zone := range zones {
+		if pointInPolygon(lat, lng, polygons[zone]) {
+			return zone
+		}
+	}
+	return ""
+}
+
+// pointInPolygon uses ray casting along the longitude axis
+func pointInPolygon(lat, lng float64, ring [][2]float64) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		yi, xi := ring[i][0], ring[i][1]
+		yj, xj := ring[j][0], ring[j][1]
+		if (yi > lat) != (yj > lat) && lng < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
@@ -15,12 +15,91 @@ var PDAgentMap = map[string]models.AgentInfo{
 
 // MapAgent finds the agent based on property group names (looking for PD1, PD2, etc.)
 func MapAgent(groups []models.AppFolioGroup) *models.AgentInfo {
+	return MapAgentFrom(groups, PDAgentMap)
+}
+
+// MapAgentFrom is MapAgent against an explicit zone → agent roster
+func MapAgentFrom(groups []models.AppFolioGroup, roster map[string]models.AgentInfo) *models.AgentInfo {
 	for _, group := range groups {
 		name := strings.ToUpper(strings.TrimSpace(group.Name))
-		if agent, ok := PDAgentMap[name]; ok {
+		if agent, ok := roster[name]; ok {
 			agent.ZoneGroup = group.Name
 			return &agent
 		}
 	}
 	return nil
 }
+
+// RosterByZone indexes agents by upper-cased zone. An empty list yields the
+// built-in PDAgentMap so a missing agents table never breaks mapping.
+func RosterByZone(agents []models.AgentInfo) map[string]models.AgentInfo {
+	if len(agents) == 0 {
+		return PDAgentMap
+	}
+	roster := make(map[string]models.AgentInfo, len(agents))
+	for _, a := range agents {
+		roster[strings.ToUpper(strings.TrimSpace(a.Zone))] = a
+	}
+	return roster
+}
+
+// MatchRosterAgent resolves agents reported by a property system (which may
+// carry only a name) to a roster entry with a calendar email. Email matches
+// win; otherwise the first name is compared case-insensitively.
+func MatchRosterAgent(candidates []models.AgentInfo, roster map[string]models.AgentInfo) *models.AgentInfo {
+	for _, c := range candidates {
+		for _, agent := range roster {
+			if c.Email != "" && strings.EqualFold(c.Email, agent.Email) {
+				return &agent
+			}
+		}
+	}
+	for _, c := range candidates {
+		first := strings.Fields(c.Name)
+		if len(first) == 0 {
+			continue
+		}
+		for _, agent := range roster {
+			if strings.EqualFold(first[0], agent.Name) || strings.EqualFold(c.Name, agent.Name) {
+				return &agent
+			}
+		}
+	}
+	return nil
+}
+
+// Escalation levels above the zone
+const (
+	EscalationRegion  = "region"
+	EscalationCompany = "company"
+)
+
+// Escalate returns the contact for a property none of whose zones (group
+// names or a geocoded zone, in any case) has an available agent: the first
+// zone's regional contact, else the company contact. It returns nil when
+// the hierarchy has neither.
+func Escalate(hierarchy *models.ZoneHierarchy, zones []string) *models.AgentInfo {
+	if hierarchy == nil {
+		return nil
+	}
+	for _, zone := range zones {
+		zone = strings.ToUpper(strings.TrimSpace(zone))
+		for name, region := range hierarchy.Regions {
+			for _, z := range region.Zones {
+				if strings.ToUpper(strings.TrimSpace(z)) == zone && region.Contact.Email != "" {
+					contact := region.Contact
+					contact.Zone = zone
+					contact.ZoneGroup = name
+					contact.EscalationLevel = EscalationRegion
+					return &contact
+				}
+			}
+		}
+	}
+	if hierarchy.Company != nil && hierarchy.Company.Email != "" {
+		contact := *hierarchy.Company
+		contact.EscalationLevel = EscalationCompany
+		return &contact
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package logic
+
+import (
+	"sort"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+// PreferredDateSpan is how many days either side of a preferred date are
+// searched for alternates
+const PreferredDateSpan = 7
+
+// AlternateDayCount is how many alternate days are offered when the
+// preferred date is full
+const AlternateDayCount = 2
+
+// PreferredDaySlots returns the slots on day (a midnight in the schedule's
+// location). When day has none it returns the slots of the n days nearest to
+// it that do, in time order, along with those days (earlier day first on a
+// tie in distance).
+func PreferredDaySlots(slots []models.TimeSlot, day time.Time, n int) ([]models.TimeSlot, []time.Time) {
+	byDay := make(map[time.Time][]models.TimeSlot)
+	var days []time.Time
+	for _, slot := range slots {
+		start := slot.Start.In(day.Location())
+		d := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, day.Location())
+		if _, ok := byDay[d]; !ok {
+			days = append(days, d)
+		}
+		byDay[d] = append(byDay[d], slot)
+	}
+	if matches, ok := byDay[day]; ok {
+		return matches, nil
+	}
+
+	distance := func(d time.Time) time.Duration {
+		if d.Before(day) {
+			return day.Sub(d)
+		}
+		return d.Sub(day)
+	}
+	sort.SliceStable(days, func(i, j int) bool {
+		if di, dj := distance(days[i]), distance(days[j]); di != dj {
+			return di < dj
+		}
+		return days[i].Before(days[j])
+	})
+	if len(days) > n {
+		days = days[:n]
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+	var alternates []models.TimeSlot
+	for _, d := range days {
+		alternates = append(alternates, byDay[d]...)
+	}
+	return alternates, days
+}
@@ -0,0 +1,15 @@
+package logic
+
+import "time"
+
+// Quiet hours: business-initiated texts are not sent from 9 PM to 8 AM in
+// the recipient's local time.
+const (
+	QuietHoursStart = 21
+	QuietHoursEnd   = 8
+)
+
+// InQuietHours reports whether t (in the recipient's zone) is within quiet hours
+func InQuietHours(t time.Time) bool {
+	return t.Hour() >= QuietHoursStart || t.Hour() < QuietHoursEnd
+}
@@ -0,0 +1,135 @@
+package logic
+
+import (
+	"sort"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+const (
+	// TravelBuffer is the slack wanted on top of drive time between appointments
+	TravelBuffer = 10 * time.Minute
+	// PrecedingEventWindow is how far back an event still counts as "just before" a slot
+	PrecedingEventWindow = 3 * time.Hour
+	// SuggestionCount is how many ranked slots are suggested
+	SuggestionCount = 3
+
+	TravelRiskTight      = "tight"
+	TravelRiskInfeasible = "infeasible"
+
+	// ClusterMaxGap is the largest gap between a slot and an existing showing
+	// for the two to count as back-to-back
+	ClusterMaxGap = 15 * time.Minute
+)
+
+// RankingWeights tunes RankSlots. Weights are expressed in days of waiting:
+// a ClusterBonus of 1.5 prefers a clustered slot over a lone slot up to a
+// day and a half sooner.
+type RankingWeights struct {
+	ClusterBonus float64
+}
+
+// ShowingWindow is an existing showing at or near the property being scheduled
+type ShowingWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+// AnnotateClusters marks slots that sit back-to-back with a nearby showing,
+// so agents can batch showings geographically.
+func AnnotateClusters(slots []models.TimeSlot, nearby []ShowingWindow) {
+	for i := range slots {
+		for _, w := range nearby {
+			before := slots[i].Start.Sub(w.End)
+			after := w.Start.Sub(slots[i].End)
+			if (before >= 0 && before <= ClusterMaxGap) || (after >= 0 && after <= ClusterMaxGap) {
+				slots[i].Clustered = true
+				break
+			}
+		}
+	}
+}
+
+// AnnotateTravel flags slots whose preceding calendar event (ending at most
+// PrecedingEventWindow earlier) is somewhere the agent can't drive from in
+// time. travel maps an event location to the drive time to the property;
+// events without a known drive time are ignored.
+func AnnotateTravel(slots []models.TimeSlot, events []models.CalendarEvent, travel map[string]time.Duration) {
+	if len(travel) == 0 {
+		return
+	}
+
+	for i := range slots {
+		prev, prevEnd := precedingEvent(slots[i].Start, events)
+		if prev == nil {
+			continue
+		}
+		drive, ok := travel[prev.Location]
+		if !ok {
+			continue
+		}
+
+		gap := slots[i].Start.Sub(prevEnd)
+		slots[i].TravelMinutes = int(drive.Minutes())
+		switch {
+		case gap < drive:
+			slots[i].TravelRisk = TravelRiskInfeasible
+		case gap < drive+TravelBuffer:
+			slots[i].TravelRisk = TravelRiskTight
+		}
+	}
+}
+
+// precedingEvent returns the located event ending closest before start
+func precedingEvent(start time.Time, events []models.CalendarEvent) (*models.CalendarEvent, time.Time) {
+	var best *models.CalendarEvent
+	var bestEnd time.Time
+	for i := range events {
+		if events[i].Location == "" {
+			continue
+		}
+		end, ok := events[i].End.Time()
+		if !ok || end.After(start) || start.Sub(end) > PrecedingEventWindow {
+			continue
+		}
+		if best == nil || end.After(bestEnd) {
+			best, bestEnd = &events[i], end
+		}
+	}
+	return best, bestEnd
+}
+
+// RankSlots returns up to n slots ordered by preference: the soonest slots
+// first, with travel-risky slots pushed behind comfortable ones and slots
+// clustered with nearby showings pulled forward. The input order is preserved.
+func RankSlots(slots []models.TimeSlot, n int, weights RankingWeights) []models.TimeSlot {
+	if len(slots) == 0 || n <= 0 {
+		return nil
+	}
+
+	ranked := make([]models.TimeSlot, len(slots))
+	copy(ranked, slots)
+
+	reference := slots[0].Start
+	score := func(s models.TimeSlot) float64 {
+		// One point per day of waiting
+		v := -s.Start.Sub(reference).Hours() / 24
+		switch s.TravelRisk {
+		case TravelRiskInfeasible:
+			v -= 100
+		case TravelRiskTight:
+			v -= 2
+		}
+		if s.Clustered {
+			v += weights.ClusterBonus
+		}
+		return v
+
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return score(ranked[i]) > score(ranked[j])
+	})
+	return ranked[:min(n, len(ranked))]
+}
@@ -0,0 +1,75 @@
+package logic
+
+import (
+	"math"
+	"time"
+)
+
+// Stop is a location an agent visits during the day
+type Stop struct {
+	Label string
+	Start time.Time
+	Lat   float64
+	Lng   float64
+	// Located is false when the stop couldn't be geocoded
+	Located bool
+}
+
+// OrderRoute orders stops with a nearest-neighbour walk starting from the
+// earliest stop. Stops without coordinates keep their chronological place
+// at the end of the route.
+func OrderRoute(stops []Stop) []Stop {
+	var located, unlocated []Stop
+	for _, s := range stops {
+		if s.Located {
+			located = append(located, s)
+		} else {
+			unlocated = append(unlocated, s)
+		}
+	}
+	if len(located) == 0 {
+		return stops
+	}
+
+	first := 0
+	for i, s := range located {
+		if s.Start.Before(located[first].Start) {
+			first = i
+		}
+	}
+
+	route := make([]Stop, 0, len(stops))
+	visited := make([]bool, len(located))
+	current := first
+	for len(route) < len(located) {
+		visited[current] = true
+		route = append(route, located[current])
+
+		next, bestDist := -1, math.MaxFloat64
+		for i, s := range located {
+			if visited[i] {
+				continue
+			}
+			if d := HaversineKm(located[current].Lat, located[current].Lng, s.Lat, s.Lng); d < bestDist {
+				next, bestDist = i, d
+			}
+		}
+		if next < 0 {
+			break
+		}
+		current = next
+	}
+	return append(route, unlocated...)
+}
+
+// HaversineKm returns the great-circle distance between two points in km
+func HaversineKm(lat1, lng1, lat2, lng2 float64) float64 {
+	const earthRadiusKm = 6371.0
+	toRad := func(d float64) float64 { return d * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return earthRadiusKm * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
@@ -0,0 +1,210 @@
+package logic
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+// defaultRules is the shared read-only copy of DefaultScheduleRules
+var defaultRules = DefaultScheduleRules()
+
+// DefaultScheduleRules are the built-in showing hours: weekdays 9-5,
+// Fridays ending at 3:30, no exclusions.
+func DefaultScheduleRules() *models.ScheduleRules {
+	weekday := models.BusinessHours{Start: "09:00", End: "17:00"}
+	return &models.ScheduleRules{
+		BusinessHours: map[string]models.BusinessHours{
+			"monday":    weekday,
+			"tuesday":   weekday,
+			"wednesday": weekday,
+			"thursday":  weekday,
+			"friday":    {Start: "09:00", End: "15:30"},
+		},
+	}
+}
+
+// businessHours returns the showing window on day's date, or false if the day is closed
+func businessHours(rules *models.ScheduleRules, day time.Time) (time.Time, time.Time, bool) {
+	h, dated := rules.DateHours[day.Format(time.DateOnly)]
+	ok := dated
+	if !ok {
+		h, ok = businessHoursMap(rules)[weekdayKey(day)]
+	}
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	start, okStart := clockOn(day, h.Start)
+	end, okEnd := clockOn(day, h.End)
+	if !okStart || !okEnd || !end.After(start) {
+		slog.Warn("schedule_rules_invalid_hours", "day", weekdayKey(day), "start", h.Start, "end", h.End)
+		return time.Time{}, time.Time{}, false
+	}
+	// Date-specific hours (an agent's working day) aren't extended
+	if !dated {
+		end = eveningEnd(rules, day, end)
+	}
+	return start, end, true
+}
+
+// eveningEnd returns the latest end among the evening extensions in season
+// on day, or end if none extends it
+func eveningEnd(rules *models.ScheduleRules, day time.Time, end time.Time) time.Time {
+	for _, ext := range rules.EveningExtensions {
+		if len(ext.Days) > 0 && !containsDay(ext.Days, weekdayKey(day)) {
+			continue
+		}
+		if !inSeason(ext.From, ext.To, day) {
+			continue
+		}
+		extEnd, ok := clockOn(day, ext.End)
+		if !ok {
+			slog.Warn("schedule_rules_invalid_extension", "label", ext.Label, "end", ext.End)
+			continue
+		}
+		if extEnd.After(end) {
+			end = extEnd
+		}
+	}
+	return end
+}
+
+// inSeason reports whether day's month and day fall within the inclusive
+// "MM-DD" range from..to, which wraps past New Year when from is later
+func inSeason(from, to string, day time.Time) bool {
+	f, errFrom := time.Parse("01-02", strings.TrimSpace(from))
+	t, errTo := time.Parse("01-02", strings.TrimSpace(to))
+	if errFrom != nil || errTo != nil {
+		slog.Warn("schedule_rules_invalid_season", "from", from, "to", to)
+		return false
+	}
+	key := int(day.Month())*100 + day.Day()
+	lo, hi := int(f.Month())*100+f.Day(), int(t.Month())*100+t.Day()
+	if lo <= hi {
+		return key >= lo && key <= hi
+	}
+	return key >= lo || key <= hi
+}
+
+// longestDay returns the longest configured business day, for sizing buffers
+func longestDay(rules *models.ScheduleRules) time.Duration {
+	var longest time.Duration
+	ref := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, hours := range []map[string]models.BusinessHours{businessHoursMap(rules), rules.DateHours} {
+		for _, h := range hours {
+			start, okStart := clockOn(ref, h.Start)
+			end, okEnd := clockOn(ref, h.End)
+			if okStart && okEnd {
+				longest = max(longest, end.Sub(start))
+			}
+			for _, ext := range rules.EveningExtensions {
+				if extEnd, ok := clockOn(ref, ext.End); okStart && ok {
+					longest = max(longest, extEnd.Sub(start))
+				}
+			}
+		}
+	}
+	return longest
+}
+
+// WithWorkingHours returns a copy of rules (nil means the defaults) whose
+// hours on each date covered by windows are that day's working window,
+// from the earliest start to the latest end. Other dates keep the rules'
+// weekday hours.
+func WithWorkingHours(rules *models.ScheduleRules, windows []models.TimeRange) *models.ScheduleRules {
+	if rules == nil {
+		rules = defaultRules
+	}
+	if len(windows) == 0 {
+		return rules
+	}
+	loc := ScheduleLocation(rules)
+
+	type span struct{ start, end time.Time }
+	days := make(map[string]span)
+	for _, w := range windows {
+		start, end := w.Start.In(loc), w.End.In(loc)
+		date := start.Format(time.DateOnly)
+		if end.Format(time.DateOnly) != date {
+			// Clip windows running past midnight to the day they start on
+			end = time.Date(start.Year(), start.Month(), start.Day(), 23, 59, 0, 0, loc)
+		}
+		if d, ok := days[date]; ok {
+			start, end = minTime(start, d.start), maxTime(end, d.end)
+		}
+		days[date] = span{start, end}
+	}
+
+	out := *rules
+	out.DateHours = make(map[string]models.BusinessHours, len(rules.DateHours)+len(days))
+	for date, h := range rules.DateHours {
+		out.DateHours[date] = h
+	}
+	for date, d := range days {
+		out.DateHours[date] = models.BusinessHours{Start: d.start.Format("15:04"), End: d.end.Format("15:04")}
+	}
+	return &out
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func businessHoursMap(rules *models.ScheduleRules) map[string]models.BusinessHours {
+	if rules.BusinessHours == nil {
+		return defaultRules.BusinessHours
+	}
+	return rules.BusinessHours
+}
+
+// exclusionRanges returns the exclusion windows that apply on day's date
+func exclusionRanges(rules *models.ScheduleRules, day time.Time) []models.TimeRange {
+	var ranges []models.TimeRange
+	for _, ex := range rules.Exclusions {
+		if len(ex.Days) > 0 && !containsDay(ex.Days, weekdayKey(day)) {
+			continue
+		}
+		start, okStart := clockOn(day, ex.Start)
+		end, okEnd := clockOn(day, ex.End)
+		if !okStart || !okEnd || !end.After(start) {
+			slog.Warn("schedule_rules_invalid_exclusion", "label", ex.Label, "start", ex.Start, "end", ex.End)
+			continue
+		}
+		ranges = append(ranges, models.TimeRange{Start: start, End: end})
+	}
+	return ranges
+}
+
+// clockOn returns the "HH:MM" wall-clock time on day's date in day's location
+func clockOn(day time.Time, clock string) (time.Time, bool) {
+	t, err := time.Parse("15:04", strings.TrimSpace(clock))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location()), true
+}
+
+func weekdayKey(day time.Time) string {
+	return strings.ToLower(day.Weekday().String())
+}
+
+func containsDay(days []string, key string) bool {
+	for _, d := range days {
+		if strings.EqualFold(strings.TrimSpace(d), key) {
+			return true
+		}
+	}
+	return false
+}
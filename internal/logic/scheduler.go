@@ -1,54 +1,127 @@
 package logic
 
 import (
-	"log/slog"
 	"time"
 
 	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
 )
 
 const (
-	WorkStartHour = 9
-	WorkEndHour   = 17
-	SlotDuration  = 30 * time.Minute
-	MaxDays       = 7
+	SlotDuration = 30 * time.Minute
+	MaxDays      = 7
+	// MaxRangeDays bounds an explicit date range (GenerateSlotsInRange)
+	MaxRangeDays = 31
+
+	slotDateLayout = "Monday, January 2, 2006"
+	slotTimeLayout = "3:04 PM"
+
+	// Bounds for per-property tour duration overrides
+	MinSlotDuration = 15 * time.Minute
+	MaxSlotDuration = 2 * time.Hour
 )
 
-// GenerateAvailableSlots calculates free slots given busy periods
-func GenerateAvailableSlots(busySlots []models.TimeRange, referenceTime time.Time) ([]models.TimeSlot, int, int) {
-	loc, err := time.LoadLocation("America/Los_Angeles")
-	if err != nil {
-		loc = time.UTC
-		slog.Warn("timezone_load_failed", "timezone", "America/Los_Angeles", "error", err)
+// TourDuration returns the slot length for a property's tour-duration
+// override in minutes, falling back to SlotDuration when unset and clamping
+// to [MinSlotDuration, MaxSlotDuration].
+func TourDuration(minutes int) time.Duration {
+	if minutes <= 0 {
+		return SlotDuration
+	}
+	d := time.Duration(minutes) * time.Minute
+	return min(max(d, MinSlotDuration), MaxSlotDuration)
+}
+
+// GenerateAvailableSlots calculates free slots of the given length given busy
+// periods, within the business hours and outside the exclusion windows of
+// rules (nil means DefaultScheduleRules), in the rules' time zone.
+func GenerateAvailableSlots(busySlots []models.TimeRange, referenceTime time.Time, rules *models.ScheduleRules, slotDuration time.Duration) ([]models.TimeSlot, int, int) {
+	return generateSlots(busySlots, referenceTime, referenceTime, time.Time{}, MaxDays, rules, slotDuration, 0)
+}
+
+// GenerateSlotsInRange is GenerateAvailableSlots over [from, to) instead of
+// the next MaxDays days. from is raised to now and the range is capped at
+// MaxRangeDays.
+func GenerateSlotsInRange(busySlots []models.TimeRange, now, from, to time.Time, rules *models.ScheduleRules, slotDuration time.Duration) ([]models.TimeSlot, int, int) {
+	from, to, days := rangeDays(now, from, to, rules)
+	return generateSlots(busySlots, now, from, to, days, rules, slotDuration, 0)
+}
+
+// GenerateEarliestSlots returns the first n free slots in [from, to) (see
+// GenerateSlotsInRange), stopping as soon as they are found. The counts
+// cover only the days walked.
+func GenerateEarliestSlots(busySlots []models.TimeRange, now, from, to time.Time, rules *models.ScheduleRules, slotDuration time.Duration, n int) ([]models.TimeSlot, int, int) {
+	from, to, days := rangeDays(now, from, to, rules)
+	return generateSlots(busySlots, now, from, to, days, rules, slotDuration, max(n, 1))
+}
+
+// rangeDays clamps [from, to) and converts it to the rules' time zone,
+// returning the number of calendar days it touches.
+func rangeDays(now, from, to time.Time, rules *models.ScheduleRules) (time.Time, time.Time, int) {
+	from, to = ClampRange(now, from, to)
+	loc := ScheduleLocation(rules)
+	from, to = from.In(loc), to.In(loc)
+
+	days := 0
+	for d := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc); d.Before(to); d = d.AddDate(0, 0, 1) {
+		days++
+	}
+	return from, to, days
+}
+
+// ClampRange raises from to now and caps [from, to) at MaxRangeDays
+func ClampRange(now, from, to time.Time) (time.Time, time.Time) {
+	if from.Before(now) {
+		from = now
+	}
+	if limit := from.AddDate(0, 0, MaxRangeDays); to.After(limit) {
+		to = limit
 	}
+	return from, to
+}
 
-	// Calculate the minimum start time (2 hours from reference time)
-	minStartTime := referenceTime.Add(2 * time.Hour)
+// generateSlots walks days calendar days starting at from's date. Slots
+// start at least 2 hours after now and no earlier than from, and end by
+// until unless it is zero. A positive limit stops after that many slots.
+func generateSlots(busySlots []models.TimeRange, now, from, until time.Time, days int, rules *models.ScheduleRules, slotDuration time.Duration, limit int) ([]models.TimeSlot, int, int) {
+	if slotDuration <= 0 {
+		slotDuration = SlotDuration
+	}
+	if rules == nil {
+		rules = defaultRules
+	}
+
+	loc := ScheduleLocation(rules)
+
+	// Calculate the minimum start time (2 hours from now, not before from),
+	// in loc: slots starting from it are labelled in its zone
+	minStartTime := now.Add(2 * time.Hour)
+	if from.After(minStartTime) {
+		minStartTime = from
+	}
+	minStartTime = minStartTime.In(loc)
 
 	// Normalize search start
-	startSearch := referenceTime.In(loc)
+	startSearch := from.In(loc)
 
-	var availableSlots []models.TimeSlot
+	// Upper bound: every open day fully free
+	capacity := days * int(longestDay(rules)/slotDuration)
+	if limit > 0 {
+		capacity = min(capacity, limit)
+	}
+	availableSlots := make([]models.TimeSlot, 0, capacity)
 	daysChecked := 0
 	totalSlots := 0
 
-	for d := 0; d < MaxDays; d++ {
+	for d := 0; d < days; d++ {
 		dayDate := startSearch.AddDate(0, 0, d)
 
-		// Skip weekends
-		if dayDate.Weekday() == time.Saturday || dayDate.Weekday() == time.Sunday {
+		// Business hours for this day (closed days are skipped)
+		workStart, workEnd, open := businessHours(rules, dayDate)
+		if !open {
 			continue
 		}
 		daysChecked++
-
-		// Set work hours for this day
-		workStart := time.Date(dayDate.Year(), dayDate.Month(), dayDate.Day(), WorkStartHour, 0, 0, 0, loc)
-		workEnd := time.Date(dayDate.Year(), dayDate.Month(), dayDate.Day(), WorkEndHour, 0, 0, 0, loc)
-
-		// Friday check: Ends at 3:30 PM (15:30)
-		if dayDate.Weekday() == time.Friday {
-			workEnd = time.Date(dayDate.Year(), dayDate.Month(), dayDate.Day(), 15, 30, 0, 0, loc)
-		}
+		excluded := exclusionRanges(rules, dayDate)
 
 		// Adjust workStart if it's before minStartTime (ensure 2h buffer)
 		if workStart.Before(minStartTime) {
@@ -70,11 +143,17 @@ func GenerateAvailableSlots(busySlots []models.TimeRange, referenceTime time.Tim
 
 		// Generate slots
 		curr := workStart
-		for curr.Add(SlotDuration).Before(workEnd) || curr.Add(SlotDuration).Equal(workEnd) {
-			slotEnd := curr.Add(SlotDuration)
+		for curr.Add(slotDuration).Before(workEnd) || curr.Add(slotDuration).Equal(workEnd) {
+			slotEnd := curr.Add(slotDuration)
+			if !until.IsZero() && slotEnd.After(until) {
+				break
+			}
 
-			if !isBusy(curr, slotEnd, busySlots) {
-				availableSlots = append(availableSlots, formatSlot(curr, slotEnd))
+			if !IsBusy(curr, slotEnd, busySlots) && !IsBusy(curr, slotEnd, excluded) {
+				availableSlots = append(availableSlots, NewTimeSlot(curr, slotEnd, loc))
+				if len(availableSlots) == limit {
+					return availableSlots, daysChecked, totalSlots + 1
+				}
 			}
 			totalSlots++
 
@@ -85,25 +164,54 @@ func GenerateAvailableSlots(busySlots []models.TimeRange, referenceTime time.Tim
 	return availableSlots, daysChecked, totalSlots
 }
 
-func isBusy(start, end time.Time, busy []models.TimeRange) bool {
-	loc := start.Location()
+// NewTimeSlot is the slot [start, end) at a property in loc. Its times and
+// labels are in loc whatever zone start and end come in.
+func NewTimeSlot(start, end time.Time, loc *time.Location) models.TimeSlot {
+	start, end = start.In(loc), end.In(loc)
+	return models.TimeSlot{
+		ID:       SlotID(start),
+		Date:     start.Format(slotDateLayout),
+		Time:     start.Format(slotTimeLayout),
+		Start:    start,
+		End:      end,
+		StartUTC: start.UTC(),
+		EndUTC:   end.UTC(),
+		TimeZone: loc.String(),
+	}
+}
 
-	for _, b := range busy {
-		busyStart := b.Start.In(loc)
-		busyEnd := b.End.In(loc)
+// SlotID names the slot starting at start (in the property's zone) by its
+// local start time, so the same slot keeps its ID across searches
+func SlotID(start time.Time) string {
+	return start.Format("20060102-1504")
+}
 
-		if start.Before(busyEnd) && end.After(busyStart) {
-			return true
+// FreeRange returns busy with [start, end) taken out, splitting the
+// periods that straddle it, e.g. to see a showing's own time as free when
+// moving it
+func FreeRange(busy []models.TimeRange, start, end time.Time) []models.TimeRange {
+	freed := make([]models.TimeRange, 0, len(busy)+1)
+	for _, b := range busy {
+		if !b.Start.Before(end) || !b.End.After(start) {
+			freed = append(freed, b)
+			continue
+		}
+		if b.Start.Before(start) {
+			freed = append(freed, models.TimeRange{Start: b.Start, End: start})
+		}
+		if b.End.After(end) {
+			freed = append(freed, models.TimeRange{Start: end, End: b.End})
 		}
 	}
-	return false
+	return freed
 }
 
-func formatSlot(start, end time.Time) models.TimeSlot {
-	return models.TimeSlot{
-		Date:  start.Format("Monday, January 2, 2006"),
-		Time:  start.Format("3:04 PM"),
-		Start: start,
-		End:   end,
+// IsBusy reports whether [start, end) overlaps any busy period
+func IsBusy(start, end time.Time, busy []models.TimeRange) bool {
+	for _, b := range busy {
+		if start.Before(b.End) && end.After(b.Start) {
+			return true
+		}
 	}
+	return false
 }
@@ -0,0 +1,138 @@
+package logic
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+// benchWeek returns a Monday-morning reference time and a typical week of
+// busy periods (three meetings a day)
+func benchWeek(b *testing.B) (time.Time, []models.TimeRange) {
+	b.Helper()
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		b.Fatal(err)
+	}
+	ref := time.Date(2026, 10, 19, 7, 0, 0, 0, loc)
+
+	var busy []models.TimeRange
+	for d := 0; d < MaxDays; d++ {
+		day := ref.AddDate(0, 0, d)
+		for _, h := range []int{10, 13, 15} {
+			start := time.Date(day.Year(), day.Month(), day.Day(), h, 0, 0, 0, loc)
+			busy = append(busy, models.TimeRange{Start: start, End: start.Add(45 * time.Minute)})
+		}
+	}
+	return ref, busy
+}
+
+func BenchmarkGenerateAvailableSlots(b *testing.B) {
+	ref, busy := benchWeek(b)
+	b.ReportAllocs()
+	for b.Loop() {
+		GenerateAvailableSlots(busy, ref, nil, SlotDuration)
+	}
+}
+
+func BenchmarkGenerateAvailableSlotsEmptyCalendar(b *testing.B) {
+	ref, _ := benchWeek(b)
+	b.ReportAllocs()
+	for b.Loop() {
+		GenerateAvailableSlots(nil, ref, nil, SlotDuration)
+	}
+}
+
+func BenchmarkGenerateAvailableSlotsWithExclusions(b *testing.B) {
+	ref, busy := benchWeek(b)
+	rules := DefaultScheduleRules()
+	rules.Exclusions = []models.ExclusionWindow{{Start: "12:00", End: "13:00"}, {Start: "16:30", End: "17:00"}}
+	b.ReportAllocs()
+	for b.Loop() {
+		GenerateAvailableSlots(busy, ref, rules, SlotDuration)
+	}
+}
+
+func BenchmarkGenerateEarliestSlots(b *testing.B) {
+	ref, busy := benchWeek(b)
+	to := ref.AddDate(0, 0, MaxDays)
+	b.ReportAllocs()
+	for b.Loop() {
+		GenerateEarliestSlots(busy, ref, ref, to, nil, SlotDuration, SuggestionCount)
+	}
+}
+
+func TestGeneratedSlotsAreInRulesZone(t *testing.T) {
+	rules := DefaultScheduleRules()
+	rules.TimeZone = "America/New_York"
+	ny, err := time.LoadLocation(rules.TimeZone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Monday 10:00 AM in New York, as seen by a server in another zone
+	now := time.Date(2026, 10, 19, 14, 0, 0, 0, time.UTC).In(tokyo)
+
+	slots, _, _ := GenerateAvailableSlots(nil, now, rules, SlotDuration)
+	if len(slots) == 0 {
+		t.Fatal("no slots generated")
+	}
+	if got := slots[0].Date + " " + slots[0].Time; got != "Monday, October 19, 2026 12:00 PM" {
+		t.Errorf("first slot = %q, want Monday, October 19, 2026 12:00 PM", got)
+	}
+	for _, slot := range slots {
+		if slot.TimeZone != "America/New_York" {
+			t.Fatalf("slot %s: TimeZone = %q", slot.ID, slot.TimeZone)
+		}
+		if slot.Start.Location().String() != ny.String() || slot.End.Location().String() != ny.String() {
+			t.Errorf("slot %s: start/end in %s/%s, want America/New_York", slot.ID, slot.Start.Location(), slot.End.Location())
+		}
+		if slot.Date != slot.Start.In(ny).Format(slotDateLayout) || slot.Time != slot.Start.In(ny).Format(slotTimeLayout) {
+			t.Errorf("slot %s: labels %q %q don't match start %s", slot.ID, slot.Date, slot.Time, slot.Start)
+		}
+		if slot.StartUTC.Location() != time.UTC || !slot.StartUTC.Equal(slot.Start) {
+			t.Errorf("slot %s: StartUTC = %s, want %s in UTC", slot.ID, slot.StartUTC, slot.Start)
+		}
+		if slot.EndUTC.Location() != time.UTC || !slot.EndUTC.Equal(slot.End) {
+			t.Errorf("slot %s: EndUTC = %s, want %s in UTC", slot.ID, slot.EndUTC, slot.End)
+		}
+	}
+}
+
+func TestNewTimeSlotJSON(t *testing.T) {
+	denver, err := time.LoadLocation("America/Denver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 12, 4, 16, 30, 0, 0, time.UTC)
+	slot := NewTimeSlot(start, start.Add(SlotDuration), denver)
+
+	body, err := json.Marshal(slot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"id":       "20261204-0930",
+		"date":     "Friday, December 4, 2026",
+		"time":     "9:30 AM",
+		"start":    "2026-12-04T09:30:00-07:00",
+		"end":      "2026-12-04T10:00:00-07:00",
+		"startUtc": "2026-12-04T16:30:00Z",
+		"endUtc":   "2026-12-04T17:00:00Z",
+		"timeZone": "America/Denver",
+	}
+	for field, value := range want {
+		if got[field] != value {
+			t.Errorf("%s = %v, want %s", field, got[field], value)
+		}
+	}
+}
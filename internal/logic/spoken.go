@@ -0,0 +1,192 @@
+package logic
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// fillerPhrases are conversational lead-ins and hesitations that carry no
+// address information
+var fillerPhrases = regexp.MustCompile(`\b(um+|uh+|er+|ah+|hmm+|you know|i mean|okay|ok|please|so|like|` +
+	`i'?m (looking at|looking for|calling about|interested in)|i'?d like to see|i want to see|` +
+	`the (one|place|house|home|apartment|unit|property) (on|at))\b`)
+
+// spokenTokens splits a normalized query into words, "#" and everything else
+var spokenTokens = regexp.MustCompile(`[a-z0-9']+|#`)
+
+// streetSuffixes maps spoken street types to their postal abbreviations
+var streetSuffixes = map[string]string{
+	"street": "st", "avenue": "ave", "av": "ave", "road": "rd", "drive": "dr",
+	"boulevard": "blvd", "lane": "ln", "court": "ct", "place": "pl",
+	"terrace": "ter", "circle": "cir", "parkway": "pkwy", "highway": "hwy",
+}
+
+// unitWords all mean the unit designator
+var unitWords = map[string]bool{
+	"apartment": true, "apt": true, "unit": true, "suite": true, "ste": true, "#": true,
+}
+
+var (
+	digitWords = map[string]int{
+		"zero": 0, "oh": 0, "one": 1, "two": 2, "three": 3, "four": 4,
+		"five": 5, "six": 6, "seven": 7, "eight": 8, "nine": 9,
+	}
+	teenWords = map[string]int{
+		"ten": 10, "eleven": 11, "twelve": 12, "thirteen": 13, "fourteen": 14,
+		"fifteen": 15, "sixteen": 16, "seventeen": 17, "eighteen": 18, "nineteen": 19,
+	}
+	tensWords = map[string]int{
+		"twenty": 20, "thirty": 30, "forty": 40, "fifty": 50,
+		"sixty": 60, "seventy": 70, "eighty": 80, "ninety": 90,
+	}
+	ordinalWords = map[string]int{
+		"first": 1, "second": 2, "third": 3, "fourth": 4, "fifth": 5,
+		"sixth": 6, "seventh": 7, "eighth": 8, "ninth": 9, "tenth": 10,
+		"eleventh": 11, "twelfth": 12, "thirteenth": 13, "fourteenth": 14,
+		"fifteenth": 15, "sixteenth": 16, "seventeenth": 17, "eighteenth": 18,
+		"nineteenth": 19, "twentieth": 20, "thirtieth": 30, "fortieth": 40,
+		"fiftieth": 50, "sixtieth": 60, "seventieth": 70, "eightieth": 80, "ninetieth": 90,
+	}
+)
+
+// NormalizeSpokenAddress rewrites a transcribed voice query into the form
+// addresses are written in, so search and address matching see "123 main st
+// unit 4" rather than "um the one on one two three main street apartment
+// four". Numbers are read digit by digit ("one two three"), in pairs
+// ("twelve thirty four") or as cardinals ("fifteen hundred"); ordinals
+// become "5th", "23rd". The result is lowercase. It is deterministic and
+// leaves queries without anything to normalize unchanged apart from case.
+func NormalizeSpokenAddress(query string) string {
+	s := strings.ToLower(query)
+	s = strings.NewReplacer("’", "'", ".", " ", ",", " ").Replace(s)
+	s = fillerPhrases.ReplaceAllString(s, " ")
+	words := spokenTokens.FindAllString(s, -1)
+
+	var out []string
+	for i := 0; i < len(words); {
+		if n, consumed := spokenNumber(words[i:]); consumed > 0 {
+			out = append(out, n)
+			i += consumed
+			continue
+		}
+		w := words[i]
+		i++
+		switch {
+		case unitWords[w]:
+			out = append(out, "unit")
+			// "apartment number four", "unit #4"
+			for i < len(words) && (words[i] == "number" || words[i] == "no" || words[i] == "#") {
+				i++
+			}
+		case streetSuffixes[w] != "":
+			out = append(out, streetSuffixes[w])
+		default:
+			out = append(out, w)
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+// spokenNumber reads the number spelled out at the start of words,
+// returning it in digits and the number of words consumed (0 if words
+// doesn't start with one)
+func spokenNumber(words []string) (string, int) {
+	var (
+		chunks     []string // values read digit by digit or in pairs
+		total, cur int      // cardinal reading ("one hundred twenty")
+		cardinal   bool
+		consumed   int
+	)
+	for consumed < len(words) {
+		w := words[consumed]
+		if d, ok := digitWords[w]; ok {
+			// "oh" is only a digit inside a number
+			if w == "oh" && consumed == 0 {
+				break
+			}
+			chunks = append(chunks, strconv.Itoa(d))
+			cur += d
+		} else if t, ok := teenWords[w]; ok {
+			chunks = append(chunks, strconv.Itoa(t))
+			cur += t
+		} else if t, ok := tensWords[w]; ok {
+			// "twenty one" is one chunk
+			if consumed+1 < len(words) {
+				if d, ok := digitWords[words[consumed+1]]; ok && d > 0 {
+					chunks = append(chunks, strconv.Itoa(t+d))
+					cur += t + d
+					consumed += 2
+					continue
+				}
+				if o, ok := ordinalWords[words[consumed+1]]; ok && o < 10 {
+					if consumed > 0 {
+						break
+					}
+					return ordinal(t + o), 2
+				}
+			}
+			chunks = append(chunks, strconv.Itoa(t))
+			cur += t
+		} else if o, ok := ordinalWords[w]; ok {
+			// An ordinal is a street name of its own: "fifteen hundred fifth
+			// avenue" is 1500 5th ave
+			if consumed > 0 {
+				break
+			}
+			return ordinal(o), 1
+		} else if (w == "hundred" || w == "thousand") && consumed > 0 {
+			cardinal = true
+			if w == "hundred" {
+				cur *= 100
+			} else {
+				total += cur * 1000
+				cur = 0
+			}
+		} else if w == "and" && cardinal && consumed+1 < len(words) && isNumberWord(words[consumed+1]) {
+			// "one hundred and five"
+		} else {
+			break
+		}
+		consumed++
+	}
+	if consumed == 0 {
+		return "", 0
+	}
+	return prefix(chunks, total+cur, cardinal), consumed
+}
+
+// prefix renders the number read so far, or "" if none
+func prefix(chunks []string, value int, cardinal bool) string {
+	switch {
+	case cardinal:
+		return strconv.Itoa(value)
+	case len(chunks) > 0:
+		return strings.Join(chunks, "")
+	}
+	return ""
+}
+
+// ordinal renders n with its suffix: "5th", "23rd"
+func ordinal(n int) string {
+	suffix := "th"
+	if n%100 < 11 || n%100 > 13 {
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+func isNumberWord(w string) bool {
+	_, digit := digitWords[w]
+	_, teen := teenWords[w]
+	_, tens := tensWords[w]
+	_, ord := ordinalWords[w]
+	return digit || teen || tens || ord
+}
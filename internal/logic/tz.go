@@ -0,0 +1,50 @@
+package logic
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+// DefaultTimeZone is the scheduling time zone when rules don't set one
+const DefaultTimeZone = "America/Los_Angeles"
+
+// locations caches resolved time zones by name; tzdata is read once per zone
+// per process instead of on every request.
+var locations sync.Map // string -> *time.Location
+
+var defaultLocation = loadLocation(DefaultTimeZone, time.UTC)
+
+// Location returns the named IANA time zone, resolved once and cached.
+// Empty or unknown names fall back to DefaultTimeZone.
+func Location(name string) *time.Location {
+	if name == "" {
+		return defaultLocation
+	}
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location)
+	}
+	loc := loadLocation(name, defaultLocation)
+	actual, _ := locations.LoadOrStore(name, loc)
+	return actual.(*time.Location)
+}
+
+// ScheduleLocation returns the time zone rules' hours are expressed in
+// (nil means DefaultScheduleRules).
+func ScheduleLocation(rules *models.ScheduleRules) *time.Location {
+	if rules == nil {
+		return defaultLocation
+	}
+	return Location(rules.TimeZone)
+}
+
+func loadLocation(name string, fallback *time.Location) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		slog.Warn("timezone_load_failed", "timezone", name, "error", err)
+		return fallback
+	}
+	return loc
+}
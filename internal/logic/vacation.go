@@ -0,0 +1,49 @@
+package logic
+
+import (
+	"strings"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+// OnVacation reports whether the agent is away at now
+func OnVacation(agent models.AgentInfo, now time.Time) bool {
+	return agent.VacationUntil != nil && now.Before(*agent.VacationUntil)
+}
+
+// CoverAgent returns the agent who takes assignment for agent at now: agent
+// itself, or the first available agent along its chain of backups (with
+// CoveringFor set). It returns nil when agent is away and no backup is
+// available.
+func CoverAgent(agent models.AgentInfo, agents []models.AgentInfo, now time.Time) *models.AgentInfo {
+	if !OnVacation(agent, now) {
+		return &agent
+	}
+
+	byEmail := make(map[string]models.AgentInfo, len(agents))
+	for _, a := range agents {
+		byEmail[strings.ToLower(a.Email)] = a
+	}
+
+	current := byEmail[strings.ToLower(agent.Email)]
+	if current.Email == "" {
+		current = agent
+	}
+	// Each hop visits a different agent, so a cycle of backups ends
+	for range agents {
+		backup, ok := byEmail[strings.ToLower(current.BackupEmail)]
+		if current.BackupEmail == "" || !ok {
+			return nil
+		}
+		if !OnVacation(backup, now) {
+			backup.Zone = agent.Zone
+			backup.ZoneGroup = agent.ZoneGroup
+			backup.AssignedBy = agent.AssignedBy
+			backup.CoveringFor = agent.Name
+			return &backup
+		}
+		current = backup
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Namespace is the CloudWatch namespace for all service metrics
+const Namespace = "SchedulingService"
+
+type Unit string
+
+const (
+	Count        Unit = "Count"
+	Milliseconds Unit = "Milliseconds"
+	Bytes        Unit = "Bytes"
+	None         Unit = "None"
+)
+
+// Record emits a single metric in CloudWatch Embedded Metric Format.
+// It is written through slog so it lands in the same JSON log stream;
+// CloudWatch extracts the metric from the "_aws" block and ignores the
+// extra log fields. dims are alternating dimension name/value pairs.
+func Record(ctx context.Context, name string, value float64, unit Unit, dims ...string) {
+	var dimNames []string
+	args := make([]any, 0, 4+len(dims))
+	for i := 0; i+1 < len(dims); i += 2 {
+		dimNames = append(dimNames, dims[i])
+		args = append(args, dims[i], dims[i+1])
+	}
+	if dimNames == nil {
+		dimNames = []string{}
+	}
+
+	emf := map[string]any{
+		"Timestamp": time.Now().UnixMilli(),
+		"CloudWatchMetrics": []map[string]any{{
+			"Namespace":  Namespace,
+			"Dimensions": [][]string{dimNames},
+			"Metrics":    []map[string]string{{"Name": name, "Unit": string(unit)}},
+		}},
+	}
+	args = append(args, "_aws", emf, name, value)
+
+	slog.InfoContext(ctx, "metric", args...)
+}
+
+// Incr emits a count of 1
+func Incr(ctx context.Context, name string, dims ...string) {
+	Record(ctx, name, 1, Count, dims...)
+}
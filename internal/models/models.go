@@ -2,31 +2,189 @@ package models
 
 import (
 	"encoding/json"
+	"strings"
 	"time"
 )
 
 // Request is the input event for the Lambda
 type Request struct {
-	Query string `json:"Query"`
-	Phone string `json:"Phone,omitempty"`
+	Query    string `json:"Query"`
+	Phone    string `json:"Phone,omitempty"`
+	TenantID string `json:"TenantId,omitempty"`
+	// From and To limit availability to a date range: dates ("2006-01-02",
+	// To inclusive) in the property's time zone, or RFC 3339 times
+	From string `json:"From,omitempty"`
+	To   string `json:"To,omitempty"`
+	// LookAheadDays extends an open-ended search past the default week
+	// (up to a month); the calendar is read a week at a time until enough
+	// slots are found.
+	LookAheadDays int `json:"LookAheadDays,omitempty"`
+	// Mode "earliest" returns only the first few open slots (PageSize, or
+	// three by default), skipping ranking and the full availability text
+	Mode string `json:"Mode,omitempty"`
+	// Page (1-based) and PageSize select a window of the available slots
+	Page     int `json:"Page,omitempty"`
+	PageSize int `json:"PageSize,omitempty"`
+	// PreferredDate ("2006-01-02") is the day the caller asked for. Only its
+	// slots are offered, or the nearest days' when it's full. Ignored with
+	// From/To and in earliest mode.
+	PreferredDate string `json:"PreferredDate,omitempty"`
+	// Action selects what to do instead of checking availability:
+	// "callback" schedules an outbound call to Phone at CallbackAt (RFC 3339,
+	// or "2006-01-02T15:04" in the tenant's time zone); "book" books the
+	// showing starting at SlotStart (same formats, in the property's zone)
+	// on the agent's calendar for Name at Phone; "confirm" books SlotID, a
+	// slot offered earlier in the same voice call; "cancel" cancels the
+	// booking ConfirmationID, or else Phone's upcoming showing; "reschedule"
+	// moves that booking to SlotStart
+	Action     string `json:"Action,omitempty"`
+	CallbackAt string `json:"CallbackAt,omitempty"`
+	SlotStart  string `json:"SlotStart,omitempty"`
+	SlotID     string `json:"SlotId,omitempty"`
+	// ConfirmationID is a booking's Response.Booking.ConfirmationID
+	ConfirmationID string `json:"ConfirmationId,omitempty"`
+	// Name is the prospect's name, written on the booked showing
+	Name string `json:"Name,omitempty"`
+	// Reason is passed to the callback assistant, e.g. "confirm showing"
+	Reason string `json:"Reason,omitempty"`
+	// SMSConsent records that the caller agreed to receive texts at Phone
+	SMSConsent bool `json:"SmsConsent,omitempty"`
+	// UnitID picks one of the units a NextChooseUnit response listed
+	UnitID string `json:"UnitId,omitempty"`
+	// ConfirmedPropertyID is the property of a NeedsConfirmation response
+	// the caller confirmed; matching is skipped
+	ConfirmedPropertyID string `json:"ConfirmedPropertyId,omitempty"`
+	// Source attributes the inquiry to a marketing channel, e.g. a Zillow
+	// call tracking number, "widget", or "vapi:<assistant ID>" (the
+	// default for VAPI calls). It is kept on the lead and its bookings.
+	Source string `json:"Source,omitempty"`
+	// Metadata is the tenant's own data (campaign IDs, CRM IDs, ...). It is
+	// stored on the lead and echoed back on the response untouched.
+	Metadata map[string]any `json:"Metadata,omitempty"`
+	// Batch checks availability for several properties in one call. Items
+	// inherit TenantId, Phone and Source; each is answered on its own in a
+	// BatchResponse, so one bad property doesn't fail the rest.
+	Batch []Request `json:"Batch,omitempty"`
+}
+
+// BatchResponse answers a Request.Batch, item by item in request order
+type BatchResponse struct {
+	Items     []BatchItem `json:"items"`
+	Succeeded int         `json:"succeeded"`
+	Failed    int         `json:"failed"`
+	// Metadata echoes the batch request's Metadata
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// BatchItem is one item's outcome; Error is set when it failed
+type BatchItem struct {
+	Query    string    `json:"query"`
+	Success  bool      `json:"success"`
+	Error    string    `json:"error,omitempty"`
+	Response *Response `json:"response,omitempty"`
 }
 
 // Response is the output of the Lambda
 type Response struct {
 	Success      bool         `json:"success"`
+	ErrorCode    string       `json:"errorCode,omitempty"`
 	Property     PropertyInfo `json:"property"`
 	Agent        AgentInfo    `json:"agent"`
 	Availability Availability `json:"availability"`
+	Provenance   string       `json:"provenance,omitempty"` // where property details came from: appfolio, buildium, yardi, feed
+	SelfGuided   bool         `json:"selfGuided,omitempty"`
 	Message      string       `json:"message"`
 	FormattedMsg string       `json:"formattedMessage"`
+	// NextActions tells the voice agent what to do next (see Next*), so its
+	// prompt can branch without interpreting FormattedMsg
+	NextActions []string `json:"nextActions,omitempty"`
+	// Caller is set when the caller's phone matched a property-system record
+	Caller *Caller `json:"caller,omitempty"`
+	// Units lists a multi-unit property's vacant units when the request
+	// didn't say which one (NextChooseUnit)
+	Units []UnitInfo `json:"units,omitempty"`
+	// MatchSource says how the query was matched to Property (Match*), and
+	// MatchConfidence (0-1) how sure that match is, so the voice agent can
+	// confirm the address before reading out times
+	MatchSource     string  `json:"matchSource,omitempty"`
+	MatchConfidence float64 `json:"matchConfidence,omitempty"`
+	// NeedsConfirmation is set when the match was too uncertain to offer
+	// times for: confirm Property's address with the caller, then call
+	// again with ConfirmedPropertyId
+	NeedsConfirmation bool `json:"needsConfirmation,omitempty"`
+	// Metadata echoes Request.Metadata
+	Metadata map[string]any `json:"metadata,omitempty"`
+	// Booking confirms the showing an Action "book" request booked, the one
+	// a "cancel" request cancelled, or where a "reschedule" moved it
+	Booking *BookingConfirmation `json:"booking,omitempty"`
+	// Bookings lists the caller's upcoming showings when a "cancel" or
+	// "reschedule" request by phone matched more than one; call again with
+	// its ConfirmationId
+	Bookings []BookingConfirmation `json:"bookings,omitempty"`
+}
+
+// BookingConfirmation is what the caller is told about a booked showing
+type BookingConfirmation struct {
+	// ConfirmationID is the confirmation number (Booking.Reference) that
+	// identifies the booking for cancelling or rescheduling. It is empty
+	// when the showing was booked but its record couldn't be saved.
+	ConfirmationID  string    `json:"confirmationId"`
+	Date            string    `json:"date"` // "Friday, December 6, 2025"
+	Time            string    `json:"time"` // "9:00 AM"
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	TimeZone        string    `json:"timeZone"`
+	PropertyAddress string    `json:"propertyAddress"`
+	AgentName       string    `json:"agentName"`
+	AgentEmail      string    `json:"agentEmail"`
+	CalendarEventID string    `json:"calendarEventId"`
 }
 
+// Response.MatchSource values
+const (
+	// MatchOpenAI: the LLM picked one of the caller's address candidates
+	// (whichever provider ran it)
+	MatchOpenAI = "openai"
+	// MatchSearch: the search service
+	MatchSearch = "search"
+	// MatchFuzzy: the property system's address query (street number and
+	// name)
+	MatchFuzzy = "fuzzy"
+	// MatchOverride: the caller or flow named the property itself
+	MatchOverride = "override"
+)
+
+// Response.NextActions values
+const (
+	NextOfferSlots        = "offer_slots"
+	NextAskDatePreference = "ask_for_date_preference"
+	NextConfirmAddress    = "confirm_address"
+	NextCollectPhone      = "collect_phone"
+	NextTransferToHuman   = "transfer_to_human"
+	// NextChooseUnit: ask which of Response.Units and call again with its
+	// UnitId
+	NextChooseUnit = "choose_unit"
+)
+
 type PropertyInfo struct {
-	ID      string `json:"id"`
-	Name    string `json:"name"`
-	Address string `json:"address,omitempty"`
-	City    string `json:"city,omitempty"`
-	State   string `json:"state,omitempty"`
+	ID      string  `json:"id"`
+	Name    string  `json:"name"`
+	Address string  `json:"address,omitempty"`
+	City    string  `json:"city,omitempty"`
+	State   string  `json:"state,omitempty"`
+	Rent    float64 `json:"rent,omitempty"`
+	// UnitID is set when the showing is for one unit of a multi-unit
+	// property; Address then includes the unit
+	UnitID string `json:"unitId,omitempty"`
+}
+
+// UnitInfo is a vacant unit offered for a showing
+type UnitInfo struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	Bedrooms  float64 `json:"bedrooms,omitempty"`
+	Bathrooms float64 `json:"bathrooms,omitempty"`
+	Rent      float64 `json:"rent,omitempty"`
 }
 
 type AgentInfo struct {
@@ -35,21 +193,131 @@ type AgentInfo struct {
 	Email     string `json:"email"`
 	Zone      string `json:"zone,omitempty"`
 	ZoneGroup string `json:"zoneGroup,omitempty"`
+	// AssignedBy records how the agent was resolved: override, group,
+	// directory, feed, geo or escalation
+	AssignedBy string `json:"assignedBy,omitempty"`
+	// VacationUntil and BackupEmail come from the agents table; while an
+	// agent is away their properties go to the backup agent
+	VacationUntil *time.Time `json:"vacationUntil,omitempty"`
+	BackupEmail   string     `json:"backupEmail,omitempty"`
+	// CoveringFor names the away agent this one is standing in for
+	CoveringFor string `json:"coveringFor,omitempty"`
+	// EscalationLevel is "region" or "company" when no zone agent was
+	// available and the property went to a ZoneHierarchy contact
+	EscalationLevel string `json:"escalationLevel,omitempty"`
+}
+
+// ZoneHierarchy groups agent zones into regions under the company, so a
+// property whose zone has no available agent escalates to its regional
+// contact, and a property with no known zone to the company contact.
+type ZoneHierarchy struct {
+	Regions map[string]ZoneRegion `json:"regions"`
+	Company *AgentInfo            `json:"company,omitempty"`
+}
+
+// ZoneRegion is a set of zones with a regional default contact
+type ZoneRegion struct {
+	Zones   []string  `json:"zones"`
+	Contact AgentInfo `json:"contact"`
 }
 
 type Availability struct {
 	TotalSlotsAvailable int        `json:"totalSlotsAvailable"`
 	DaysChecked         int        `json:"daysChecked"`
 	Slots               []TimeSlot `json:"slots"`
+	// Slots holds one page of the available slots; HasMore reports
+	// whether page+1 has any
+	Page     int  `json:"page"`
+	PageSize int  `json:"pageSize"`
+	HasMore  bool `json:"hasMore"`
+	// SearchedThrough is the last date (YYYY-MM-DD) whose calendar was checked
+	SearchedThrough string `json:"searchedThrough,omitempty"`
+	// Suggestions are the best few slots to offer first, ranked
+	Suggestions []TimeSlot `json:"suggestions,omitempty"`
+	// PreferredDate echoes the request's; AlternateDates (YYYY-MM-DD) are
+	// the days offered instead when it had no open slots
+	PreferredDate  string   `json:"preferredDate,omitempty"`
+	AlternateDates []string `json:"alternateDates,omitempty"`
 }
 
 type TimeSlot struct {
-	Date  string    `json:"date"`  // "Friday, December 6, 2025"
-	Time  string    `json:"time"`  // "9:00 AM"
-	Start time.Time `json:"start"` // ISO string
-	End   time.Time `json:"end"`   // ISO string
+	// ID names the slot for a later confirm call: its local start,
+	// "20251206-0900"
+	ID string `json:"id,omitempty"`
+	// Date, Time, Start and End are in the property's zone, TimeZone;
+	// StartUTC and EndUTC are the same instants in UTC
+	Date     string    `json:"date"` // "Friday, December 6, 2025"
+	Time     string    `json:"time"` // "9:00 AM"
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	StartUTC time.Time `json:"startUtc"`
+	EndUTC   time.Time `json:"endUtc"`
+	TimeZone string    `json:"timeZone"`
+
+	// TravelRisk flags a slot right after an appointment elsewhere:
+	// "tight" (less than the buffer to spare) or "infeasible"
+	TravelRisk    string `json:"travelRisk,omitempty"`
+	TravelMinutes int    `json:"travelMinutes,omitempty"`
+	// Clustered marks a slot adjacent to another showing at or near the property
+	Clustered bool `json:"clustered,omitempty"`
+}
+
+// Branding is the company identity a tenant's messages carry
+type Branding struct {
+	CompanyName string `json:"companyName"`
+	Phone       string `json:"phone,omitempty"`
+	Website     string `json:"website,omitempty"`
+	// SignOff ends outbound texts, e.g. "- Acme Rentals. Reply STOP to opt out."
+	SignOff string `json:"signOff,omitempty"`
 }
 
+// DataResidency pins a tenant's data to its own Supabase project and
+// event-log bucket. Empty fields use the deployment's defaults, except that
+// a tenant with its own SupabaseProjectID never fails over to the default
+// project's replica.
+type DataResidency struct {
+	// Region is the AWS region of EventLogBucket
+	Region                    string `json:"region,omitempty"`
+	SupabaseProjectID         string `json:"supabaseProjectId,omitempty"`
+	SupabaseKey               string `json:"supabaseKey,omitempty"`
+	SupabaseFallbackProjectID string `json:"supabaseFallbackProjectId,omitempty"`
+	SupabaseFallbackKey       string `json:"supabaseFallbackKey,omitempty"`
+	EventLogBucket            string `json:"eventLogBucket,omitempty"`
+}
+
+// NotifySettings routes a tenant's notifications. Channels maps a purpose
+// (e.g. "booking_reminder", "team"; "*" for any other) to the channels it
+// goes out on: "sms", "email", "slack", "webhook". The other fields are
+// where team-facing channels deliver when a message names no recipient.
+type NotifySettings struct {
+	Channels     map[string][]string `json:"channels,omitempty"`
+	SlackChannel string              `json:"slackChannel,omitempty"`
+	Email        string              `json:"email,omitempty"`
+	WebhookURL   string              `json:"webhookUrl,omitempty"`
+}
+
+// UsageQuota limits a tenant's monthly usage of one metric. Past Soft the
+// tenant is warned; past Hard requests are refused. Zero means no limit.
+type UsageQuota struct {
+	Soft int64 `json:"soft,omitempty"`
+	Hard int64 `json:"hard,omitempty"`
+}
+
+// Caller is a person in the property system matched by phone number
+type Caller struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Type       string `json:"type"` // CallerTenant or CallerProspect
+	PropertyID string `json:"propertyId,omitempty"`
+	UnitID     string `json:"unitId,omitempty"`
+}
+
+// Caller.Type values
+const (
+	CallerTenant   = "tenant"
+	CallerProspect = "prospect"
+)
+
 // --- AppFolio Models ---
 
 type AppFolioPropertyResponse struct {
@@ -65,6 +333,37 @@ type AppFolioProperty struct {
 	PropertyGroupIds []string `json:"PropertyGroupIds"`
 }
 
+// AppFolioPerson is a tenant or guest card (prospect) record
+type AppFolioPerson struct {
+	ID         string `json:"Id"`
+	FirstName  string `json:"FirstName"`
+	LastName   string `json:"LastName"`
+	PropertyID string `json:"PropertyId"`
+	UnitID     string `json:"UnitId"`
+}
+
+type AppFolioPersonResponse struct {
+	Data []AppFolioPerson `json:"data"`
+}
+
+// AppFolioUnit is a rentable unit of a property
+type AppFolioUnit struct {
+	ID         string  `json:"Id"`
+	PropertyID string  `json:"PropertyId"`
+	Name       string  `json:"Name"`
+	Bedrooms   float64 `json:"Bedrooms"`
+	Bathrooms  float64 `json:"Bathrooms"`
+	MarketRent float64 `json:"MarketRent"`
+	Vacant     bool    `json:"Vacant"`
+	// AvailableOn is when the unit can be moved into; zero when AppFolio
+	// has no date for it
+	AvailableOn time.Time `json:"AvailableOn"`
+}
+
+type AppFolioUnitResponse struct {
+	Data []AppFolioUnit `json:"data"`
+}
+
 type AppFolioGroupResponse struct {
 	Data []AppFolioGroup `json:"data"`
 }
@@ -74,6 +373,121 @@ type AppFolioGroup struct {
 	Name string `json:"Name"`
 }
 
+// --- Buildium Models ---
+
+type BuildiumRental struct {
+	ID      int             `json:"Id"`
+	Name    string          `json:"Name"`
+	Address BuildiumAddress `json:"Address"`
+}
+
+type BuildiumAddress struct {
+	AddressLine1 string `json:"AddressLine1"`
+	City         string `json:"City"`
+	State        string `json:"State"`
+	PostalCode   string `json:"PostalCode"`
+}
+
+type BuildiumPropertyGroup struct {
+	ID   int    `json:"Id"`
+	Name string `json:"Name"`
+}
+
+// --- Yardi Voyager Models ---
+
+type YardiProperty struct {
+	Code          string `xml:"Code"`
+	MarketingName string `xml:"MarketingName"`
+	AddressLine1  string `xml:"AddressLine1"`
+	City          string `xml:"City"`
+	State         string `xml:"State"`
+	PostalCode    string `xml:"PostalCode"`
+}
+
+type YardiAgent struct {
+	FirstName string `xml:"AgentName>FirstName"`
+	LastName  string `xml:"AgentName>LastName"`
+	Email     string `xml:"Email"`
+}
+
+// --- Listings Feed Models ---
+
+// PropertySnapshot is a property as the property system last reported it to
+// the property sync job: address, zones (its group names) and whether any
+// unit is vacant
+type PropertySnapshot struct {
+	PropertyID string    `json:"property_id"`
+	Address1   string    `json:"address1"`
+	City       string    `json:"city"`
+	State      string    `json:"state"`
+	Zones      []string  `json:"zones"`
+	Vacant     bool      `json:"vacant"`
+	SyncedAt   time.Time `json:"synced_at"`
+}
+
+// Kinds of PropertyDrift
+const (
+	DriftAdded   = "added"
+	DriftRemoved = "removed"
+	DriftAddress = "address"
+	DriftZones   = "zones"
+	DriftVacancy = "vacancy"
+)
+
+// PropertyDrift is one difference between the property system and the
+// stored snapshot of a property
+type PropertyDrift struct {
+	PropertyID string `json:"property_id"`
+	Kind       string `json:"kind"`
+	Was        string `json:"was,omitempty"`
+	Now        string `json:"now,omitempty"`
+}
+
+// DriftReport is a property sync's reconciliation report
+type DriftReport struct {
+	RunAt      time.Time       `json:"run_at"`
+	Properties int             `json:"properties"`
+	Drift      []PropertyDrift `json:"drift"`
+}
+
+// FeedListing is a property row ingested nightly from a syndication feed
+// (Zillow/Zumper). It backs property lookups when the property system is down.
+type FeedListing struct {
+	PropertyID string    `json:"property_id"`
+	Source     string    `json:"source"` // zillow, zumper
+	Name       string    `json:"name"`
+	Address1   string    `json:"address1"`
+	City       string    `json:"city"`
+	State      string    `json:"state"`
+	Zip        string    `json:"zip,omitempty"`
+	Rent       float64   `json:"rent,omitempty"`
+	AgentName  string    `json:"agent_name,omitempty"`
+	AgentEmail string    `json:"agent_email,omitempty"`
+	IngestedAt time.Time `json:"ingested_at"`
+}
+
+// Property converts the listing into the canonical property shape
+func (l *FeedListing) Property() *AppFolioProperty {
+	name := l.Name
+	if name == "" {
+		name = l.Address1
+	}
+	return &AppFolioProperty{ID: l.PropertyID, Name: name, Address1: l.Address1, City: l.City, State: l.State}
+}
+
+// Agent finds the roster agent matching the listing's contact email
+func (l *FeedListing) Agent(roster map[string]AgentInfo) *AgentInfo {
+	if l.AgentEmail == "" {
+		return nil
+	}
+	for _, agent := range roster {
+		if strings.EqualFold(agent.Email, l.AgentEmail) {
+			return &agent
+		}
+	}
+	return nil
+}
+
 // --- Google Calendar Models ---
 
 type FreeBusyRequest struct {
@@ -101,11 +515,389 @@ type TimeRange struct {
 	End   time.Time `json:"end"`
 }
 
+// --- Scheduling Rules ---
+
+// ScheduleRules is a tenant's showing-hours document. Times are "HH:MM" in
+// TimeZone; weekdays are lower-case English names.
+type ScheduleRules struct {
+	// TimeZone is the IANA zone of the tenant's properties, e.g.
+	// "America/Denver". Empty means America/Los_Angeles.
+	TimeZone string `json:"timeZone,omitempty"`
+	// BusinessHours maps a weekday to its showing hours. Days not listed are
+	// closed; a nil map uses the default hours.
+	BusinessHours map[string]BusinessHours `json:"businessHours,omitempty"`
+	// Exclusions are daily windows with no showings (e.g. lunch, rush hour)
+	Exclusions []ExclusionWindow `json:"exclusions,omitempty"`
+	// DateHours overrides BusinessHours on specific dates ("2006-01-02"),
+	// e.g. with an agent's working hours for that day
+	DateHours map[string]BusinessHours `json:"dateHours,omitempty"`
+	// EveningExtensions move the end of the weekday hours later for part of
+	// the year, e.g. 19:00 from June through August while it's light out
+	EveningExtensions []EveningExtension `json:"eveningExtensions,omitempty"`
+}
+
+// EveningExtension ends showings at End instead of the weekday hours' end
+// on dates between From and To ("MM-DD", inclusive; may wrap past New
+// Year), on the listed days or every open day if none are listed. It never
+// shortens a day or opens a closed one.
+type EveningExtension struct {
+	From  string   `json:"from"`
+	To    string   `json:"to"`
+	End   string   `json:"end"`
+	Days  []string `json:"days,omitempty"`
+	Label string   `json:"label,omitempty"`
+}
+
+type BusinessHours struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// ExclusionWindow blocks [Start, End) on the listed days, or every day if none are listed
+type ExclusionWindow struct {
+	Start string   `json:"start"`
+	End   string   `json:"end"`
+	Days  []string `json:"days,omitempty"`
+	Label string   `json:"label,omitempty"`
+}
+
 type Error struct {
 	Domain string `json:"domain"`
 	Reason string `json:"reason"`
 }
 
+// CalendarEvent is the subset of a Google Calendar event resource we write
+type CalendarEvent struct {
+	ID          string             `json:"id,omitempty"`
+	Summary     string             `json:"summary,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Location    string             `json:"location,omitempty"`
+	Start       *CalendarEventTime `json:"start,omitempty"`
+	End         *CalendarEventTime `json:"end,omitempty"`
+	HTMLLink    string             `json:"htmlLink,omitempty"`
+	// Status is "confirmed" (Google's default) or "tentative"
+	Status string `json:"status,omitempty"`
+}
+
+// Calendar event statuses
+const (
+	EventConfirmed = "confirmed"
+	EventTentative = "tentative"
+)
+
+type CalendarEventTime struct {
+	DateTime string `json:"dateTime,omitempty"`
+	Date     string `json:"date,omitempty"` // all-day events only
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// Time parses DateTime; all-day events (and a nil time) report ok=false
+func (t *CalendarEventTime) Time() (time.Time, bool) {
+	if t == nil || t.DateTime == "" {
+		return time.Time{}, false
+	}
+	return ParseTime(t.DateTime)
+}
+
+// --- Property Settings ---
+
+// PropertySettings are per-property scheduling options stored in Supabase
+type PropertySettings struct {
+	PropertyID string `json:"property_id"`
+	// SelfGuided properties are toured without an agent using a smart-lock code
+	SelfGuided bool   `json:"self_guided"`
+	LockID     string `json:"lock_id,omitempty"`
+	// TourMinutes overrides the default showing length (e.g. 45-60 for large homes)
+	TourMinutes int `json:"tour_minutes,omitempty"`
+	// MaxShowingsPerDay caps the showings booked per day (per unit at a
+	// multi-unit property), e.g. 3 for a tenant-occupied home; 0 is no cap
+	MaxShowingsPerDay int `json:"max_showings_per_day,omitempty"`
+}
+
+// PropertyAgentOverride assigns a property to a specific agent regardless
+// of its zone
+type PropertyAgentOverride struct {
+	PropertyID string `json:"property_id"`
+	AgentEmail string `json:"agent_email"`
+	// AgentName is used when the agent isn't on the roster
+	AgentName string    `json:"agent_name,omitempty"`
+	Note      string    `json:"note,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DoNotContact is a phone number that must not be texted, emailed or
+// stored as a marketing lead. An empty TenantID applies to every tenant.
+type DoNotContact struct {
+	TenantID  string    `json:"tenant_id"`
+	Phone     string    `json:"phone"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// --- Leads ---
+
+// Identity verification statuses, as reported by Stripe Identity
+const (
+	IDVerificationRequiresInput = "requires_input"
+	IDVerificationProcessing    = "processing"
+	IDVerificationVerified      = "verified"
+	IDVerificationCanceled      = "canceled"
+)
+
+// Lead is a prospective renter, keyed by phone number
+type Lead struct {
+	Phone string `json:"phone"`
+
+	// Identity verification, required before self-guided access is issued
+	IDVerificationStatus    string     `json:"id_verification_status,omitempty"`
+	IDVerificationSessionID string     `json:"id_verification_session_id,omitempty"`
+	IDVerificationURL       string     `json:"id_verification_url,omitempty"`
+	IDVerifiedAt            *time.Time `json:"id_verified_at,omitempty"`
+
+	// Post-showing application link delivery and click tracking
+	ApplicationPropertyID string     `json:"application_property_id,omitempty"`
+	ApplicationSentAt     *time.Time `json:"application_sent_at,omitempty"`
+	ApplicationClickedAt  *time.Time `json:"application_clicked_at,omitempty"`
+	ApplicationClicks     int        `json:"application_clicks,omitempty"`
+
+	// Outbound call-back scheduled through VAPI
+	CallbackCallID string     `json:"callback_call_id,omitempty"`
+	CallbackAt     *time.Time `json:"callback_at,omitempty"`
+
+	// Summary of the prospect's latest VAPI call, for agent context
+	CallSummary      *CallSummary `json:"call_summary,omitempty"`
+	CallSummarizedAt *time.Time   `json:"call_summarized_at,omitempty"`
+	// Tags prioritize the lead for agents, e.g. ["hot", "asap-mover"]
+	Tags     []string   `json:"tags,omitempty"`
+	ScoredAt *time.Time `json:"scored_at,omitempty"`
+
+	// SMS consent (TCPA): business-initiated texts need consent that
+	// hasn't been withdrawn by a later STOP
+	SMSConsentAt     *time.Time `json:"sms_consent_at,omitempty"`
+	SMSConsentSource string     `json:"sms_consent_source,omitempty"`
+	SMSOptedOutAt    *time.Time `json:"sms_opted_out_at,omitempty"`
+
+	// Source is the channel of the lead's latest inquiry (see Request.Source)
+	Source string `json:"source,omitempty"`
+	// Metadata is the tenant's data from the lead's latest inquiry that
+	// carried any (see Request.Metadata)
+	Metadata map[string]any `json:"metadata,omitempty"`
+
+	// Waitlist: the lead asked about a property with no vacant unit; the
+	// property sync texts fresh times once one opens up
+	WaitlistPropertyID string     `json:"waitlist_property_id,omitempty"`
+	WaitlistedAt       *time.Time `json:"waitlisted_at,omitempty"`
+	VacancyNotifiedAt  *time.Time `json:"vacancy_notified_at,omitempty"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CallSummary is the structured summary of a call transcript
+type CallSummary struct {
+	// InterestLevel is "high", "medium" or "low"
+	InterestLevel   string   `json:"interest_level"`
+	Objections      []string `json:"objections"`
+	PreferredMoveIn string   `json:"preferred_move_in,omitempty"`
+	Summary         string   `json:"summary"`
+}
+
+// Lead tags. Every scored lead gets exactly one of hot, warm or cold.
+const (
+	LeadHot            = "hot"
+	LeadWarm           = "warm"
+	LeadCold           = "cold"
+	LeadPriceSensitive = "price-sensitive"
+	LeadASAPMover      = "asap-mover"
+)
+
+// LeadTags is every tag the scorer may assign
+var LeadTags = []string{LeadHot, LeadWarm, LeadCold, LeadPriceSensitive, LeadASAPMover}
+
+// SMS consent sources
+const (
+	ConsentVoice    = "voice"      // agreed on a VAPI call
+	ConsentSMSOptIn = "sms_opt_in" // texted us first, or texted START
+)
+
+// SMSConsented reports whether the lead has consented to texts and not
+// opted out since
+func (l *Lead) SMSConsented() bool {
+	return l != nil && l.SMSConsentAt != nil && (l.SMSOptedOutAt == nil || l.SMSOptedOutAt.Before(*l.SMSConsentAt))
+}
+
+// AwaitingVacancy reports whether the lead is waitlisted and hasn't been
+// told about a vacancy since
+func (l *Lead) AwaitingVacancy() bool {
+	return l != nil && l.WaitlistPropertyID != "" && l.WaitlistedAt != nil &&
+		(l.VacancyNotifiedAt == nil || l.VacancyNotifiedAt.Before(*l.WaitlistedAt))
+}
+
+// IDVerified reports whether the lead has completed identity verification
+func (l *Lead) IDVerified() bool {
+	return l != nil && l.IDVerificationStatus == IDVerificationVerified
+}
+
+// --- SMS Conversation Models ---
+
+// SMSSession tracks the slots offered to a prospect over SMS so a reply
+// of "1", "2", ... can be resolved back to a concrete time.
+type SMSSession struct {
+	Phone           string     `json:"phone"`
+	TenantID        string     `json:"tenant_id,omitempty"` // tenant of the offered property
+	PropertyID      string     `json:"property_id"`
+	UnitID          string     `json:"unit_id,omitempty"`
+	PropertyAddress string     `json:"property_address"`
+	AgentName       string     `json:"agent_name"`
+	AgentEmail      string     `json:"agent_email"`
+	AgentZone       string     `json:"agent_zone,omitempty"`
+	OfferedSlots    []TimeSlot `json:"offered_slots"`
+	// TimeZone is the IANA zone the offered slots were generated in
+	TimeZone string `json:"time_zone,omitempty"`
+	// Source is the marketing channel of the inquiry (Request.Source)
+	Source      string     `json:"source,omitempty"`
+	EventID     string     `json:"event_id,omitempty"`
+	BookedStart *time.Time `json:"booked_start,omitempty"`
+	BookedEnd   *time.Time `json:"booked_end,omitempty"`
+	// ConfirmBy is set while a booking is tentative: the prospect must reply
+	// YES before then or the slot is released
+	ConfirmBy *time.Time `json:"confirm_by,omitempty"`
+	// AgentResponse is "accepted" once the agent accepts the booking (when
+	// agent approval is on); a declined booking is released and re-offered
+	AgentResponse string `json:"agent_response,omitempty"`
+	// BookingID links the session's showing to its booking record
+	BookingID string `json:"booking_id,omitempty"`
+	// ApplicationSentAt is set once the post-showing application link is texted
+	ApplicationSentAt *time.Time `json:"application_sent_at,omitempty"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+
+	// Self-guided tours book a lock code instead of a calendar event
+	SelfGuided   bool   `json:"self_guided,omitempty"`
+	LockID       string `json:"lock_id,omitempty"`
+	AccessCodeID string `json:"access_code_id,omitempty"`
+}
+
+// CallState is the conversation state of a voice call, kept between its
+// tool calls
+type CallState struct {
+	CallID   string `json:"call_id"`
+	TenantID string `json:"tenant_id,omitempty"`
+	// PendingPropertyID is the low-confidence match awaiting the caller's
+	// confirmation, found for Query
+	PendingPropertyID string `json:"pending_property_id,omitempty"`
+	Query             string `json:"query,omitempty"`
+	// ConfirmedPropertyID is the property the caller confirmed
+	ConfirmedPropertyID string `json:"confirmed_property_id,omitempty"`
+	// Offer is the last set of times read to the caller
+	Offer     *ShowingOffer `json:"offer,omitempty"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+// ShowingOffer is the slots offered for a property, with what's needed to
+// book one of them without searching again
+type ShowingOffer struct {
+	PropertyID      string     `json:"property_id"`
+	UnitID          string     `json:"unit_id,omitempty"`
+	PropertyAddress string     `json:"property_address"`
+	Agent           AgentInfo  `json:"agent"`
+	TimeZone        string     `json:"time_zone"`
+	Source          string     `json:"source,omitempty"`
+	Slots           []TimeSlot `json:"slots"`
+}
+
+// Slot returns the offered slot with the given ID
+func (o *ShowingOffer) Slot(id string) (TimeSlot, bool) {
+	for _, slot := range o.Slots {
+		if slot.ID == id {
+			return slot, true
+		}
+	}
+	return TimeSlot{}, false
+}
+
+// Booking is a showing through its whole lifecycle, whichever channel made
+// it. It is the record cancellations, reminders and reporting work from;
+// see internal/store.
+type Booking struct {
+	ID string `json:"id"`
+	// Reference is the short confirmation number the prospect is given
+	// (ConfirmationNumber); ID stays internal
+	Reference string `json:"reference,omitempty"`
+	TenantID  string `json:"tenant_id,omitempty"`
+	Status    string `json:"status"`
+	Channel   string `json:"channel"` // "sms", "voice" or "web"
+	// Source is the marketing channel the lead came from (Request.Source)
+	Source          string `json:"source,omitempty"`
+	Phone           string `json:"phone"`
+	PropertyID      string `json:"property_id"`
+	UnitID          string `json:"unit_id,omitempty"`
+	PropertyAddress string `json:"property_address,omitempty"`
+	AgentEmail      string `json:"agent_email,omitempty"`
+	AgentName       string `json:"agent_name,omitempty"`
+	// Start and End are UTC; TimeZone is the zone the slot was offered in
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	TimeZone string    `json:"time_zone,omitempty"`
+
+	// EventID is the agent's calendar event; self-guided showings have an
+	// AccessCodeID on LockID instead
+	EventID      string `json:"event_id,omitempty"`
+	SelfGuided   bool   `json:"self_guided,omitempty"`
+	LockID       string `json:"lock_id,omitempty"`
+	AccessCodeID string `json:"access_code_id,omitempty"`
+
+	// ConfirmBy is set while the booking is held awaiting the prospect's YES
+	ConfirmBy    *time.Time `json:"confirm_by,omitempty"`
+	ConfirmedAt  *time.Time `json:"confirmed_at,omitempty"`
+	CancelledAt  *time.Time `json:"cancelled_at,omitempty"`
+	CancelReason string     `json:"cancel_reason,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	// Version increases with every write; an update only succeeds against
+	// the version it read (see store.ErrConflict)
+	Version int `json:"version"`
+}
+
+// Booking.Status values, in lifecycle order. A slot is offered, may be held
+// while the prospect confirms, is booked, and is confirmed; it ends
+// completed, cancelled or as a no-show.
+const (
+	BookingOffered   = "offered"
+	BookingHeld      = "held"
+	BookingBooked    = "booked"
+	BookingConfirmed = "confirmed"
+	BookingCompleted = "completed"
+	BookingCancelled = "cancelled"
+	BookingNoShow    = "no_show"
+)
+
+// ConfirmationNumber is what the prospect quotes to find the booking again:
+// its Reference, or the ID of a record saved before references existed
+func (b *Booking) ConfirmationNumber() string {
+	if b.Reference != "" {
+		return b.Reference
+	}
+	return b.ID
+}
+
+// Active reports whether the booking still occupies its slot
+func (b *Booking) Active() bool {
+	switch b.Status {
+	case BookingHeld, BookingBooked, BookingConfirmed:
+		return true
+	}
+	return false
+}
+
+// Booked reports whether the session holds a showing, confirmed or tentative
+func (s *SMSSession) Booked() bool {
+	return s.BookedStart != nil
+}
+
+// Tentative reports whether the session's showing awaits the prospect's YES
+func (s *SMSSession) Tentative() bool {
+	return s.Booked() && s.ConfirmBy != nil
+}
+
 // --- VAPI Webhook Models ---
 
 // VAPIWebhookPayload represents the incoming VAPI webhook request
@@ -134,6 +926,24 @@ type VAPIFunctionArgs struct {
 	Query             string `json:"Query"`
 	Phone             string `json:"Phone"`
 	ExtractedProperty string `json:"ExtractedProperty,omitempty"`
+	TenantID          string `json:"TenantId,omitempty"`
+	// schedule_callback tool arguments
+	CallbackAt string `json:"CallbackAt,omitempty"`
+	Reason     string `json:"Reason,omitempty"`
+	// book_showing, confirm_slot and reschedule_showing tool arguments
+	SlotStart string `json:"SlotStart,omitempty"`
+	SlotID    string `json:"SlotId,omitempty"`
+	Name      string `json:"Name,omitempty"`
+	// cancel_showing and reschedule_showing tool argument
+	ConfirmationID string `json:"ConfirmationId,omitempty"`
+	// SmsConsent is true once the caller agreed to receive texts
+	SMSConsent bool `json:"SmsConsent,omitempty"`
+	// UnitId answers a choose_unit follow-up
+	UnitID string `json:"UnitId,omitempty"`
+	// ConfirmedPropertyId answers a needsConfirmation follow-up
+	ConfirmedPropertyID string         `json:"ConfirmedPropertyId,omitempty"`
+	Source              string         `json:"Source,omitempty"`
+	Metadata            map[string]any `json:"Metadata,omitempty"`
 }
 
 type VAPIArtifact struct {
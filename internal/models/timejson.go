@@ -0,0 +1,94 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// timeLayouts are the time formats accepted from upstream APIs, most
+// common first. Google's free/busy usually sends RFC 3339 but has been seen
+// with other precisions and colon-less offsets; AppFolio dates come both
+// ISO and US style. Layouts without a zone are UTC.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z0700",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+	"1/2/2006",
+}
+
+// TimeFieldError reports a time field whose value matched none of the
+// accepted layouts, e.g. `TimeRange.start: unrecognized time "soon"`
+type TimeFieldError struct {
+	Field string
+	Value string
+}
+
+func (e *TimeFieldError) Error() string {
+	return fmt.Sprintf("%s: unrecognized time %s", e.Field, e.Value)
+}
+
+// ParseTime parses s in any of the accepted upstream layouts
+func ParseTime(s string) (time.Time, bool) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// UnmarshalTimeField decodes raw, a JSON string in any accepted layout,
+// into t for a type's UnmarshalJSON. Missing, null and empty values leave t
+// zero; anything else unparseable is a *TimeFieldError naming field.
+func UnmarshalTimeField(field string, raw json.RawMessage, t *time.Time) error {
+	*t = time.Time{}
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return &TimeFieldError{Field: field, Value: string(raw)}
+	}
+	if s == "" {
+		return nil
+	}
+	parsed, ok := ParseTime(s)
+	if !ok {
+		return &TimeFieldError{Field: field, Value: string(raw)}
+	}
+	*t = parsed
+	return nil
+}
+
+func (r *TimeRange) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Start json.RawMessage `json:"start"`
+		End   json.RawMessage `json:"end"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("TimeRange: %w", err)
+	}
+	if err := UnmarshalTimeField("TimeRange.start", raw.Start, &r.Start); err != nil {
+		return err
+	}
+	return UnmarshalTimeField("TimeRange.end", raw.End, &r.End)
+}
+
+func (u *AppFolioUnit) UnmarshalJSON(data []byte) error {
+	type plain AppFolioUnit
+	var raw struct {
+		*plain
+		AvailableOn json.RawMessage `json:"AvailableOn"`
+	}
+	raw.plain = (*plain)(u)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("AppFolioUnit: %w", err)
+	}
+	return UnmarshalTimeField("AppFolioUnit.AvailableOn", raw.AvailableOn, &u.AvailableOn)
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseTimeLayouts(t *testing.T) {
+	utc := func(h, m, s, ns int) time.Time { return time.Date(2026, 10, 19, h, m, s, ns, time.UTC) }
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2026-10-19T14:30:00Z", utc(14, 30, 0, 0)},
+		{"2026-10-19T07:30:00-07:00", utc(14, 30, 0, 0)},
+		{"2026-10-19T14:30:00.123456789Z", utc(14, 30, 0, 123456789)},
+		{"2026-10-19T07:30:00.5-0700", utc(14, 30, 0, 500000000)},
+		{"2026-10-19T07:30:00-0700", utc(14, 30, 0, 0)},
+		{"2026-10-19T07:30-07:00", utc(14, 30, 0, 0)},
+		{"2026-10-19T14:30:00", utc(14, 30, 0, 0)},
+		{"2026-10-19T14:30:00.25", utc(14, 30, 0, 250000000)},
+		{"2026-10-19 07:30:00-07:00", utc(14, 30, 0, 0)},
+		{"2026-10-19 07:30:00-0700", utc(14, 30, 0, 0)},
+		{"2026-10-19 14:30:00", utc(14, 30, 0, 0)},
+		{"2026-10-19", utc(0, 0, 0, 0)},
+		{"10/19/2026", utc(0, 0, 0, 0)},
+		{"1/5/2026", time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{"01/05/2026", time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, ok := ParseTime(tt.in)
+		if !ok {
+			t.Errorf("ParseTime(%q) failed", tt.in)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseTime(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseTimeRejectsBadInput(t *testing.T) {
+	for _, in := range []string{"", "soon", "2026-13-01", "2026-10-19T25:00:00Z", "19/10/2026", "10-19-2026", "2026-10-19T14:30:00 PST"} {
+		if got, ok := ParseTime(in); ok {
+			t.Errorf("ParseTime(%q) = %s, want failure", in, got)
+		}
+	}
+}
+
+func TestTimeFieldErrorNamesField(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		into  any
+		field string
+	}{
+		{"range start", `{"start":"soon","end":"2026-10-19T15:00:00Z"}`, &TimeRange{}, "TimeRange.start"},
+		{"range end", `{"start":"2026-10-19T14:00:00Z","end":42}`, &TimeRange{}, "TimeRange.end"},
+		{"unit available", `{"Id":"u1","AvailableOn":"next month"}`, &AppFolioUnit{}, "AppFolioUnit.AvailableOn"},
+	}
+	for _, tt := range tests {
+		err := json.Unmarshal([]byte(tt.body), tt.into)
+		var fieldErr *TimeFieldError
+		if !errors.As(err, &fieldErr) {
+			t.Errorf("%s: err = %v, want *TimeFieldError", tt.name, err)
+			continue
+		}
+		if fieldErr.Field != tt.field {
+			t.Errorf("%s: Field = %q, want %q", tt.name, fieldErr.Field, tt.field)
+		}
+	}
+}
+
+func TestAppFolioUnitAvailableOn(t *testing.T) {
+	tests := []struct {
+		body string
+		want time.Time
+	}{
+		{`{"Id":"u1","Vacant":true,"AvailableOn":"11/01/2026"}`, time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{`{"Id":"u1","Vacant":true,"AvailableOn":"2026-11-01"}`, time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{`{"Id":"u1","Vacant":true,"AvailableOn":null}`, time.Time{}},
+		{`{"Id":"u1","Vacant":true}`, time.Time{}},
+	}
+	for _, tt := range tests {
+		var u AppFolioUnit
+		if err := json.Unmarshal([]byte(tt.body), &u); err != nil {
+			t.Errorf("%s: %v", tt.body, err)
+			continue
+		}
+		if u.ID != "u1" || !u.Vacant {
+			t.Errorf("%s: other fields lost: %+v", tt.body, u)
+		}
+		if !u.AvailableOn.Equal(tt.want) {
+			t.Errorf("%s: AvailableOn = %s, want %s", tt.body, u.AvailableOn, tt.want)
+		}
+	}
+}
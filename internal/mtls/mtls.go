@@ -0,0 +1,133 @@
+// Package mtls holds client certificates for dependencies that require
+// mutual TLS. A loader is registered per host at startup; the certificate
+// is fetched on the first request to that host, re-fetched periodically so
+// rotations are picked up, and applied by the shared dependency transport
+// (breaker.Transport).
+package mtls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// LoadTimeout bounds fetching a certificate on a request's path
+	LoadTimeout = 5 * time.Second
+	// RefreshInterval is how long a loaded certificate is used before it is
+	// fetched again, so a rotated secret reaches long-lived processes
+	RefreshInterval = 6 * time.Hour
+	// RetryInterval is how long a failed refresh keeps the previous
+	// certificate before trying again
+	RetryInterval = time.Minute
+)
+
+// Loader fetches a host's client certificate and, for private CAs, the pool
+// that replaces the system CAs for verifying it (nil for the system CAs)
+type Loader func(ctx context.Context) (tls.Certificate, *x509.CertPool, error)
+
+// Transport routes requests to hosts with a registered client certificate
+// through a TLS-configured transport, and everything else through
+// http.DefaultTransport.
+var Transport http.RoundTripper = roundTripper{}
+
+var (
+	mu    sync.RWMutex
+	hosts = make(map[string]*host)
+)
+
+type host struct {
+	name string
+	load Loader
+
+	mu        sync.Mutex
+	transport *http.Transport
+	nextLoad  time.Time
+}
+
+// Register presents the certificate load returns on TLS connections to
+// name. Nothing is fetched until the first request to the host.
+func Register(name string, load Loader) {
+	mu.Lock()
+	hosts[strings.ToLower(name)] = &host{name: name, load: load}
+	mu.Unlock()
+}
+
+// ParsePEM builds a client certificate from PEM-encoded cert and key, and a
+// CA pool from caPEM (nil pool if caPEM is empty).
+func ParsePEM(certPEM, keyPEM, caPEM []byte) (tls.Certificate, *x509.CertPool, error) {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	if len(caPEM) == 0 {
+		return cert, nil, nil
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caPEM) {
+		return tls.Certificate{}, nil, errors.New("no CA certificates found in PEM")
+	}
+	return cert, roots, nil
+}
+
+// transportFor returns h's transport, loading the certificate when it
+// hasn't been or is due for a refresh. A failed refresh keeps the previous
+// certificate; with none, the request fails rather than going out without
+// one.
+func (h *host) transportFor(ctx context.Context) (*http.Transport, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.transport != nil && time.Now().Before(h.nextLoad) {
+		return h.transport, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, LoadTimeout)
+	defer cancel()
+	cert, roots, err := h.load(ctx)
+	if err != nil {
+		if h.transport == nil {
+			return nil, fmt.Errorf("mtls: client certificate for %s: %w", h.name, err)
+		}
+		slog.ErrorContext(ctx, "mtls_certificate_refresh_failed", "host", h.name, "error", err)
+		h.nextLoad = time.Now().Add(RetryInterval)
+		return h.transport, nil
+	}
+
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      roots,
+		MinVersion:   tls.VersionTLS12,
+	}
+	if h.transport != nil {
+		h.transport.CloseIdleConnections()
+	}
+	h.transport = t
+	h.nextLoad = time.Now().Add(RefreshInterval)
+	slog.InfoContext(ctx, "mtls_certificate_loaded", "host", h.name)
+	return t, nil
+}
+
+type roundTripper struct{}
+
+func (roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	mu.RLock()
+	h, ok := hosts[strings.ToLower(req.URL.Hostname())]
+	mu.RUnlock()
+
+	if !ok {
+		return http.DefaultTransport.RoundTrip(req)
+	}
+	t, err := h.transportFor(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	return t.RoundTrip(req)
+}
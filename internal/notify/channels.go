@@ -0,0 +1,116 @@
+package notify
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ses"
+	"github.com/aws/aws-sdk-go/service/ses/sesiface"
+)
+
+// SMSSender sends a text message (clients.TwilioClient)
+type SMSSender interface {
+	SendSMS(ctx context.Context, to, body string) error
+}
+
+// SlackPoster posts to a Slack channel (clients.SlackClient)
+type SlackPoster interface {
+	PostMessage(ctx context.Context, channel, text string) error
+}
+
+// WebhookPoster posts a JSON payload to a URL (clients.WebhookClient)
+type WebhookPoster interface {
+	Post(ctx context.Context, url string, payload interface{}) error
+}
+
+// SMS texts msg.Text to msg.To.Phone
+type SMS struct {
+	Sender SMSSender
+}
+
+func (SMS) Channel() string { return ChannelSMS }
+
+func (n SMS) Send(ctx context.Context, msg Message) error {
+	if msg.To.Phone == "" {
+		return ErrNoRecipient
+	}
+	return n.Sender.SendSMS(ctx, msg.To.Phone, msg.Text)
+}
+
+// Slack posts msg.Text to msg.To.SlackChannel
+type Slack struct {
+	Poster SlackPoster
+}
+
+func (Slack) Channel() string { return ChannelSlack }
+
+func (n Slack) Send(ctx context.Context, msg Message) error {
+	if msg.To.SlackChannel == "" {
+		return ErrNoRecipient
+	}
+	return n.Poster.PostMessage(ctx, msg.To.SlackChannel, msg.Text)
+}
+
+// Email sends msg as a plain-text email through SES
+type Email struct {
+	From string
+	SES  sesiface.SESAPI
+}
+
+func NewEmail(sess *session.Session, from string) *Email {
+	return &Email{From: from, SES: ses.New(sess)}
+}
+
+func (*Email) Channel() string { return ChannelEmail }
+
+func (n *Email) Send(ctx context.Context, msg Message) error {
+	if msg.To.Email == "" {
+		return ErrNoRecipient
+	}
+	subject := msg.Subject
+	if subject == "" {
+		subject = msg.Purpose
+	}
+	_, err := n.SES.SendEmailWithContext(ctx, &ses.SendEmailInput{
+		Source:      aws.String(n.From),
+		Destination: &ses.Destination{ToAddresses: []*string{aws.String(msg.To.Email)}},
+		Message: &ses.Message{
+			Subject: &ses.Content{Data: aws.String(subject), Charset: aws.String("UTF-8")},
+			Body:    &ses.Body{Text: &ses.Content{Data: aws.String(msg.Text), Charset: aws.String("UTF-8")}},
+		},
+	})
+	return err
+}
+
+// Webhook posts msg as JSON to msg.To.WebhookURL
+type Webhook struct {
+	Poster WebhookPoster
+}
+
+// WebhookPayload is the body of a webhook notification
+type WebhookPayload struct {
+	TenantID string                 `json:"tenantId,omitempty"`
+	Purpose  string                 `json:"purpose"`
+	Subject  string                 `json:"subject,omitempty"`
+	Text     string                 `json:"text"`
+	Data     map[string]interface{} `json:"data,omitempty"`
+	SentAt   time.Time              `json:"sentAt"`
+}
+
+func (Webhook) Channel() string { return ChannelWebhook }
+
+func (n Webhook) Send(ctx context.Context, msg Message) error {
+	if msg.To.WebhookURL == "" {
+		return ErrNoRecipient
+	}
+	return n.Poster.Post(ctx, msg.To.WebhookURL, WebhookPayload{
+		TenantID: msg.TenantID,
+		Purpose:  msg.Purpose,
+		Subject:  msg.Subject,
+		Text:     msg.Text,
+		Data:     msg.Data,
+		SentAt:   time.Now().UTC(),
+	})
+}
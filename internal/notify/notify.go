@@ -0,0 +1,192 @@
+// Package notify delivers notifications (booking confirmations, reminders,
+// team alerts) over pluggable channels. Each channel is a Notifier
+// registered on a Dispatcher; the Dispatcher picks the channels for a
+// message from the tenant's routing (models.NotifySettings), fills in
+// default recipients, and retries transient failures.
+//
+// Compliance checks (do-not-contact, consent, quiet hours) stay with the
+// caller: the Dispatcher delivers whatever it is given.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/breaker"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/clients"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+// Channels
+const (
+	ChannelSMS     = "sms"
+	ChannelEmail   = "email"
+	ChannelSlack   = "slack"
+	ChannelWebhook = "webhook"
+)
+
+// PurposeAny is the routing key for purposes without their own entry
+const PurposeAny = "*"
+
+// ErrNoRecipient is returned by a Notifier when the message has no
+// recipient for its channel; the Dispatcher skips that channel quietly.
+var ErrNoRecipient = errors.New("no recipient for channel")
+
+// Recipient holds the per-channel destinations of a message. A Notifier
+// uses only its own field.
+type Recipient struct {
+	Phone        string
+	Email        string
+	SlackChannel string
+	WebhookURL   string
+}
+
+// Message is one notification
+type Message struct {
+	TenantID string
+	// Purpose names the kind of notification, e.g. "booking_reminder"; it
+	// selects the tenant's channels and is reported to webhooks
+	Purpose string
+	To      Recipient
+	Subject string
+	Text    string
+	// Data is structured detail for webhook receivers
+	Data map[string]interface{}
+}
+
+// Notifier delivers messages over one channel
+type Notifier interface {
+	Channel() string
+	Send(ctx context.Context, msg Message) error
+}
+
+// RetryPolicy bounds redelivery of transient failures. Attempts counts the
+// first try; the wait doubles after each failure.
+type RetryPolicy struct {
+	Attempts int
+	Backoff  time.Duration
+}
+
+// DefaultRetry suits calls made while a caller or webhook is waiting
+var DefaultRetry = RetryPolicy{Attempts: 3, Backoff: 200 * time.Millisecond}
+
+// Dispatcher routes messages to registered notifiers
+type Dispatcher struct {
+	// Settings returns a tenant's routing; nil routes everything to the
+	// channels passed to Dispatch
+	Settings func(tenantID string) models.NotifySettings
+	Retry    RetryPolicy
+
+	mu        sync.RWMutex
+	notifiers map[string]Notifier
+}
+
+func NewDispatcher(settings func(tenantID string) models.NotifySettings, retry RetryPolicy) *Dispatcher {
+	return &Dispatcher{Settings: settings, Retry: retry, notifiers: make(map[string]Notifier)}
+}
+
+// Register adds n, replacing any notifier for the same channel
+func (d *Dispatcher) Register(n Notifier) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.notifiers[n.Channel()] = n
+}
+
+// Has reports whether a notifier is registered for channel
+func (d *Dispatcher) Has(channel string) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	_, ok := d.notifiers[channel]
+	return ok
+}
+
+// Dispatch delivers msg on the channels the tenant routes its purpose to,
+// or on defaults when the tenant has no route for it. Channels without a
+// registered notifier or a recipient are skipped. It returns the joined
+// errors of the channels that failed.
+func (d *Dispatcher) Dispatch(ctx context.Context, msg Message, defaults ...string) error {
+	var settings models.NotifySettings
+	if d.Settings != nil {
+		settings = d.Settings(msg.TenantID)
+	}
+	channels, ok := settings.Channels[msg.Purpose]
+	if !ok {
+		channels, ok = settings.Channels[PurposeAny]
+	}
+	if !ok {
+		channels = defaults
+	}
+	if msg.To.SlackChannel == "" {
+		msg.To.SlackChannel = settings.SlackChannel
+	}
+	if msg.To.Email == "" {
+		msg.To.Email = settings.Email
+	}
+	if msg.To.WebhookURL == "" {
+		msg.To.WebhookURL = settings.WebhookURL
+	}
+
+	var errs []error
+	for _, channel := range channels {
+		d.mu.RLock()
+		n, ok := d.notifiers[channel]
+		d.mu.RUnlock()
+		if !ok {
+			slog.WarnContext(ctx, "notify_channel_unavailable", "channel", channel, "purpose", msg.Purpose)
+			continue
+		}
+
+		err := d.send(ctx, n, msg)
+		if errors.Is(err, ErrNoRecipient) {
+			continue
+		}
+		if err != nil {
+			slog.WarnContext(ctx, "notification_failed", "channel", channel, "purpose", msg.Purpose, "error", err)
+			metrics.Incr(ctx, "NotificationFailed", "Channel", channel, "Purpose", msg.Purpose)
+			errs = append(errs, fmt.Errorf("%s: %w", channel, err))
+			continue
+		}
+		slog.InfoContext(ctx, "notification_sent", "channel", channel, "purpose", msg.Purpose)
+		metrics.Incr(ctx, "NotificationSent", "Channel", channel, "Purpose", msg.Purpose)
+	}
+	return errors.Join(errs...)
+}
+
+// send delivers msg through n, retrying transient failures
+func (d *Dispatcher) send(ctx context.Context, n Notifier, msg Message) error {
+	attempts := max(d.Retry.Attempts, 1)
+	wait := d.Retry.Backoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = n.Send(ctx, msg); err == nil || attempt >= attempts || !retryable(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// retryable reports whether a delivery failure may succeed if repeated.
+// Timeouts aren't retried: the first attempt may have been delivered, and
+// a duplicate text is worse than a missing one.
+func retryable(err error) bool {
+	var apiErr *clients.APIError
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr.Code >= 500 || apiErr.Code == http.StatusTooManyRequests
+	case errors.Is(err, ErrNoRecipient), errors.Is(err, breaker.ErrOpen), errors.Is(err, clients.ErrTimeout),
+		errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	}
+	return true
+}
@@ -2,30 +2,53 @@ package ratelimit
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"sync"
 	"time"
 
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/metrics"
 	"golang.org/x/time/rate"
 )
 
+// ErrSaturated means a limiter couldn't allow a request within the wait
+// the caller could afford
+var ErrSaturated = errors.New("rate limiter saturated")
+
+// OpenAI names the OpenAI API's limiter
+const OpenAI = "openai"
+
 var (
-	openaiLimiter *rate.Limiter
-	once          sync.Once
+	mu       sync.Mutex
+	limiters = map[string]*rate.Limiter{}
 )
 
-const (
-	OpenAIRequestsPerMinute = 10
-	OpenAIBurstSize         = 3
-)
+// Limiter returns the named dependency's limiter. Until Configure sets its
+// rate it allows every request.
+func Limiter(name string) *rate.Limiter {
+	mu.Lock()
+	defer mu.Unlock()
+	l, ok := limiters[name]
+	if !ok {
+		l = rate.NewLimiter(rate.Inf, 0)
+		limiters[name] = l
+	}
+	return l
+}
+
+// Configure sets the named dependency's request rate; callers already
+// holding its limiter see the change
+func Configure(name string, perMinute float64, burst int) {
+	l := Limiter(name)
+	l.SetLimit(rate.Limit(perMinute / 60))
+	l.SetBurst(burst)
+}
 
-// GetOpenAILimiter returns the singleton rate limiter
+// GetOpenAILimiter returns the OpenAI API's limiter
 func GetOpenAILimiter() *rate.Limiter {
-	once.Do(func() {
-		openaiLimiter = rate.NewLimiter(rate.Every(time.Minute/OpenAIRequestsPerMinute), OpenAIBurstSize)
-	})
-	return openaiLimiter
+	return Limiter(OpenAI)
 }
 
 // WaitForOpenAI blocks until the rate limiter allows a request
@@ -37,3 +60,80 @@ func WaitForOpenAI(ctx context.Context) error {
 	}
 	return nil
 }
+
+// TryAcquire waits for limiter to allow a request, unless that would take
+// longer than maxWait (or than ctx has left): then it returns ErrSaturated
+// at once, without using up the limiter's allowance
+func TryAcquire(ctx context.Context, limiter *rate.Limiter, maxWait time.Duration) error {
+	r := limiter.Reserve()
+	if !r.OK() {
+		return ErrSaturated
+	}
+	delay := r.Delay()
+	if deadline, ok := ctx.Deadline(); ok {
+		maxWait = min(maxWait, time.Until(deadline))
+	}
+	if delay > maxWait {
+		r.Cancel()
+		return ErrSaturated
+	}
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
+
+// TryOpenAI is WaitForOpenAI for callers that can't wait longer than
+// maxWait, e.g. a voice caller on the line
+func TryOpenAI(ctx context.Context, maxWait time.Duration) error {
+	err := TryAcquire(ctx, GetOpenAILimiter(), maxWait)
+	if errors.Is(err, ErrSaturated) {
+		slog.WarnContext(ctx, "openai_rate_limit_saturated", "max_wait", maxWait)
+		metrics.Incr(ctx, "RateLimiterSaturated", "Limiter", "openai")
+	}
+	if err != nil {
+		return fmt.Errorf("rate limited: %w", err)
+	}
+	return nil
+}
+
+type pacingKey struct{}
+
+// WithPacing marks ctx as bulk work (e.g. a nightly job): its requests
+// through a pacing Transport wait their turn on the dependency's limiter
+func WithPacing(ctx context.Context) context.Context {
+	return context.WithValue(ctx, pacingKey{}, true)
+}
+
+// Transport paces bulk-work requests (see WithPacing) to the named
+// dependency to its configured rate, so a job drips requests out rather
+// than bursting them. Other requests pass straight through.
+func Transport(name string, base http.RoundTripper) http.RoundTripper {
+	return &pacedTransport{name: name, limiter: Limiter(name), base: base}
+}
+
+type pacedTransport struct {
+	name    string
+	limiter *rate.Limiter
+	base    http.RoundTripper
+}
+
+func (t *pacedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if paced, _ := req.Context().Value(pacingKey{}).(bool); paced {
+		start := time.Now()
+		if err := t.limiter.Wait(req.Context()); err != nil {
+			return nil, fmt.Errorf("paced: %w", err)
+		}
+		metrics.Record(req.Context(), "PacedWait", float64(time.Since(start).Milliseconds()), metrics.Milliseconds, "Dependency", t.name)
+	}
+	return t.base.RoundTrip(req)
+}
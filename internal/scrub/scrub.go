@@ -0,0 +1,103 @@
+// Package scrub removes incidental personal data and profanity from text
+// before it leaves our boundary (LLM prompts). It is deliberately
+// conservative: it masks what it recognizes and leaves everything else, so
+// it limits exposure rather than guaranteeing anonymity.
+package scrub
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Categories
+const (
+	SSN          = "ssn"
+	Card         = "card"
+	Email        = "email"
+	Phone        = "phone"
+	SpokenNumber = "spoken_number" // long digit strings read out word by word
+	Name         = "name"          // full names after "my name is" etc.
+	Profanity    = "profanity"
+)
+
+// AllCategories is the default set
+var AllCategories = []string{SSN, Card, Email, Phone, SpokenNumber, Name, Profanity}
+
+// defaultProfanity is extended by the words passed to New
+var defaultProfanity = []string{"fuck", "fucking", "shit", "bullshit", "asshole", "bitch", "bastard", "damn", "dick", "crap"}
+
+var spokenDigit = `(?:zero|oh|one|two|three|four|five|six|seven|eight|nine)`
+
+type rule struct {
+	category    string
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// patterns are applied in order: longer digit runs first, so a card number
+// isn't half-masked as a phone number
+var patterns = []rule{
+	{Card, regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`), "[card]"},
+	{SSN, regexp.MustCompile(`\b\d{3}[- ]?\d{2}[- ]?\d{4}\b`), "[ssn]"},
+	{Phone, regexp.MustCompile(`(?:\+?1[ .-]?)?\(?\b\d{3}\)?[ .-]?\d{3}[ .-]?\d{4}\b`), "[phone]"},
+	{Email, regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`), "[email]"},
+	// Seven or more digits read out: phone, SSN or account numbers. House
+	// numbers are shorter.
+	{SpokenNumber, regexp.MustCompile(`(?i)\b` + spokenDigit + `(?:[\s,-]+` + spokenDigit + `){6,}\b`), "[number]"},
+	{Name, regexp.MustCompile(`\b((?i:my name is|my name's|name is|i am|i'm))\s+[A-Z][a-z'-]+(?:\s+[A-Z][a-z'-]+){0,2}`), "$1 [name]"},
+}
+
+// Scrubber masks the configured categories. A nil Scrubber masks nothing.
+type Scrubber struct {
+	rules []rule
+}
+
+// New returns a Scrubber for categories (AllCategories if empty); words
+// are added to the profanity list
+func New(categories, words []string) *Scrubber {
+	if len(categories) == 0 {
+		categories = AllCategories
+	}
+	enabled := make(map[string]bool, len(categories))
+	for _, c := range categories {
+		enabled[strings.ToLower(strings.TrimSpace(c))] = true
+	}
+
+	s := &Scrubber{}
+	for _, p := range patterns {
+		if enabled[p.category] {
+			s.rules = append(s.rules, p)
+		}
+	}
+	if enabled[Profanity] {
+		quoted := make([]string, 0, len(defaultProfanity)+len(words))
+		for _, w := range append(append([]string{}, defaultProfanity...), words...) {
+			if w = strings.TrimSpace(w); w != "" {
+				quoted = append(quoted, regexp.QuoteMeta(w))
+			}
+		}
+		s.rules = append(s.rules, rule{Profanity, regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`), "[redacted]"})
+	}
+	return s
+}
+
+// Scrub returns text with the configured categories masked, and how many
+// matches of each category it masked (nil if none)
+func (s *Scrubber) Scrub(text string) (string, map[string]int) {
+	if s == nil {
+		return text, nil
+	}
+	var found map[string]int
+	for _, r := range s.rules {
+		n := len(r.pattern.FindAllStringIndex(text, -1))
+		if n == 0 {
+			continue
+		}
+		if found == nil {
+			found = make(map[string]int)
+		}
+		found[r.category] += n
+		text = r.pattern.ReplaceAllString(text, r.replacement)
+	}
+	return text, found
+}
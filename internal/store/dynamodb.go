@@ -0,0 +1,179 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbattribute"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+// DynamoDB secondary indexes on the bookings table. The list indexes have
+// sort key "start"; ReferenceIndex has none.
+const (
+	PhoneIndex     = "phone-start-index"    // partition key "phone"
+	AgentIndex     = "agent-start-index"    // partition key "agent_email"
+	PropertyIndex  = "property-start-index" // partition key "property_id"
+	ReferenceIndex = "reference-index"      // partition key "reference"
+)
+
+// DynamoBookings keeps bookings in a DynamoDB table with partition key "id"
+// and the PhoneIndex, AgentIndex, PropertyIndex and ReferenceIndex global
+// secondary indexes. Attributes use the models.Booking JSON names; times are
+// RFC 3339 strings in UTC.
+type DynamoBookings struct {
+	Table  string
+	client dynamodbiface.DynamoDBAPI
+}
+
+func NewDynamoBookings(sess *session.Session, table string) *DynamoBookings {
+	client := dynamodb.New(sess)
+	xray.AWS(client.Client)
+	return &DynamoBookings{Table: table, client: client}
+}
+
+func (s *DynamoBookings) Create(ctx context.Context, booking models.Booking) (models.Booking, error) {
+	booking = prepareNew(booking)
+	if err := s.put(ctx, booking, "attribute_not_exists(id)", nil); err != nil {
+		return models.Booking{}, fmt.Errorf("booking create: %w", err)
+	}
+	return booking, nil
+}
+
+func (s *DynamoBookings) Get(ctx context.Context, id string) (*models.Booking, error) {
+	out, err := s.client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.Table),
+		Key:            map[string]*dynamodb.AttributeValue{"id": {S: aws.String(id)}},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("booking get: %w", err)
+	}
+	if out.Item == nil {
+		return nil, nil
+	}
+	var booking models.Booking
+	if err := dynamodbattribute.UnmarshalMap(out.Item, &booking); err != nil {
+		return nil, fmt.Errorf("booking get: %w", err)
+	}
+	return &booking, nil
+}
+
+func (s *DynamoBookings) Update(ctx context.Context, booking models.Booking) error {
+	booking = normalize(booking)
+	booking.UpdatedAt = time.Now().UTC()
+	read := booking.Version
+	booking.Version++
+	condition := "version = :read"
+	if read == 0 {
+		// Rows written before versioning have no version attribute and
+		// read as 0
+		condition = "attribute_exists(id) AND (attribute_not_exists(version) OR version = :read)"
+	}
+	err := s.put(ctx, booking, condition, map[string]*dynamodb.AttributeValue{":read": {N: aws.String(strconv.Itoa(read))}})
+	var aerr awserr.Error
+	if errors.As(err, &aerr) && aerr.Code() == dynamodb.ErrCodeConditionalCheckFailedException {
+		current, getErr := s.Get(ctx, booking.ID)
+		if getErr != nil {
+			return getErr
+		}
+		if current == nil {
+			return ErrNotFound
+		}
+		return fmt.Errorf("booking %s at version %d, not %d: %w", booking.ID, current.Version, read, ErrConflict)
+	}
+	if err != nil {
+		return fmt.Errorf("booking update: %w", err)
+	}
+	return nil
+}
+
+func (s *DynamoBookings) GetByReference(ctx context.Context, reference string) (*models.Booking, error) {
+	bookings, err := s.query(ctx, ReferenceIndex, "#r = :k", map[string]*dynamodb.AttributeValue{":k": {S: aws.String(reference)}})
+	if err != nil || len(bookings) == 0 {
+		return nil, err
+	}
+	return &bookings[0], nil
+}
+
+func (s *DynamoBookings) ListByPhone(ctx context.Context, phone string) ([]models.Booking, error) {
+	return s.query(ctx, PhoneIndex, "phone = :k", map[string]*dynamodb.AttributeValue{":k": {S: aws.String(phone)}})
+}
+
+func (s *DynamoBookings) ListByAgent(ctx context.Context, agentEmail string, from, to time.Time) ([]models.Booking, error) {
+	booking := normalize(models.Booking{AgentEmail: agentEmail})
+	return s.query(ctx, AgentIndex, "agent_email = :k AND #s BETWEEN :from AND :to", map[string]*dynamodb.AttributeValue{
+		":k":    {S: aws.String(booking.AgentEmail)},
+		":from": {S: aws.String(from.UTC().Format(time.RFC3339))},
+		// BETWEEN is inclusive; stop just short of to
+		":to": {S: aws.String(to.UTC().Add(-time.Second).Format(time.RFC3339))},
+	})
+}
+
+func (s *DynamoBookings) ListByProperty(ctx context.Context, propertyID string, from, to time.Time) ([]models.Booking, error) {
+	return s.query(ctx, PropertyIndex, "property_id = :k AND #s BETWEEN :from AND :to", map[string]*dynamodb.AttributeValue{
+		":k":    {S: aws.String(propertyID)},
+		":from": {S: aws.String(from.UTC().Format(time.RFC3339))},
+		// BETWEEN is inclusive; stop just short of to
+		":to": {S: aws.String(to.UTC().Add(-time.Second).Format(time.RFC3339))},
+	})
+}
+
+func (s *DynamoBookings) put(ctx context.Context, booking models.Booking, condition string, values map[string]*dynamodb.AttributeValue) error {
+	item, err := dynamodbattribute.MarshalMap(booking)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.PutItemWithContext(ctx, &dynamodb.PutItemInput{
+		TableName:                 aws.String(s.Table),
+		Item:                      item,
+		ConditionExpression:       aws.String(condition),
+		ExpressionAttributeValues: values,
+	})
+	return err
+}
+
+func (s *DynamoBookings) query(ctx context.Context, index, keyCondition string, values map[string]*dynamodb.AttributeValue) ([]models.Booking, error) {
+	input := &dynamodb.QueryInput{
+		TableName:                 aws.String(s.Table),
+		IndexName:                 aws.String(index),
+		KeyConditionExpression:    aws.String(keyCondition),
+		ExpressionAttributeValues: values,
+	}
+	// "start" is a DynamoDB reserved word; "reference" is aliased the same way
+	// in case it becomes one
+	if _, ok := values[":from"]; ok {
+		input.ExpressionAttributeNames = map[string]*string{"#s": aws.String("start")}
+	}
+	if index == ReferenceIndex {
+		input.ExpressionAttributeNames = map[string]*string{"#r": aws.String("reference")}
+	}
+
+	var bookings []models.Booking
+	var decodeErr error
+	err := s.client.QueryPagesWithContext(ctx, input, func(page *dynamodb.QueryOutput, _ bool) bool {
+		var items []models.Booking
+		if decodeErr = dynamodbattribute.UnmarshalListOfMaps(page.Items, &items); decodeErr != nil {
+			return false
+		}
+		bookings = append(bookings, items...)
+		return true
+	})
+	if err == nil {
+		err = decodeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("booking query %s: %w", index, err)
+	}
+	return bookings, nil
+}
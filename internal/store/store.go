@@ -0,0 +1,103 @@
+// Package store is the repository layer for bookings. Bookings is
+// implemented over Supabase (the default) and DynamoDB, selected with
+// BOOKING_STORE.
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/clients"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+// ErrNotFound is returned by Update when the booking doesn't exist
+var ErrNotFound = errors.New("booking not found")
+
+// ErrConflict is returned by Update when the booking changed since it was
+// read. It is retryable: re-read, re-check and write again.
+var ErrConflict = clients.ErrConflict
+
+// Bookings stores booking records. Get and GetByReference return nil, nil
+// for an unknown ID or reference.
+// Update writes the next version of the record read at booking.Version,
+// failing with ErrConflict if another write got there first. Lists are
+// ordered by start time.
+type Bookings interface {
+	Create(ctx context.Context, booking models.Booking) (models.Booking, error)
+	Get(ctx context.Context, id string) (*models.Booking, error)
+	// GetByReference finds a booking by its confirmation number
+	GetByReference(ctx context.Context, reference string) (*models.Booking, error)
+	Update(ctx context.Context, booking models.Booking) error
+	ListByPhone(ctx context.Context, phone string) ([]models.Booking, error)
+	// ListByAgent returns the agent's bookings starting in [from, to)
+	ListByAgent(ctx context.Context, agentEmail string, from, to time.Time) ([]models.Booking, error)
+	// ListByProperty returns the property's bookings starting in [from, to)
+	ListByProperty(ctx context.Context, propertyID string, from, to time.Time) ([]models.Booking, error)
+}
+
+// prepareNew fills in the ID, reference and timestamps of a booking about to
+// be created
+func prepareNew(booking models.Booking) models.Booking {
+	if booking.ID == "" {
+		booking.ID = NewID()
+	}
+	if booking.Reference == "" {
+		booking.Reference = NewReference()
+	}
+	now := time.Now().UTC()
+	booking.CreatedAt, booking.UpdatedAt = now, now
+	booking.Version = 1
+	return normalize(booking)
+}
+
+// normalize stores times in UTC and agent emails in lower case, so lookups
+// by either match regardless of how the caller formatted them
+func normalize(booking models.Booking) models.Booking {
+	booking.Start, booking.End = booking.Start.UTC(), booking.End.UTC()
+	booking.AgentEmail = strings.ToLower(booking.AgentEmail)
+	return booking
+}
+
+// NewID returns a random booking ID
+func NewID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// referenceAlphabet leaves out 0/O, 1/I/L and U/V, which are easy to mix up
+// when a reference is read aloud or written down
+const referenceAlphabet = "23456789ABCDEFGHJKMNPQRSTWXYZ"
+
+// ReferenceLength is the number of characters in a booking reference; 29^8
+// (about 5*10^11) keeps collisions negligible without a uniqueness check
+const ReferenceLength = 8
+
+// NewReference returns a random booking reference, e.g. "K7QH3WTC"
+func NewReference() string {
+	b := make([]byte, ReferenceLength)
+	rand.Read(b)
+	for i := range b {
+		// 256 isn't a multiple of the alphabet; the bias is small and a
+		// reference only needs to be hard to collide, not uniform
+		b[i] = referenceAlphabet[int(b[i])%len(referenceAlphabet)]
+	}
+	return string(b)
+}
+
+// NormalizeReference reads a reference as a prospect might give it: any
+// case, with spaces or dashes between characters
+func NormalizeReference(reference string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return unicode.ToUpper(r)
+	}, reference)
+}
@@ -0,0 +1,29 @@
+package store
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewReferenceIsSpeakable(t *testing.T) {
+	for range 100 {
+		ref := NewReference()
+		if len(ref) != ReferenceLength {
+			t.Fatalf("NewReference() = %q, want %d characters", ref, ReferenceLength)
+		}
+		if i := strings.IndexFunc(ref, func(r rune) bool { return !strings.ContainsRune(referenceAlphabet, r) }); i >= 0 {
+			t.Fatalf("NewReference() = %q, has %q outside the alphabet", ref, ref[i])
+		}
+	}
+	if strings.ContainsAny(referenceAlphabet, "0O1IL") {
+		t.Errorf("referenceAlphabet %q has ambiguous characters", referenceAlphabet)
+	}
+}
+
+func TestNormalizeReference(t *testing.T) {
+	for _, in := range []string{"K7QH3WTC", "k7qh3wtc", "K7QH-3WTC", "k7q h3w tc"} {
+		if got := NormalizeReference(in); got != "K7QH3WTC" {
+			t.Errorf("NormalizeReference(%q) = %q, want K7QH3WTC", in, got)
+		}
+	}
+}
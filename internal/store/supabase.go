@@ -0,0 +1,57 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/clients"
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+// SupabaseBookings keeps bookings in the Supabase "bookings" table
+type SupabaseBookings struct {
+	Client *clients.SupabaseClient
+}
+
+func NewSupabaseBookings(client *clients.SupabaseClient) *SupabaseBookings {
+	return &SupabaseBookings{Client: client}
+}
+
+func (s *SupabaseBookings) Create(ctx context.Context, booking models.Booking) (models.Booking, error) {
+	booking = prepareNew(booking)
+	if err := s.Client.CreateBooking(ctx, booking); err != nil {
+		return models.Booking{}, err
+	}
+	return booking, nil
+}
+
+func (s *SupabaseBookings) Get(ctx context.Context, id string) (*models.Booking, error) {
+	return s.Client.GetBooking(ctx, id)
+}
+
+func (s *SupabaseBookings) GetByReference(ctx context.Context, reference string) (*models.Booking, error) {
+	return s.Client.GetBookingByReference(ctx, reference)
+}
+
+func (s *SupabaseBookings) Update(ctx context.Context, booking models.Booking) error {
+	booking = normalize(booking)
+	booking.UpdatedAt = time.Now().UTC()
+	err := s.Client.UpdateBooking(ctx, booking)
+	if errors.Is(err, clients.ErrNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *SupabaseBookings) ListByPhone(ctx context.Context, phone string) ([]models.Booking, error) {
+	return s.Client.ListBookingsByPhone(ctx, phone)
+}
+
+func (s *SupabaseBookings) ListByAgent(ctx context.Context, agentEmail string, from, to time.Time) ([]models.Booking, error) {
+	return s.Client.ListBookingsByAgent(ctx, agentEmail, from, to)
+}
+
+func (s *SupabaseBookings) ListByProperty(ctx context.Context, propertyID string, from, to time.Time) ([]models.Booking, error) {
+	return s.Client.ListBookingsByProperty(ctx, propertyID, from, to)
+}
@@ -0,0 +1,66 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/aws/aws-xray-sdk-go/xray"
+)
+
+// DynamoCounter keeps one item per tenant and period (partition key "pk",
+// "<tenant>#<period>") with a number attribute per metric, incremented with
+// an atomic ADD. The items double as the monthly billing record.
+type DynamoCounter struct {
+	Table  string
+	client dynamodbiface.DynamoDBAPI
+}
+
+func NewDynamoCounter(sess *session.Session, table string) *DynamoCounter {
+	client := dynamodb.New(sess)
+	xray.AWS(client.Client)
+	return &DynamoCounter{Table: table, client: client}
+}
+
+func (c *DynamoCounter) Add(ctx context.Context, tenantID, period, metric string, n int64) (int64, error) {
+	out, err := c.client.UpdateItemWithContext(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(c.Table),
+		Key:                       c.key(tenantID, period),
+		UpdateExpression:          aws.String("ADD #m :n SET #t = :t, #p = :p"),
+		ExpressionAttributeNames:  map[string]*string{"#m": aws.String(metric), "#t": aws.String("tenant_id"), "#p": aws.String("period")},
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{":n": {N: aws.String(strconv.FormatInt(n, 10))}, ":t": {S: aws.String(tenantID)}, ":p": {S: aws.String(period)}},
+		ReturnValues:              aws.String(dynamodb.ReturnValueUpdatedNew),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("usage counter add: %w", err)
+	}
+	return number(out.Attributes[metric])
+}
+
+func (c *DynamoCounter) Get(ctx context.Context, tenantID, period, metric string) (int64, error) {
+	out, err := c.client.GetItemWithContext(ctx, &dynamodb.GetItemInput{
+		TableName:                aws.String(c.Table),
+		Key:                      c.key(tenantID, period),
+		ProjectionExpression:     aws.String("#m"),
+		ExpressionAttributeNames: map[string]*string{"#m": aws.String(metric)},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("usage counter get: %w", err)
+	}
+	return number(out.Item[metric])
+}
+
+func (c *DynamoCounter) key(tenantID, period string) map[string]*dynamodb.AttributeValue {
+	return map[string]*dynamodb.AttributeValue{"pk": {S: aws.String(tenantID + "#" + period)}}
+}
+
+func number(v *dynamodb.AttributeValue) (int64, error) {
+	if v == nil || v.N == nil {
+		return 0, nil
+	}
+	return strconv.ParseInt(*v.N, 10, 64)
+}
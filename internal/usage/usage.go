@@ -0,0 +1,51 @@
+// Package usage counts billable usage per tenant per calendar month and
+// checks it against the tenant's quotas.
+package usage
+
+import (
+	"context"
+	"time"
+
+	"github.com/vishnuanilkumar/go-scheduling-service/internal/models"
+)
+
+// Metered usage
+const (
+	Invocations = "invocations"
+	OpenAICalls = "openai_calls"
+	SMSSends    = "sms_sends"
+	Bookings    = "bookings"
+)
+
+// Counter is an atomic per-tenant usage counter store
+type Counter interface {
+	// Add increments metric for the tenant's period and returns the new total
+	Add(ctx context.Context, tenantID, period, metric string, n int64) (int64, error)
+	// Get returns the current total
+	Get(ctx context.Context, tenantID, period, metric string) (int64, error)
+}
+
+// Period returns the billing period containing t ("2006-01", UTC)
+func Period(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
+
+// Status is where a total stands against a quota
+type Status int
+
+const (
+	WithinQuota Status = iota
+	OverSoft           // still served; the tenant should be warned
+	OverHard           // refused
+)
+
+// Check compares total against quota; zero limits are unlimited
+func Check(total int64, quota models.UsageQuota) Status {
+	switch {
+	case quota.Hard > 0 && total > quota.Hard:
+		return OverHard
+	case quota.Soft > 0 && total > quota.Soft:
+		return OverSoft
+	}
+	return WithinQuota
+}